@@ -0,0 +1,30 @@
+package app
+
+import (
+	"github.com/womat/golib/web"
+	"log/slog"
+	"net/http"
+)
+
+// HandleAuthzRoutes returns the effective authorization policy of every registered route.
+//
+//	@Summary		Get route authorization policies
+//	@Description	This endpoint lists all registered routes with their effective authorization policy, including overrides from the config file.
+//	@Tags			info
+//	@Success		200	{object}	[]authz.Route	"Route policies successfully retrieved"
+//	@Failure		401	{object}	web.ApiError	"Unauthorized: Missing or invalid credentials"
+//	@Failure		403	{object}	web.ApiError	"Forbidden: Insufficient permissions"
+//	@Router			/api/authz/routes [get]
+//	@Security		APIKeyAuth 		"API key must be provided in the header"
+func (app *App) HandleAuthzRoutes() http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			slog.DebugContext(r.Context(), "Incoming web request for route policies",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"client_ip", r.RemoteAddr)
+
+			web.Encode(w, http.StatusOK, app.router.Routes())
+		},
+	)
+}
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"github.com/womat/go-api-template/app/service/authz"
+	"github.com/womat/go-api-template/app/service/problem"
+	"github.com/womat/golib/web"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"net/url"
+	"runtime/trace"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Default durations of the profiles if the seconds parameter is missing.
+const (
+	defaultCPUProfileDuration = 30 * time.Second
+	defaultTraceDuration      = time.Second
+)
+
+// traceMu allows only one runtime trace capture at a time, the runtime supports only one active trace.
+var traceMu sync.Mutex
+
+// initDebugRoutes registers the pprof, runtime trace and expvar endpoints under /debug/.
+// The endpoints require the admin role and are limited by withDebugLimits.
+func (app *App) initDebugRoutes() {
+	admin := authz.RequireRoles(authz.RoleAdmin)
+
+	app.router.Handle("GET /debug/pprof/", app.withDebugLimits(http.HandlerFunc(pprof.Index), 0), admin)
+	app.router.Handle("GET /debug/pprof/cmdline", app.withDebugLimits(http.HandlerFunc(pprof.Cmdline), 0), admin)
+	app.router.Handle("GET /debug/pprof/profile", app.withDebugLimits(http.HandlerFunc(pprof.Profile), defaultCPUProfileDuration), admin)
+	app.router.Handle("GET /debug/pprof/symbol", app.withDebugLimits(http.HandlerFunc(pprof.Symbol), 0), admin)
+	app.router.Handle("POST /debug/pprof/symbol", app.withDebugLimits(http.HandlerFunc(pprof.Symbol), 0), admin)
+	app.router.Handle("GET /debug/pprof/trace", app.withDebugLimits(app.HandleDebugTrace(), defaultTraceDuration), admin)
+	app.router.Handle("GET /debug/vars", app.withDebugLimits(app.HandleDebugVars(), 0), admin)
+}
+
+// withDebugLimits rejects requests from other than loopback addresses (if configured) and
+// profiles longer than the configured maximum duration (seconds parameter).
+// If the seconds parameter is missing and the default duration of the handler exceeds the maximum,
+// the maximum duration is used.
+func (app *App) withDebugLimits(h http.Handler, defaultDuration time.Duration) http.Handler {
+	cfg := app.config.Debug
+
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			slog.InfoContext(r.Context(), "Incoming web request for debug endpoint",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"query", r.URL.RawQuery,
+				"client_ip", r.RemoteAddr)
+
+			if cfg.LoopbackOnly && !isLoopback(r.RemoteAddr) {
+				problem.Write(w, r, problem.Forbidden(errors.New("debug endpoints are only available from loopback addresses")))
+				return
+			}
+
+			query := r.URL.Query()
+			d, err := profileDuration(query, defaultDuration)
+			if err != nil {
+				problem.Write(w, r, problem.Validation("invalid parameter", problem.Field("seconds", err.Error())))
+				return
+			}
+			if d > cfg.MaxProfileDuration {
+				if query.Get("seconds") != "" {
+					problem.Write(w, r, problem.Validation("invalid parameter", problem.Field("seconds", fmt.Sprintf("profile duration %v exceeds the maximum of %v", d, cfg.MaxProfileDuration))))
+					return
+				}
+				query.Set("seconds", strconv.Itoa(int(cfg.MaxProfileDuration.Seconds())))
+				r.URL.RawQuery = query.Encode()
+			}
+
+			h.ServeHTTP(w, r)
+		},
+	)
+}
+
+// profileDuration returns the duration of the seconds parameter, defaultDuration if the parameter is missing.
+// The pprof handlers accept only whole seconds, so seconds must be a positive integer.
+func profileDuration(query url.Values, defaultDuration time.Duration) (time.Duration, error) {
+	v := query.Get("seconds")
+	if v == "" {
+		return defaultDuration, nil
+	}
+
+	seconds, err := strconv.Atoi(v)
+	if err != nil || seconds <= 0 {
+		return 0, errors.New("expected a positive integer")
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// isLoopback reports whether the remote address is a loopback address.
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// HandleDebugTrace captures a runtime trace for the given duration.
+//
+//	@Summary		Capture a runtime trace
+//	@Description	This endpoint captures a runtime trace (go tool trace) for the given number of seconds (default 1s). Only one trace can be captured at a time.
+//	@Tags			debug
+//	@Param			seconds	query		integer			false	"Duration of the trace in seconds"
+//	@Success		200		{file}		binary			"Runtime trace"
+//	@Failure		400		{object}	web.ApiError	"Invalid or too long duration"
+//	@Failure		401		{object}	web.ApiError	"Unauthorized: Missing or invalid credentials"
+//	@Failure		403		{object}	web.ApiError	"Forbidden: Insufficient permissions"
+//	@Failure		409		{object}	web.ApiError	"A trace is already being captured"
+//	@Router			/debug/pprof/trace [get]
+//	@Security		APIKeyAuth 		"API key must be provided in the header"
+func (app *App) HandleDebugTrace() http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			d, err := profileDuration(r.URL.Query(), defaultTraceDuration)
+			if err != nil {
+				problem.Write(w, r, problem.Validation("invalid parameter", problem.Field("seconds", err.Error())))
+				return
+			}
+
+			if !traceMu.TryLock() {
+				problem.Write(w, r, problem.Conflict(errors.New("a trace is already being captured")))
+				return
+			}
+			defer traceMu.Unlock()
+
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", `attachment; filename="trace"`)
+			if err := trace.Start(w); err != nil {
+				// e.g. a trace started by another tool
+				w.Header().Del("Content-Disposition")
+				problem.Write(w, r, problem.Conflict(err))
+				return
+			}
+
+			slog.InfoContext(r.Context(), "Capturing runtime trace", "duration", d)
+
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+			}
+			trace.Stop()
+		},
+	)
+}
+
+// HandleDebugVars returns the published expvar variables and the application state in expvar format.
+//
+//	@Summary		Get debug variables
+//	@Description	This endpoint returns the published expvar variables (cmdline, memstats, ...), the runtime statistics, the component and job status and the number of in-flight requests.
+//	@Tags			debug
+//	@Success		200	{object}	map[string]any	"Debug variables successfully retrieved"
+//	@Failure		401	{object}	web.ApiError	"Unauthorized: Missing or invalid credentials"
+//	@Failure		403	{object}	web.ApiError	"Forbidden: Insufficient permissions"
+//	@Router			/debug/vars [get]
+//	@Security		APIKeyAuth 		"API key must be provided in the header"
+func (app *App) HandleDebugVars() http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			vars := map[string]any{}
+			expvar.Do(func(kv expvar.KeyValue) {
+				vars[kv.Key] = json.RawMessage(kv.Value.String())
+			})
+
+			vars["runtimestats"] = app.collector.Snapshot()
+			vars["components"] = app.components.Status()
+			vars["jobs"] = app.scheduler.Status()
+			vars["inFlight"] = app.inFlight.Load()
+
+			web.Encode(w, http.StatusOK, vars)
+		},
+	)
+}
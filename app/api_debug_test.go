@@ -0,0 +1,106 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestProfileDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		seconds string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"missing", "", 30 * time.Second, false},
+		{"whole seconds", "5", 5 * time.Second, false},
+		{"fraction", "1.5", 0, true},
+		{"zero", "0", 0, true},
+		{"negative", "-1", 0, true},
+		{"not a number", "abc", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{}
+			if tt.seconds != "" {
+				query.Set("seconds", tt.seconds)
+			}
+
+			got, err := profileDuration(query, 30*time.Second)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("profileDuration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("profileDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithDebugLimits(t *testing.T) {
+	tests := []struct {
+		name            string
+		maxDuration     time.Duration
+		defaultDuration time.Duration
+		query           string
+		remoteAddr      string
+		wantStatus      int
+		wantSeconds     string
+	}{
+		{"default within the maximum", 30 * time.Second, time.Second, "", "127.0.0.1:1234", http.StatusOK, ""},
+		{"default capped to the maximum", 10 * time.Second, 30 * time.Second, "", "127.0.0.1:1234", http.StatusOK, "10"},
+		{"maximum is truncated to whole seconds", 1500 * time.Millisecond, 30 * time.Second, "", "127.0.0.1:1234", http.StatusOK, "1"},
+		{"seconds within the maximum", 10 * time.Second, 30 * time.Second, "seconds=10", "127.0.0.1:1234", http.StatusOK, "10"},
+		{"seconds exceed the maximum", 10 * time.Second, 30 * time.Second, "seconds=11", "127.0.0.1:1234", http.StatusBadRequest, ""},
+		{"fractional seconds", 10 * time.Second, 30 * time.Second, "seconds=0.5", "127.0.0.1:1234", http.StatusBadRequest, ""},
+		{"zero seconds", 10 * time.Second, 30 * time.Second, "seconds=0", "127.0.0.1:1234", http.StatusBadRequest, ""},
+		{"seconds without profile", 10 * time.Second, 0, "seconds=x", "127.0.0.1:1234", http.StatusBadRequest, ""},
+		{"ipv6 loopback", 10 * time.Second, 0, "", "[::1]:1234", http.StatusOK, ""},
+		{"remote address", 10 * time.Second, 0, "", "192.0.2.1:1234", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewConfig()
+			config.Debug.LoopbackOnly = true
+			config.Debug.MaxProfileDuration = tt.maxDuration
+			app := &App{config: config}
+
+			var seconds string
+			h := app.withDebugLimits(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seconds = r.URL.Query().Get("seconds")
+			}), tt.defaultDuration)
+
+			r := httptest.NewRequest(http.MethodGet, "/debug/pprof/profile?"+tt.query, nil)
+			r.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if seconds != tt.wantSeconds {
+				t.Errorf("seconds = %q, want %q", seconds, tt.wantSeconds)
+			}
+		})
+	}
+}
+
+func TestHandleDebugTraceInvalidSeconds(t *testing.T) {
+	app := &App{config: NewConfig()}
+
+	for _, seconds := range []string{"0", "1.5", "abc"} {
+		t.Run(seconds, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			app.HandleDebugTrace().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/pprof/trace?seconds="+seconds, nil))
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
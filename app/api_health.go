@@ -1,8 +1,11 @@
 package app
 
 import (
+	"github.com/womat/go-api-template/app/service/cgroup"
+	"github.com/womat/go-api-template/app/service/encoder"
 	"github.com/womat/go-api-template/app/service/health"
-	"github.com/womat/golib/web"
+	"github.com/womat/go-api-template/app/service/host"
+	"github.com/womat/go-api-template/app/service/process"
 	"log/slog"
 	"net/http"
 )
@@ -10,21 +13,41 @@ import (
 // HandleHealth returns data about the health of the application.
 //
 //	@Summary		Get health data
-//	@Description	Retrieves the health data for the application, including memory usage, goroutine count, and version.
+//	@Description	Retrieves the health data for the application, including memory usage, goroutine count, version, gc statistics, container limits, process- and host-level metrics and the status of the application components.
 //	@Tags			info
+//	@Produce		json,yaml,csv,plain,xml
+//	@Param			format	query	string	false	"Response format: json | yaml | csv | text | xml, default from the Accept header"
+//	@Param			pretty	query	bool	false	"Indent the output (json, xml)"
+//	@Param			fields	query	string	false	"Comma separated list of dotted fields to return, e.g. Version,Components"
 //	@Success		200	{object}	health.Model	"Health data successfully retrieved"
+//	@Failure		400	{object}	web.ApiError	"Invalid format"
 //	@Failure		403	{object}	web.ApiError	"Forbidden: Insufficient permissions"
 //	@Router			/api/health [get]
 func (app *App) HandleHealth() http.Handler {
 	return http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
-			slog.Info("Incoming web request for health check",
+			slog.InfoContext(r.Context(), "Incoming web request for health check",
 				"method", r.Method,
 				"path", r.URL.Path,
 				"client_ip", r.RemoteAddr)
 
-			resp := health.Health(VERSION)
-			web.Encode(w, http.StatusOK, resp)
+			var containerStats *cgroup.Stats
+			if app.cgroup != nil {
+				containerStats = app.cgroup.Stats()
+			}
+
+			var processStats *process.Stats
+			if app.process != nil {
+				processStats = app.process.Stats()
+			}
+
+			var hostStats *host.Stats
+			if app.host != nil {
+				hostStats = app.host.Stats()
+			}
+
+			resp := health.Health(VERSION, app.collector.Snapshot(), containerStats, processStats, hostStats, app.components.Status())
+			encoder.Write(w, r, http.StatusOK, resp)
 		},
 	)
 }
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"errors"
+	"github.com/womat/go-api-template/app/service/history"
+	"github.com/womat/go-api-template/app/service/problem"
+	"github.com/womat/golib/web"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// HandleMonitoringHistory returns the history of a monitoring value aggregated into buckets.
+//
+//	@Summary		Get monitoring history
+//	@Description	This endpoint returns min, max and average of a monitoring value per bucket.
+//	@Description	from and to are RFC3339 times or durations relative to now (e.g. 1h means one hour ago).
+//	@Tags			info
+//	@Param			service	query		string	true	"Service name, e.g. Heap Alloc"
+//	@Param			from	query		string	false	"Start of the range, default 1h"
+//	@Param			to		query		string	false	"End of the range, default now"
+//	@Param			step	query		string	false	"Bucket duration, e.g. 5m, default is the resolution of the history tier"
+//	@Success		200		{object}	app.HandleMonitoringHistory.Response	"History successfully retrieved"
+//	@Failure		400		{object}	web.ApiError	"Invalid parameters"
+//	@Failure		401		{object}	web.ApiError	"Unauthorized: Missing or invalid credentials"
+//	@Failure		404		{object}	web.ApiError	"Unknown service"
+//	@Router			/api/monitoring/history [get]
+//	@Security		APIKeyAuth 		"API key must be provided in the header"
+func (app *App) HandleMonitoringHistory() http.Handler {
+	type Response struct {
+		Service string          `json:"service"`
+		From    time.Time       `json:"from"`
+		To      time.Time       `json:"to"`
+		Step    string          `json:"step"`
+		Points  []history.Point `json:"points"`
+	}
+
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			slog.DebugContext(r.Context(), "Incoming web request for monitoring history",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"query", r.URL.RawQuery,
+				"client_ip", r.RemoteAddr)
+
+			q := r.URL.Query()
+			now := time.Now()
+
+			service := q.Get("service")
+			if service == "" {
+				problem.Write(w, r, problem.Validation("missing parameter", problem.Field("service", "is required")))
+				return
+			}
+
+			from, err := parseTime(q.Get("from"), now, now.Add(-time.Hour))
+			if err != nil {
+				problem.Write(w, r, problem.Validation("invalid parameter", problem.Field("from", err.Error())))
+				return
+			}
+
+			to, err := parseTime(q.Get("to"), now, now)
+			if err != nil {
+				problem.Write(w, r, problem.Validation("invalid parameter", problem.Field("to", err.Error())))
+				return
+			}
+
+			var step time.Duration
+			if s := q.Get("step"); s != "" {
+				if step, err = time.ParseDuration(s); err != nil {
+					problem.Write(w, r, problem.Validation("invalid parameter", problem.Field("step", err.Error())))
+					return
+				}
+			}
+
+			points, step, err := app.history.Query(service, from, to, step)
+			switch {
+			case errors.Is(err, history.ErrUnknownService):
+				problem.Write(w, r, problem.NotFound(err))
+				return
+			case err != nil:
+				problem.Write(w, r, problem.Validation(err.Error()))
+				return
+			}
+
+			web.Encode(w, http.StatusOK, Response{Service: service, From: from, To: to, Step: step.String(), Points: points})
+		},
+	)
+}
+
+// parseTime parses an RFC3339 time or a duration relative to now (e.g. 1h means one hour ago).
+// def is returned if s is empty.
+func parseTime(s string, now, def time.Time) (time.Time, error) {
+	if s == "" {
+		return def, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, errors.New("expected RFC3339 time or duration")
+	}
+	if d < 0 {
+		d = -d
+	}
+	return now.Add(-d), nil
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"errors"
+	"github.com/womat/go-api-template/app/service/problem"
+	"github.com/womat/go-api-template/app/service/scheduler"
+	"github.com/womat/golib/web"
+	"log/slog"
+	"net/http"
+)
+
+// HandleJobs returns the status of the scheduled jobs.
+//
+//	@Summary		Get scheduled jobs
+//	@Description	This endpoint returns the schedule, last run, duration, next run and last error of every scheduled job.
+//	@Tags			jobs
+//	@Success		200	{object}	[]scheduler.JobStatus	"Job status successfully retrieved"
+//	@Failure		401	{object}	web.ApiError			"Unauthorized: Missing or invalid credentials"
+//	@Router			/api/jobs [get]
+//	@Security		APIKeyAuth 		"API key must be provided in the header"
+func (app *App) HandleJobs() http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			slog.DebugContext(r.Context(), "Incoming web request for jobs",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"client_ip", r.RemoteAddr)
+
+			web.Encode(w, http.StatusOK, app.scheduler.Status())
+		},
+	)
+}
+
+// HandleJobRun triggers a run of a job, independent of its schedule.
+//
+//	@Summary		Trigger a job
+//	@Description	This endpoint starts a run of the job immediately. A job which is already running is not started again.
+//	@Tags			jobs
+//	@Param			name	path		string					true	"Job name"
+//	@Success		202		{object}	app.HandleJobRun.Response	"Job run started"
+//	@Failure		401		{object}	web.ApiError			"Unauthorized: Missing or invalid credentials"
+//	@Failure		403		{object}	web.ApiError			"Forbidden: Insufficient permissions"
+//	@Failure		404		{object}	web.ApiError			"Job not found"
+//	@Failure		409		{object}	web.ApiError			"Job is already running"
+//	@Router			/api/jobs/{name}/run [post]
+//	@Security		APIKeyAuth 		"API key must be provided in the header"
+func (app *App) HandleJobRun() http.Handler {
+	type Response struct {
+		Job    string `json:"job"`
+		Status string `json:"status"`
+	}
+
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			name := r.PathValue("name")
+			slog.InfoContext(r.Context(), "Incoming web request to trigger job",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"client_ip", r.RemoteAddr,
+				"job", name)
+
+			err := app.scheduler.Trigger(name)
+			switch {
+			case errors.Is(err, scheduler.ErrJobNotFound):
+				problem.Write(w, r, problem.NotFound(err))
+			case errors.Is(err, scheduler.ErrJobRunning):
+				problem.Write(w, r, problem.Conflict(err))
+			case err != nil:
+				problem.Write(w, r, problem.Unavailable(err))
+			default:
+				web.Encode(w, http.StatusAccepted, Response{Job: name, Status: "started"})
+			}
+		},
+	)
+}
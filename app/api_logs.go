@@ -0,0 +1,164 @@
+package app
+
+import (
+	"encoding/json"
+	"github.com/womat/go-api-template/app/service/logging"
+	"github.com/womat/go-api-template/app/service/problem"
+	"github.com/womat/golib/web"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultLogsLimit is the default number of records returned by /api/logs.
+const defaultLogsLimit = 100
+
+// HandleLogs returns the last log records kept in the log buffer.
+//
+//	@Summary		Get recent log records
+//	@Description	This endpoint returns the last log records kept in memory, oldest first.
+//	@Description	from and to are RFC3339 times or durations relative to now (e.g. 1h means one hour ago).
+//	@Description	With follow=1 the matching records are streamed as newline delimited json and new records are sent as they are logged.
+//	@Tags			info
+//	@Param			level		query		string	false	"Minimum log level: debug | info | warning | error"
+//	@Param			from		query		string	false	"Start of the time range"
+//	@Param			to			query		string	false	"End of the time range, ignored with follow=1"
+//	@Param			request_id	query		string	false	"Request id, e.g. from the X-Request-ID response header"
+//	@Param			q			query		string	false	"Text searched in the message and the attributes (case-insensitive)"
+//	@Param			limit		query		int		false	"Maximum number of records, the newest are returned, default 100"
+//	@Param			follow		query		bool	false	"Stream new records until the client disconnects or the instance shuts down"
+//	@Success		200			{object}	app.HandleLogs.Response	"Log records successfully retrieved"
+//	@Failure		400			{object}	web.ApiError	"Invalid parameters"
+//	@Failure		401			{object}	web.ApiError	"Unauthorized: Missing or invalid credentials"
+//	@Failure		403			{object}	web.ApiError	"Forbidden: Insufficient permissions"
+//	@Router			/api/logs [get]
+//	@Security		APIKeyAuth 		"API key must be provided in the header"
+func (app *App) HandleLogs() http.Handler {
+	type Response struct {
+		Size    int              `json:"size"`
+		Records []logging.Record `json:"records"`
+	}
+
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			slog.DebugContext(r.Context(), "Incoming web request for logs",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"query", r.URL.RawQuery,
+				"client_ip", r.RemoteAddr)
+
+			filter, follow, err := parseLogFilter(r)
+			if err != nil {
+				problem.Write(w, r, err)
+				return
+			}
+
+			if !follow {
+				web.Encode(w, http.StatusOK, Response{Size: app.logs.Size(), Records: app.logs.Query(filter)})
+				return
+			}
+
+			app.streamLogs(w, r, filter)
+		},
+	)
+}
+
+// streamLogs writes the matching records as newline delimited json and sends new records as they are logged,
+// until the client disconnects or the web server starts draining (shutdown or restart).
+func (app *App) streamLogs(w http.ResponseWriter, r *http.Request, filter logging.Filter) {
+	// subscribe before the query to not miss records, records already sent are skipped by their sequence number
+	records, cancel := app.logs.Subscribe()
+	defer cancel()
+
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	var last uint64
+	for _, rec := range app.logs.Query(filter) {
+		if err := enc.Encode(rec); err != nil {
+			return
+		}
+		last = rec.Seq
+	}
+	if err := rc.Flush(); err != nil {
+		slog.WarnContext(r.Context(), "Streaming of log records not supported", "error", err)
+		return
+	}
+
+	filter.To = time.Time{}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-app.draining.Done():
+			return
+		case rec := <-records:
+			if rec.Seq <= last || !filter.Match(rec) {
+				continue
+			}
+			if err := enc.Encode(rec); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// parseLogFilter returns the filter and the follow flag of the /api/logs query parameters.
+// All invalid parameters are reported in a validation error.
+func parseLogFilter(r *http.Request) (logging.Filter, bool, error) {
+	q := r.URL.Query()
+	now := time.Now()
+
+	filter := logging.Filter{
+		MinLevel:  slog.LevelDebug,
+		RequestID: q.Get("request_id"),
+		Text:      q.Get("q"),
+		Limit:     defaultLogsLimit,
+	}
+
+	var fields []problem.FieldError
+	var err error
+
+	if s := q.Get("level"); s != "" {
+		if strings.EqualFold(s, "warning") {
+			s = "warn"
+		}
+		if err = filter.MinLevel.UnmarshalText([]byte(s)); err != nil {
+			fields = append(fields, problem.Field("level", "expected debug, info, warning or error"))
+		}
+	}
+
+	if filter.From, err = parseTime(q.Get("from"), now, time.Time{}); err != nil {
+		fields = append(fields, problem.Field("from", err.Error()))
+	}
+	if filter.To, err = parseTime(q.Get("to"), now, time.Time{}); err != nil {
+		fields = append(fields, problem.Field("to", err.Error()))
+	}
+
+	if s := q.Get("limit"); s != "" {
+		if filter.Limit, err = strconv.Atoi(s); err != nil || filter.Limit < 1 {
+			fields = append(fields, problem.Field("limit", "expected a positive number"))
+		}
+	}
+
+	var follow bool
+	if s := q.Get("follow"); s != "" {
+		if follow, err = strconv.ParseBool(s); err != nil {
+			fields = append(fields, problem.Field("follow", "expected a boolean"))
+		}
+	}
+
+	if len(fields) > 0 {
+		return filter, false, problem.Validation("invalid parameters", fields...)
+	}
+	return filter, follow, nil
+}
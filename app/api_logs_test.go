@@ -0,0 +1,57 @@
+package app
+
+import (
+	"crypto/tls"
+	"github.com/womat/go-api-template/app/service/logging"
+	"github.com/womat/go-api-template/app/service/secret"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFollowStreamEndsOnShutdown(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	config := testConfig(t)
+	config.HttpsServer.ApiKey = secret.String("12345678")
+	config.Shutdown.DrainTimeout = 10 * time.Second
+	a, err := New(config).WithLogBuffer(logging.NewBuffer(10, slog.LevelInfo)).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://"+net.JoinHostPort(config.HttpsServer.ListenHost, config.HttpsServer.ListenPort)+"/api/logs?follow=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Api-Key", "12345678")
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// the open stream doesn't hold up the drain until the drain timeout
+	start := time.Now()
+	go a.shutdownProcedure("shutdown")
+
+	select {
+	case <-a.Shutdown():
+	case <-time.After(5 * time.Second):
+		t.Fatal("application didn't shut down while a follow stream was open")
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("shutdown took %v, want the stream to end when the drain starts", d)
+	}
+
+	if _, err = io.ReadAll(resp.Body); err != nil {
+		t.Errorf("stream ended with error %v, want the end of the response", err)
+	}
+}
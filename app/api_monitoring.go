@@ -1,18 +1,33 @@
 package app
 
 import (
+	"fmt"
+	"github.com/womat/go-api-template/app/service/encoder"
+	"github.com/womat/go-api-template/app/service/host"
 	"github.com/womat/go-api-template/app/service/monitoring"
-	"github.com/womat/golib/web"
+	"github.com/womat/go-api-template/app/service/problem"
+	"github.com/womat/go-api-template/app/service/process"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // HandleMonitoring returns monitoring data for WATCHIT system.
 //
 //	@Summary		Get monitoring data for WATCHIT
-//	@Description	This endpoint returns monitoring data for the WATCHIT system, including health, performance metrics, and system status.
+//	@Description	This endpoint returns monitoring data for the WATCHIT system, including health, performance metrics, system status, the status of the scheduled jobs and the application metrics. The first entry summarises the worst state of all services.
 //	@Tags			info
-//	@Success		200	{object}	[]monitoring.Model	"Monitoring data successfully retrieved"
+//	@Description	The response format is selected with the version parameter (default from the config file):
+//	@Description	1 is the WATCHIT compatible list of entries with the overall state as first entry, 2 is a versioned object.
+//	@Param			version	query	int	false	"Response version: 1 (WATCHIT) or 2"
+//	@Produce		json,yaml,csv,plain,xml
+//	@Param			format	query	string	false	"Response format: json | yaml | csv | text | xml, default from the Accept header"
+//	@Param			pretty	query	bool	false	"Indent the output (json, xml)"
+//	@Param			fields	query	string	false	"Comma separated list of dotted fields to return, e.g. services.Service,services.Value"
+//	@Success		200	{object}	[]monitoring.Model	"Monitoring data successfully retrieved (version 1)"
+//	@Success		200	{object}	monitoring.Response	"Monitoring data successfully retrieved (version 2)"
+//	@Failure		400	{object}	web.ApiError		"Invalid response version or format"
 //	@Failure		403	{object}	web.ApiError		"Forbidden: Insufficient permissions"
 //	@Failure		500	{object}	web.ApiError		"Internal server error"
 //	@Router			/api/monitoring [get]
@@ -21,19 +36,74 @@ func (app *App) HandleMonitoring() http.Handler {
 
 	return http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
-			slog.Info("Incoming web request for monitoring info",
+			slog.InfoContext(r.Context(), "Incoming web request for monitoring info",
 				"method", r.Method,
 				"path", r.URL.Path,
 				"client_ip", r.RemoteAddr)
 
-			resp, err := monitoring.Monitoring(r.Host, VERSION)
+			version := app.config.Monitoring.ResponseVersion
+			if v := r.URL.Query().Get("version"); v != "" {
+				version, _ = strconv.Atoi(v)
+			}
+			if version != monitoring.ResponseV1 && version != monitoring.ResponseV2 {
+				problem.Write(w, r, problem.Validation("invalid parameter", problem.Field("version", fmt.Sprintf("unsupported response version %q", r.URL.Query().Get("version")))))
+				return
+			}
+
+			resp, err := app.monitoringData(monitoring.HostName(r.Host))
 			if err != nil {
-				slog.Error("Error retrieving monitoring data", "error", err)
-				web.Encode(w, http.StatusInternalServerError, web.NewApiError(err))
+				problem.Write(w, r, problem.Internal(err))
 				return
 			}
 
-			web.Encode(w, http.StatusOK, resp)
+			if version == monitoring.ResponseV1 {
+				encoder.Write(w, r, http.StatusOK, resp.Legacy())
+				return
+			}
+			encoder.Write(w, r, http.StatusOK, resp, encoder.Rows("services"))
 		},
 	)
 }
+
+// monitoringData returns the monitoring data of the runtime, the process, the host, the scheduled jobs and the application metrics,
+// evaluated against the thresholds and labeled with the instance labels.
+func (app *App) monitoringData(hostName string) (monitoring.Response, error) {
+	services, err := app.services(hostName)
+	if err != nil {
+		return monitoring.Response{}, err
+	}
+
+	resp := app.evaluator.Evaluate(hostName, services)
+	resp.Services = app.metrics.Label(resp.Services)
+	return resp, nil
+}
+
+// services returns the monitoring values of the runtime, the process, the host, the scheduled jobs and the application metrics.
+// The values aren't evaluated, so the hysteresis of the thresholds isn't affected (e.g. by the history).
+func (app *App) services(hostName string) ([]monitoring.Model, error) {
+	services, err := monitoring.Monitoring(hostName, VERSION, app.collector.Snapshot())
+	if err != nil {
+		return nil, err
+	}
+
+	if app.process != nil {
+		services = append(services, process.Monitoring(hostName, app.process.Stats())...)
+	}
+	if app.host != nil {
+		services = append(services, host.Monitoring(hostName, app.host.Stats())...)
+	}
+	services = append(services, app.scheduler.Monitoring(hostName)...)
+	return app.metrics.Collect(hostName, services), nil
+}
+
+// LocalMonitoring returns the host-level monitoring data (cpu, load, memory, disks, temperatures, network)
+// read by the calling process, e.g. the check command, without a running instance.
+// The cpu usage is measured over the given interval and the states are evaluated against the configured thresholds.
+func LocalMonitoring(config *Config, hostName string, interval time.Duration) monitoring.Response {
+	reader := host.NewReader(config.Monitoring.Host.Root, config.Monitoring.Host.MountPoints)
+	reader.Read()
+	time.Sleep(interval)
+
+	services := host.Monitoring(hostName, reader.Read())
+	return monitoring.NewEvaluator(config.Monitoring.Thresholds).Evaluate(hostName, services)
+}
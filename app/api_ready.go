@@ -0,0 +1,40 @@
+package app
+
+import (
+	"errors"
+	"github.com/womat/go-api-template/app/service/problem"
+	"github.com/womat/golib/web"
+	"log/slog"
+	"net/http"
+)
+
+// HandleReady returns whether the instance is ready to accept traffic.
+// The instance is not ready during the shutdown procedure, so load balancers can stop routing requests to it.
+//
+//	@Summary		Get readiness
+//	@Description	Returns 200 if the instance accepts traffic and 503 while it is shutting down.
+//	@Tags			info
+//	@Success		200	{object}	app.HandleReady.Response	"Instance is ready"
+//	@Failure		503	{object}	web.ApiError				"Instance is not ready"
+//	@Router			/api/ready [get]
+func (app *App) HandleReady() http.Handler {
+	type Response struct {
+		Ready bool `json:"ready"`
+	}
+
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			slog.DebugContext(r.Context(), "Incoming web request for readiness",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"client_ip", r.RemoteAddr)
+
+			if !app.ready.Load() {
+				problem.Write(w, r, problem.Unavailable(errors.New("not ready")))
+				return
+			}
+
+			web.Encode(w, http.StatusOK, Response{Ready: true})
+		},
+	)
+}
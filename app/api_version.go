@@ -6,25 +6,63 @@ import (
 	"net/http"
 )
 
-// HandleVersion returns the version and name of the application.
+// VersionInfo is the response of the public version endpoint.
+// The module dependencies aren't included, they are only available at the authenticated sbom endpoint and with -version -json.
+type VersionInfo struct {
+	// Name is the name of the application.
+	Name string `json:"name"`
+
+	// Version is the version of the application.
+	Version string `json:"version"`
+
+	// Revision is the short vcs revision the binary was built from, followed by "-dirty" if the working tree was modified.
+	Revision string `json:"revision,omitempty"`
+
+	// GoVersion is the version of the Go toolchain that built the binary.
+	GoVersion string `json:"goVersion"`
+}
+
+// HandleVersion returns the name, version, vcs revision and go version of the application.
 //
 //	@Summary		Get application version and name
 //	@Description	This endpoint returns the name and version of the application to help with debugging and monitoring.
+//	@Description	The vcs revision and go version are added, the module dependencies are only available at /api/sbom.
 //	@Tags			info
-//	@Success		200	{object}	app.HandleVersion.Response	"Application version and name successfully retrieved"
+//	@Success		200	{object}	app.VersionInfo	"Application version and name successfully retrieved"
 //	@Router			/api/version [get]
 func (app *App) HandleVersion() http.Handler {
-	type Response struct {
-		Name    string `json:"name"`
-		Version string `json:"version"`
-	}
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			slog.DebugContext(r.Context(), "Incoming web request for version info",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"client_ip", r.RemoteAddr)
+			info := BuildInfo()
+			web.Encode(w, http.StatusOK, VersionInfo{
+				Name:      info.Name,
+				Version:   info.Version,
+				Revision:  info.ShortRevision(),
+				GoVersion: info.GoVersion,
+			})
+		})
+}
 
+// HandleSBOM returns the software bill of materials (CycloneDX) of the application.
+//
+//	@Summary		Get software bill of materials
+//	@Description	This endpoint returns the module dependencies of the application as CycloneDX json, if enabled in the config file.
+//	@Tags			info
+//	@Success		200	{object}	buildinfo.SBOM	"Software bill of materials successfully retrieved"
+//	@Failure		401	{object}	web.ApiError	"Unauthorized: Missing or invalid credentials"
+//	@Router			/api/sbom [get]
+//	@Security		APIKeyAuth 		"API key must be provided in the header"
+func (app *App) HandleSBOM() http.Handler {
 	return http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
-			slog.Debug("Incoming web request for version info",
+			slog.DebugContext(r.Context(), "Incoming web request for sbom",
 				"method", r.Method,
 				"path", r.URL.Path,
 				"client_ip", r.RemoteAddr)
-			web.Encode(w, http.StatusOK, Response{Version: VERSION, Name: MODULE})
+			web.Encode(w, http.StatusOK, BuildInfo().SBOM())
 		})
 }
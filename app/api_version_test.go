@@ -0,0 +1,49 @@
+package app
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestHandleVersion(t *testing.T) {
+	w := httptest.NewRecorder()
+	(&App{}).HandleVersion().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/version", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	// the public endpoint is limited to name, version, revision and go version
+	allowed := []string{"name", "version", "revision", "goVersion"}
+	for _, k := range slices.Sorted(maps.Keys(got)) {
+		if !slices.Contains(allowed, k) {
+			t.Errorf("response contains %q, want only %q", k, allowed)
+		}
+	}
+
+	info := BuildInfo()
+	tests := []struct {
+		key  string
+		want any
+	}{
+		{"name", MODULE},
+		{"version", VERSION},
+		{"goVersion", info.GoVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got[tt.key] != tt.want {
+				t.Errorf("%s = %v, want %v", tt.key, got[tt.key], tt.want)
+			}
+		})
+	}
+}
@@ -2,12 +2,27 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/womat/go-api-template/app/service/authz"
+	"github.com/womat/go-api-template/app/service/buildinfo"
+	"github.com/womat/go-api-template/app/service/cgroup"
+	"github.com/womat/go-api-template/app/service/crash"
+	"github.com/womat/go-api-template/app/service/history"
+	"github.com/womat/go-api-template/app/service/host"
+	"github.com/womat/go-api-template/app/service/lifecycle"
+	"github.com/womat/go-api-template/app/service/logging"
+	"github.com/womat/go-api-template/app/service/monitoring"
+	"github.com/womat/go-api-template/app/service/process"
+	"github.com/womat/go-api-template/app/service/runtimestats"
+	"github.com/womat/go-api-template/app/service/scheduler"
+	"github.com/womat/go-api-template/app/service/tracing"
 	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -22,11 +37,21 @@ import (
 // VERSION differs from semantic versioning as described in https://semver.org/
 // but we keep the correct syntax.
 // TODO: increase version number to 1.0.1+2020xxyy
-const (
-	VERSION = "0.0.0+yyyymmdd"
-	MODULE  = "<MODUL_NAME>"
+//
+// VERSION, MODULE and BUILDTIME are set at build time, e.g.
+//
+//	go build -ldflags "-X github.com/womat/go-api-template/app.VERSION=1.0.1+20250301 -X github.com/womat/go-api-template/app.BUILDTIME=2025-03-01T12:00:00Z" ./cmd/app
+var (
+	VERSION   = "0.0.0+yyyymmdd"
+	MODULE    = "<MODUL_NAME>"
+	BUILDTIME = ""
 )
 
+// BuildInfo returns the build information of the application (version, vcs revision, go version, dependencies).
+func BuildInfo() buildinfo.Info {
+	return buildinfo.Read(MODULE, VERSION, BUILDTIME)
+}
+
 // App is the main application struct.
 // App is where the application is wired up.
 type App struct {
@@ -34,9 +59,68 @@ type App struct {
 	// config is the application configuration
 	config *Config
 
+	// ctx is the root context of the application, it's created in Run and cancelled in the shutdown procedure
+	// after the web server was drained. Request contexts and the contexts of components are derived from it.
+	ctx context.Context
+
+	// cancel cancels the root context.
+	cancel context.CancelFunc
+
 	// web is the web server.
 	web *http.Server
 
+	// draining is cancelled when the web server starts draining. Streaming responses (e.g. /api/logs?follow=1)
+	// end then, otherwise they would hold up the drain until the drain timeout.
+	draining context.Context
+
+	// router holds the registered routes and their authorization policies.
+	router *authz.Router
+
+	// tracer is the OpenTelemetry tracer provider, nil if tracing is disabled.
+	tracer *tracing.Provider
+
+	// components is the registry of the application components (e.g. db pools, mqtt clients, schedulers).
+	components *lifecycle.Registry
+
+	// scheduler runs the periodic jobs of the application.
+	scheduler *scheduler.Scheduler
+
+	// evaluator evaluates the monitoring thresholds, it keeps the service states between requests.
+	evaluator *monitoring.Evaluator
+
+	// metrics holds the metrics published by the application code.
+	metrics *monitoring.Registry
+
+	// panics counts the panics recovered in http handlers.
+	panics *monitoring.Counter
+
+	// crash writes the crash reports, nil if disabled.
+	crash *crash.Reporter
+
+	// collector samples the runtime statistics in the background for the health and monitoring endpoints.
+	collector *runtimestats.Collector
+
+	// cgroup reads the cgroup limits and usage in the background, nil if not running in a cgroup.
+	cgroup *cgroup.Collector
+
+	// process reads the process-level metrics in the background, nil if disabled.
+	process *process.Collector
+
+	// host reads the host-level metrics in the background, nil if disabled.
+	host *host.Collector
+
+	// history keeps the time series of the monitoring values, nil if disabled.
+	history *history.Store
+
+	// logs keeps the last log records for the /api/logs endpoint, nil if not set.
+	logs *logging.Buffer
+
+	// ready reports whether the instance accepts traffic, it's cleared at the start of the shutdown procedure.
+	ready atomic.Bool
+
+	// inFlight is the number of requests currently being served.
+	inFlight atomic.Int64
+
 	// restart signals application restart
 	restart chan struct{}
 
@@ -47,13 +131,28 @@ type App struct {
 // New checks the Web server URL and initializes the main app structure
 func New(config *Config) *App {
 
-	return &App{
+	app := &App{
 		config: config,
 		web:    &http.Server{},
 
+		components: lifecycle.NewRegistry(config.Shutdown.ComponentTimeout, config.Monitoring.CollectInterval),
+		scheduler:  scheduler.New(),
+		evaluator:  monitoring.NewEvaluator(config.Monitoring.Thresholds),
+		metrics:    monitoring.NewRegistry(config.Monitoring.Labels),
+		collector:  runtimestats.New(config.Monitoring.CollectInterval),
+
 		restart:  make(chan struct{}),
 		shutdown: make(chan struct{}),
 	}
+
+	app.panics = app.metrics.Counter("http_panics_total", "Number of panics recovered in http handlers", "route")
+	return app
+}
+
+// WithLogBuffer sets the buffer of the last log records, it's queried by the /api/logs endpoint.
+func (app *App) WithLogBuffer(b *logging.Buffer) *App {
+	app.logs = b
+	return app
 }
 
 // Run starts the application.
@@ -62,7 +161,15 @@ func New(config *Config) *App {
 func (app *App) Run() (*App, error) {
 	slog.Info("Initializing application")
 
+	app.ctx, app.cancel = context.WithCancel(context.Background())
+	app.web.BaseContext = func(net.Listener) context.Context { return app.ctx }
+
+	var stopStreams context.CancelFunc
+	app.draining, stopStreams = context.WithCancel(app.ctx)
+	app.web.RegisterOnShutdown(stopStreams)
+
 	if err := app.Init(); err != nil {
+		app.abort()
 		return app, err
 	}
 
@@ -74,21 +181,177 @@ func (app *App) Run() (*App, error) {
 	err := app.StartWebServer()
 	if err != nil {
 		slog.Error("Web server failed to start", "url", webServerAddress, "error", err)
+		app.abort()
 		return app, err
 	}
 
-	slog.Info(fmt.Sprintf("%s started successfully", MODULE), "version", VERSION, "pid", os.Getpid())
+	app.ready.Store(true)
+	slog.Info(fmt.Sprintf("%s started successfully", MODULE), "version", VERSION, "revision", BuildInfo().ShortRevision(), "pid", os.Getpid())
 	return app, nil
 }
 
+// abort releases the resources of a failed start, the components started by Init are stopped again.
+// Cancelling the root context also stops the signal handler.
+func (app *App) abort() {
+	app.cancel()
+	if err := app.Cleanup(); err != nil {
+		slog.Error("Cleanup after failed start failed", "error", err)
+	}
+}
+
 // Init is called by Run() and should be used to initialize the application.
 func (app *App) Init() (err error) {
 
+	app.initRuntimeLimits()
+	// take a new sample to report the limits in effect
+	app.collector.Collect()
+
+	if cfg := app.config.CrashReport; cfg.Dir != "" {
+		app.crash = crash.NewReporter(cfg.Dir, cfg.MaxReports, VERSION, app.config.Hash())
+		app.reportPreviousCrashes()
+	}
+
+	if cfg := app.config.Tracing; cfg.Enabled {
+		slog.Info("Initializing tracing", "exporter", cfg.Exporter, "endpoint", cfg.Endpoint, "file", cfg.File)
+
+		headers := make(map[string]string, len(cfg.Headers))
+		for k, v := range cfg.Headers {
+			headers[k] = v.Value()
+		}
+
+		app.tracer, err = tracing.Init(app.ctx, tracing.Config{
+			ServiceName:    MODULE,
+			ServiceVersion: VERSION,
+			Exporter:       cfg.Exporter,
+			Endpoint:       cfg.Endpoint,
+			Headers:        headers,
+			File:           cfg.File,
+			SampleRatio:    cfg.SampleRatio,
+		})
+		if err != nil {
+			return err
+		}
+
+		// shut the tracer down if the application fails to initialize
+		defer func() {
+			if err != nil {
+				_ = app.tracer.Shutdown(context.Background())
+				app.tracer = nil
+			}
+		}()
+	}
+
+	if err = app.Register(app.collector); err != nil {
+		return err
+	}
+
+	if app.cgroup != nil {
+		if err = app.Register(app.cgroup); err != nil {
+			return err
+		}
+	}
+
+	if app.config.Monitoring.Process.Enabled {
+		app.process = process.NewCollector(process.NewReader(), app.config.Monitoring.CollectInterval)
+		if err = app.Register(app.process); err != nil {
+			return err
+		}
+	}
+
+	if cfg := app.config.Monitoring.Host; cfg.Enabled {
+		app.host = host.NewCollector(host.NewReader(cfg.Root, cfg.MountPoints), app.config.Monitoring.CollectInterval)
+		if err = app.Register(app.host); err != nil {
+			return err
+		}
+	}
+
+	if cfg := app.config.Monitoring.History; cfg.Enabled {
+		hostName, _ := os.Hostname()
+		source := func() []monitoring.Model {
+			services, _ := app.services(hostName)
+			return services
+		}
+		if app.history, err = history.New(cfg.Tiers, source, cfg.File); err != nil {
+			return err
+		}
+		if err = app.Register(app.history, lifecycle.DependsOn(app.components.Names()...)); err != nil {
+			return err
+		}
+	}
+
+	// Register your application components here, e.g.:
+	//	if err = app.Register(db, lifecycle.WithStopTimeout(10*time.Second)); err != nil {
+	//		return err
+	//	}
+	//	if err = app.Register(mqttClient, lifecycle.DependsOn(db.Name())); err != nil {
+	//		return err
+	//	}
+
+	// Add your periodic jobs here, e.g.:
+	//	schedule, _ := scheduler.Parse("@every 10m")
+	//	if err = app.AddJob(scheduler.Job{Name: "cleanup", Schedule: schedule, Func: cleanup, Timeout: time.Minute}); err != nil {
+	//		return err
+	//	}
+
+	// the scheduler is registered last, so jobs can use all other components
+	if err = app.Register(app.scheduler, lifecycle.DependsOn(app.components.Names()...)); err != nil {
+		return err
+	}
+
+	slog.Info("Starting components")
+	if err = app.components.Start(app.ctx); err != nil {
+		return err
+	}
+
 	// initRoutes should always be called at the end
 	slog.Info("Initializing API routes")
-	app.InitRoutes()
+	return app.InitRoutes()
+}
+
+// Context returns the root context of the application.
+// The context is cancelled in the shutdown procedure (shutdown, restart and terminate) after the in-flight requests
+// were drained, background workers should use it to tie their lifetime to the application.
+func (app *App) Context() context.Context {
+	return app.ctx
+}
+
+// Metrics returns the registry for application metrics.
+// The values of the registered counters, gauges and histograms are part of /api/monitoring.
+func (app *App) Metrics() *monitoring.Registry {
+	return app.metrics
+}
 
-	return nil
+// Register adds a component to the application.
+// Registered components are started in dependency order by Init and stopped in reverse order by Cleanup.
+func (app *App) Register(c lifecycle.Component, opts ...lifecycle.Option) error {
+	return app.components.Register(c, opts...)
+}
+
+// AddJob adds a periodic job to the scheduler.
+// The schedule, timeout and jitter can be overridden in the scheduler section of the config file,
+// disabled jobs are not added.
+func (app *App) AddJob(job scheduler.Job) error {
+	if cfg, ok := app.config.Scheduler.Jobs[job.Name]; ok {
+		if cfg.Disabled {
+			slog.Info("Job disabled by configuration", "job", job.Name)
+			return nil
+		}
+		if cfg.Schedule != "" {
+			schedule, err := scheduler.Parse(cfg.Schedule)
+			if err != nil {
+				return fmt.Errorf("job %s: %w", job.Name, err)
+			}
+			job.Schedule = schedule
+		}
+		if cfg.Timeout > 0 {
+			job.Timeout = cfg.Timeout
+		}
+		if cfg.Jitter > 0 {
+			job.Jitter = cfg.Jitter
+		}
+	}
+
+	return app.scheduler.Add(job)
 }
 
 // Restart returns the read-only restart channel.
@@ -106,22 +369,30 @@ func (app *App) Shutdown() <-chan struct{} {
 // HandleOSSignals runs the os signal handler to react on os signals (SIGHUP, SIGTERM, SIGINT).
 func (app *App) HandleOSSignals() {
 
-	go func() {
-		sig := make(chan os.Signal, 1)
-		signal.Notify(sig, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGINT)
+	// the signals are registered before the handler is started, so no signal is missed
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGINT)
 
+	go func() {
 		slog.Info("Starting signal handler")
 
-		receivedSignal := <-sig
+		var receivedSignal os.Signal
+		select {
+		case receivedSignal = <-sig:
+		case <-app.ctx.Done():
+			// the application was shut down without a signal, e.g. by a failed start
+			signal.Stop(sig)
+			return
+		}
 		slog.Info("Received OS signal", "signal", receivedSignal)
 
 		switch receivedSignal {
 		case syscall.SIGHUP:
 			slog.Info("SIGHUP received, initiating restart")
 			app.shutdownProcedure("restart")
-			// reset the signal registration before the program restarts.
-			// with program restarts, the HandleOSSignals function is called again and re-registers the signals.
-			signal.Reset()
+			// stop the signal registration of this handler, unlike signal.Reset it doesn't affect
+			// the handler registered by HandleOSSignals of the restarted application.
+			signal.Stop(sig)
 
 		case syscall.SIGTERM:
 			slog.Info("SIGTERM received, gracefully shutting down")
@@ -136,20 +407,22 @@ func (app *App) HandleOSSignals() {
 
 // shutdownProcedure Handles SIGTERM, SIGINT and SIGHUP (restart) for a graceful shutdown.
 //   - terminate: Cleanup app resources and terminates the application.
-//   - shutdown: graceful shutdown the web server, Cleanup app resources and exit the application.
-//   - restart: graceful shutdown the web server and Cleanup app resources and restart the application.
+//   - shutdown: drain the web server, Cleanup app resources and exit the application.
+//   - restart: drain the web server and Cleanup app resources and restart the application.
 func (app *App) shutdownProcedure(mode string) {
 	slog.Info("Initiating shutdown", "mode", mode)
 
 	if mode == "shutdown" || mode == "restart" {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		if err := app.web.Shutdown(ctx); err != nil {
-			slog.Error("Web server shutdown failed", "error", err)
-		}
+		app.drain()
 	}
 
+	// The root context is cancelled after the drain, not at the start of the shutdown procedure:
+	// the request contexts are derived from it, cancelling it first would abort all in-flight requests
+	// instead of letting them complete within the drain timeout.
+	// Streaming responses already ended when the drain started (see draining).
+	app.cancel()
+
+	slog.Info("Shutdown phase: cleanup")
 	if err := app.Cleanup(); err != nil {
 		slog.Error("Cleanup failed", "error", err)
 	}
@@ -166,10 +439,56 @@ func (app *App) shutdownProcedure(mode string) {
 	close(app.restart)
 }
 
+// drain gracefully stops the web server in phases:
+//   - mark the instance as not ready, the readiness endpoint returns 503.
+//   - wait the pre-stop delay, so load balancers notice the instance is not ready.
+//   - stop accepting new connections and wait for in-flight requests up to the drain timeout.
+//   - force close all remaining connections, aborted requests are reported.
+func (app *App) drain() {
+	cfg := app.config.Shutdown
+
+	slog.Info("Shutdown phase: mark instance not ready")
+	app.ready.Store(false)
+
+	if cfg.PreStopDelay > 0 {
+		slog.Info("Shutdown phase: pre-stop delay", "delay", cfg.PreStopDelay)
+		time.Sleep(cfg.PreStopDelay)
+	}
+
+	slog.Info("Shutdown phase: stop accepting connections and drain in-flight requests",
+		"inFlight", app.inFlight.Load(),
+		"timeout", cfg.DrainTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DrainTimeout)
+	defer cancel()
+
+	err := app.web.Shutdown(ctx)
+	if err == nil {
+		slog.Info("Shutdown phase: drain completed")
+		return
+	}
+
+	aborted := app.inFlight.Load()
+	slog.Warn("Shutdown phase: drain timeout exceeded, force closing connections", "abortedRequests", aborted, "error", err)
+	if err := app.web.Close(); err != nil {
+		slog.Error("Web server force close failed", "error", err)
+	}
+}
+
 // Cleanup free's application resources.
 // It's called when application is shutdown or restarted.
 // Should be used to free up resources.
 func (app *App) Cleanup() error {
-	var err error
+	ctx, cancel := context.WithTimeout(context.Background(), app.config.Shutdown.CleanupTimeout)
+	defer cancel()
+
+	// stop the components in reverse start order
+	err := app.components.Stop(ctx)
+
+	if app.tracer != nil {
+		// flush pending spans
+		err = errors.Join(err, app.tracer.Shutdown(ctx))
+	}
+
 	return err
 }
@@ -0,0 +1,177 @@
+package app
+
+import (
+	"crypto/tls"
+	"github.com/womat/go-api-template/app/service/authz"
+	"github.com/womat/go-api-template/app/service/lifecycle"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testConfig returns a configuration listening on a free loopback port with the development certificate.
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	_ = l.Close()
+
+	config := NewConfig()
+	config.HttpsServer.ListenHost = "127.0.0.1"
+	config.HttpsServer.ListenPort = port
+	config.HttpsServer.CertFile = "../configs/cert.pem"
+	config.HttpsServer.KeyFile = "../configs/key.pem"
+	config.Monitoring.CollectInterval = 20 * time.Millisecond
+	config.Shutdown.DrainTimeout = time.Second
+	return config
+}
+
+// get calls the path of the running application without keeping the connection alive.
+func get(t *testing.T, config *Config, path string) {
+	t.Helper()
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+	}
+	resp, err := client.Get("https://" + net.JoinHostPort(config.HttpsServer.ListenHost, config.HttpsServer.ListenPort) + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+// runAndRestart starts the application, serves a request and restarts it like the SIGHUP handler does.
+// The restart isn't triggered by a real signal, it would reach the signal handlers of all applications of the test binary.
+func runAndRestart(t *testing.T) {
+	t.Helper()
+
+	config := testConfig(t)
+	a, err := New(config).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	get(t, config, "/api/health")
+
+	go a.shutdownProcedure("restart")
+
+	select {
+	case <-a.Restart():
+	case <-time.After(10 * time.Second):
+		t.Fatal("application didn't restart")
+	}
+}
+
+func TestRestartLeaksNoGoroutines(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// the first run starts the goroutines living for the whole process, e.g. the os/signal watcher
+	runAndRestart(t)
+	baseline := runtime.NumGoroutine()
+
+	for range 3 {
+		runAndRestart(t)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for n := runtime.NumGoroutine(); n > baseline; n = runtime.NumGoroutine() {
+		if time.Now().After(deadline) {
+			var buf strings.Builder
+			_ = pprof.Lookup("goroutine").WriteTo(&buf, 1)
+			t.Fatalf("%d goroutines leaked after restart:\n%s", n-baseline, buf.String())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestShutdownCancelsContextAfterDrain(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	config := testConfig(t)
+	config.Shutdown.PreStopDelay = 200 * time.Millisecond
+	a, err := New(config).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go a.shutdownProcedure("shutdown")
+
+	// during the pre-stop delay the instance isn't ready, but in-flight requests keep their context
+	time.Sleep(50 * time.Millisecond)
+	if a.ready.Load() {
+		t.Error("instance still ready during the pre-stop delay")
+	}
+	if err := a.Context().Err(); err != nil {
+		t.Errorf("root context cancelled before the drain: %v", err)
+	}
+
+	select {
+	case <-a.Shutdown():
+	case <-time.After(10 * time.Second):
+		t.Fatal("application didn't shut down")
+	}
+	if a.Context().Err() == nil {
+		t.Error("root context not cancelled after shutdown")
+	}
+}
+
+// assertAborted checks that the components of a failed start were stopped and the root context was cancelled.
+func assertAborted(t *testing.T, a *App) {
+	t.Helper()
+
+	for _, st := range a.components.Status() {
+		if st.State != lifecycle.StateStopped {
+			t.Errorf("component %s is %s after the failed start, want %s", st.Name, st.State, lifecycle.StateStopped)
+		}
+	}
+	if a.Context().Err() == nil {
+		t.Error("root context not cancelled after the failed start")
+	}
+}
+
+func TestRunFailsOnUnknownPolicy(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	config := testConfig(t)
+	config.Authorization.Policies = map[string]authz.Policy{"GET /api/helth": authz.Public()}
+
+	a, err := New(config).Run()
+	if err == nil || !strings.Contains(err.Error(), "GET /api/helth") {
+		t.Errorf("Run() error = %v, want the unknown route", err)
+	}
+	assertAborted(t, a)
+}
+
+func TestRunStopsComponentsIfWebServerFails(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	config := testConfig(t)
+	l, err := net.Listen("tcp4", net.JoinHostPort(config.HttpsServer.ListenHost, config.HttpsServer.ListenPort))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	a, err := New(config).Run()
+	if err == nil {
+		t.Fatal("Run() error = nil, want the listen error")
+	}
+	if len(a.components.Status()) == 0 {
+		t.Fatal("no components registered")
+	}
+	assertAborted(t, a)
+}
@@ -1,10 +1,25 @@
 package app
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"github.com/womat/go-api-template/app/service/authz"
+	"github.com/womat/go-api-template/app/service/history"
+	"github.com/womat/go-api-template/app/service/logging"
+	"github.com/womat/go-api-template/app/service/monitoring"
+	"github.com/womat/go-api-template/app/service/problem"
+	"github.com/womat/go-api-template/app/service/secret"
 	"gopkg.in/yaml.v3"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
 )
 
 const (
@@ -30,12 +45,106 @@ type Config struct {
 	//  supported values: stdout | stderr | /path/to/logfile
 	LogDestination string `yaml:"logDestination"`
 
+	// LogRotation defines the rotation of the log file, if LogDestination is a file.
+	// The log file is reopened on SIGUSR1, e.g. after it was moved by an external logrotate.
+	LogRotation LogRotationConfig `yaml:"logRotation"`
+
+	// LogSinks defines multiple log destinations, each with its own level and format.
+	// If set, LogDestination and LogRotation are ignored; a sink without level uses LogLevel.
+	LogSinks []LogSinkConfig `yaml:"logSinks"`
+
+	// LogBuffer defines the in-memory buffer of the last log records, queried by /api/logs.
+	LogBuffer LogBufferConfig `yaml:"logBuffer"`
+
 	// HttpsServer is the configuration of the webserver and webservice
 	HttpsServer WebserverConfig `yaml:"webserver"`
 
+	// Authorization is the role based access control configuration of the api routes.
+	Authorization AuthorizationConfig `yaml:"authorization"`
+
+	// Shutdown is the configuration of the graceful shutdown sequence.
+	Shutdown ShutdownConfig `yaml:"shutdown"`
+
+	// Monitoring is the configuration of the monitoring endpoint.
+	Monitoring MonitoringConfig `yaml:"monitoring"`
+
+	// Scheduler is the configuration of the periodic jobs.
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+
+	// Tracing is the OpenTelemetry tracing configuration.
+	Tracing TracingConfig `yaml:"tracing"`
+
+	// Debug is the configuration of the pprof, runtime trace and expvar endpoints.
+	Debug DebugConfig `yaml:"debug"`
+
+	// Runtime is the configuration of the Go runtime limits (GOMAXPROCS, GOMEMLIMIT).
+	Runtime RuntimeConfig `yaml:"runtime"`
+
+	// CrashReport is the configuration of the crash reports written on panics.
+	CrashReport CrashReportConfig `yaml:"crashReport"`
+
+	// Sbom is the configuration of the software bill of materials endpoint.
+	Sbom SbomConfig `yaml:"sbom"`
+
 	// add your application-specific configuration here
 }
 
+// LogRotationConfig defines the rotation and retention of the log file.
+type LogRotationConfig struct {
+	// MaxSizeMB is the size in megabytes after which the log file is rotated, 0 disables rotation by size.
+	MaxSizeMB int `yaml:"maxSizeMB"`
+
+	// MaxAge is the age after which the log file is rotated, e.g. 24h. 0 disables rotation by age.
+	MaxAge time.Duration `yaml:"maxAge"`
+
+	// MaxFiles is the number of retained rotated log files, older files are removed. 0 retains all files.
+	MaxFiles int `yaml:"maxFiles"`
+
+	// Compress compresses rotated log files with gzip.
+	Compress bool `yaml:"compress"`
+}
+
+// LogSinkConfig defines a log destination with its own level and format.
+type LogSinkConfig struct {
+	// Type is the sink type.
+	//  supported values: stdout | stderr | null | file | syslog | journald
+	Type string `yaml:"type"`
+
+	// Level is the log level of the sink, default is LogLevel.
+	// Allowed values: debug | info | warning | error
+	Level string `yaml:"level"`
+
+	// Format is the record format, ignored for journald.
+	//  supported values: text | json | logfmt (default text)
+	Format string `yaml:"format"`
+
+	// Path is the path of the log file (file).
+	Path string `yaml:"path"`
+
+	// Rotation defines the rotation of the log file (file).
+	Rotation LogRotationConfig `yaml:"rotation"`
+
+	// Network is the syslog network: unix | udp (default unix).
+	Network string `yaml:"network"`
+
+	// Address is the syslog socket path or host:port (default /dev/log)
+	// or the journal socket path (default /run/systemd/journal/socket).
+	Address string `yaml:"address"`
+
+	// Facility is the syslog facility, e.g. daemon | user | local0 (default daemon).
+	Facility string `yaml:"facility"`
+}
+
+// LogBufferConfig defines the in-memory buffer of the last log records.
+type LogBufferConfig struct {
+	// Size is the number of records kept, 0 disables the buffer and the /api/logs endpoint.
+	Size int `yaml:"size"`
+
+	// Level is the minimum log level of the kept records, default is LogLevel.
+	// Allowed values: debug | info | warning | error
+	Level string `yaml:"level"`
+}
+
 // WebserverConfig defines the struct of the webserver and webservice configuration and configuration file
 type WebserverConfig struct {
 	// ListenHost is the host address the https server listens for connections.
@@ -45,10 +154,10 @@ type WebserverConfig struct {
 	ListenPort string `yaml:"listenPort"`
 
 	// ApiKey is the global api key for the application.
-	ApiKey string `yaml:"apiKey"`
+	ApiKey secret.String `yaml:"apiKey"`
 
 	// JwtSecret is a secret key used to sign jwt tokens.
-	JwtSecret string `yaml:"jwtSecret"`
+	JwtSecret secret.String `yaml:"jwtSecret"`
 
 	// JwtID is a unique identifier for the jwt token used to prevent login with the same jwt token to another app.
 	JwtID string `yaml:"jwtID"`
@@ -60,6 +169,11 @@ type WebserverConfig struct {
 	// Pfx files are supported as well, in which case KeyFile must be empty and CertFile must point to the pfx file, CertPassword must contain the password to decode the pfx file.
 	CertFile string `yaml:"certFile"`
 
+	// ClientCAFile is the CA certificate file used to verify client certificates.
+	// If set, clients may authenticate with a certificate signed by this CA.
+	// Default is empty, which means client certificates are not requested.
+	ClientCAFile string `yaml:"clientCAFile"`
+
 	// BlockedIPs is a list of IP addresses or networks that are forbidden from accessing the application.
 	// Default is empty, which means no IP addresses or networks are blocked.
 	// Multiple IP addresses or networks can be defined separated by a comma
@@ -73,6 +187,205 @@ type WebserverConfig struct {
 	// e.g.: 127.0.0.1,::1,192.168.0.0/16,10.0.0.0/8
 	// Note: '::1' is the IPv6 loopback address.
 	AllowedIPs []string `yaml:"allowedIPs"`
+
+	// ErrorFormat is the format of error responses for clients not asking for problem details
+	// (Accept: application/problem+json).
+	//  supported values: legacy | problem (default legacy)
+	ErrorFormat problem.Format `yaml:"errorFormat"`
+}
+
+// AuthorizationConfig defines the mapping of identities to roles and scopes and the route policy overrides.
+// The global api key (webserver.apiKey) is always granted the admin role.
+type AuthorizationConfig struct {
+	// ApiKeys is a list of additional api keys with their roles and scopes.
+	ApiKeys []authz.Grant `yaml:"apiKeys"`
+
+	// Users maps jwt users (claim "user") to roles and scopes.
+	Users []authz.Grant `yaml:"users"`
+
+	// ClientCerts maps the common name of verified client certificates to roles and scopes.
+	ClientCerts []authz.Grant `yaml:"clientCerts"`
+
+	// Policies overrides the default policy of a route, the key is the route pattern, e.g. "GET /api/monitoring".
+	Policies map[string]authz.Policy `yaml:"policies"`
+}
+
+// ShutdownConfig defines the phases of the graceful shutdown (SIGTERM) and restart (SIGHUP).
+// Durations are written as Go durations, e.g.: 500ms, 5s, 1m
+type ShutdownConfig struct {
+	// PreStopDelay is the time between marking the instance not ready and stopping the web server.
+	// It gives load balancers time to notice the instance is not ready. Default is 0 (no delay).
+	PreStopDelay time.Duration `yaml:"preStopDelay"`
+
+	// DrainTimeout is the maximum time to wait for in-flight requests to finish.
+	// Remaining connections are force closed after the timeout. Default is 5s.
+	DrainTimeout time.Duration `yaml:"drainTimeout"`
+
+	// CleanupTimeout is the maximum time for releasing application resources. Default is 5s.
+	CleanupTimeout time.Duration `yaml:"cleanupTimeout"`
+
+	// ComponentTimeout is the default maximum time for stopping a single component. Default is 2s.
+	// It can be overridden per component on registration.
+	ComponentTimeout time.Duration `yaml:"componentTimeout"`
+}
+
+// MonitoringConfig defines the configuration of the monitoring endpoint.
+type MonitoringConfig struct {
+	// CollectInterval is the interval the runtime statistics are sampled in the background. Default is 10s.
+	// The health and monitoring endpoints report the latest sample.
+	CollectInterval time.Duration `yaml:"collectInterval"`
+
+	// ResponseVersion is the default format of the monitoring response, it can be selected per request with ?version=
+	//  Allowed values: 1 | 2
+	//  - 1: WATCHIT compatible list of entries, the first entry is the overall state (default)
+	//  - 2: versioned object with the overall state and the list of entries
+	ResponseVersion int `yaml:"responseVersion"`
+
+	// Labels are static instance labels added to every monitoring entry, e.g. site: vienna
+	Labels map[string]string `yaml:"labels"`
+
+	// Thresholds defines the warning and critical limits per service, the key is the service name, e.g. "Number of Goroutines".
+	// Services without threshold are reported as OK.
+	Thresholds map[string]monitoring.Threshold `yaml:"thresholds"`
+
+	// Process is the configuration of the process-level metrics.
+	Process ProcessConfig `yaml:"process"`
+
+	// Host is the configuration of the host-level metrics.
+	Host HostConfig `yaml:"host"`
+
+	// History is the configuration of the monitoring history.
+	History HistoryConfig `yaml:"history"`
+}
+
+// ProcessConfig defines the process-level metrics read from /proc/self (linux only).
+type ProcessConfig struct {
+	// Enabled enables the process-level metrics in /api/health and /api/monitoring. Default is true.
+	Enabled bool `yaml:"enabled"`
+}
+
+// HostConfig defines the host-level metrics read from /proc and /sys (linux only).
+type HostConfig struct {
+	// Enabled enables the host-level metrics in /api/health and /api/monitoring.
+	Enabled bool `yaml:"enabled"`
+
+	// Root is the file system root containing the proc and sys trees. Default is "/".
+	// In a container the host file systems can be mounted elsewhere, e.g. /host.
+	Root string `yaml:"root"`
+
+	// MountPoints are the mount points whose disk usage is reported, relative to Root. Default is "/".
+	MountPoints []string `yaml:"mountPoints"`
+}
+
+// HistoryConfig defines the in-memory time series of the monitoring values.
+type HistoryConfig struct {
+	// Enabled enables recording of the monitoring values.
+	Enabled bool `yaml:"enabled"`
+
+	// Tiers are the downsampling levels, values are recorded at the finest resolution.
+	// Default is 10s buckets for 1h, 1m buckets for 24h and 10m buckets for 7 days.
+	Tiers []history.Tier `yaml:"tiers"`
+
+	// File is the path the history is persisted to on shutdown and loaded from on start.
+	// Default is empty, which means the history is kept in memory only.
+	File string `yaml:"file"`
+}
+
+// SchedulerConfig defines the configuration of the periodic jobs.
+type SchedulerConfig struct {
+	// Jobs overrides the settings of the jobs added by the application, the key is the job name.
+	Jobs map[string]JobConfig `yaml:"jobs"`
+}
+
+// JobConfig overrides the settings of a job.
+type JobConfig struct {
+	// Disabled prevents the job from being scheduled.
+	Disabled bool `yaml:"disabled"`
+
+	// Schedule is a cron expression (e.g. "*/15 * * * *"), a predefined schedule (e.g. @daily)
+	// or a fixed interval (e.g. "@every 10m").
+	Schedule string `yaml:"schedule"`
+
+	// Timeout is the maximum duration of a run.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// Jitter is the maximum random delay added to every scheduled run.
+	Jitter time.Duration `yaml:"jitter"`
+}
+
+// DebugConfig defines the debug endpoints under /debug/ (pprof, runtime trace, expvar).
+// The endpoints require the admin role.
+type DebugConfig struct {
+	// Enabled enables the debug endpoints.
+	Enabled bool `yaml:"enabled"`
+
+	// LoopbackOnly restricts the debug endpoints to requests from loopback addresses. Default is true.
+	LoopbackOnly bool `yaml:"loopbackOnly"`
+
+	// MaxProfileDuration is the maximum duration of cpu profiles, delta profiles and runtime traces, at least 1s. Default is 30s.
+	MaxProfileDuration time.Duration `yaml:"maxProfileDuration"`
+}
+
+// RuntimeConfig defines the Go runtime limits.
+// By default they are derived from the cgroup cpu quota and memory limit when running in a container.
+type RuntimeConfig struct {
+	// CgroupRoot is the file system root containing proc/self/cgroup and the cgroup file system (sys/fs/cgroup).
+	// Default is "/".
+	CgroupRoot string `yaml:"cgroupRoot"`
+
+	// MaxProcs sets GOMAXPROCS.
+	//  0: derived from the cgroup cpu quota, unless the GOMAXPROCS environment variable is set (default)
+	// -1: Go default (number of cpus or GOMAXPROCS environment variable)
+	MaxProcs int `yaml:"maxProcs"`
+
+	// MemoryLimit sets GOMEMLIMIT in bytes.
+	//  0: derived from the cgroup memory limit, unless the GOMEMLIMIT environment variable is set (default)
+	// -1: Go default (unlimited or GOMEMLIMIT environment variable)
+	MemoryLimit int64 `yaml:"memoryLimit"`
+
+	// MemoryLimitRatio is the fraction of the cgroup memory limit used as GOMEMLIMIT. Default is 0.9.
+	// The rest is left for memory not managed by the Go runtime (e.g. cgo, page cache).
+	MemoryLimitRatio float64 `yaml:"memoryLimitRatio"`
+}
+
+// CrashReportConfig defines the crash reports written if a panic is recovered or the process terminates with a fatal error.
+type CrashReportConfig struct {
+	// Dir is the directory of the crash reports, the reports of the previous run are logged on start.
+	// Default is empty, which means no crash reports are written.
+	Dir string `yaml:"dir"`
+
+	// MaxReports is the number of retained crash reports, older reports are removed. 0 retains all reports. Default is 10.
+	MaxReports int `yaml:"maxReports"`
+}
+
+// SbomConfig defines the software bill of materials endpoint /api/sbom (CycloneDX json of the module dependencies).
+type SbomConfig struct {
+	// Enabled enables the endpoint, it requires authentication.
+	Enabled bool `yaml:"enabled"`
+}
+
+// TracingConfig defines the OpenTelemetry tracing configuration.
+type TracingConfig struct {
+	// Enabled enables tracing of incoming and outgoing http requests.
+	Enabled bool `yaml:"enabled"`
+
+	// Exporter defines where spans are exported to.
+	//  supported values: otlp | file
+	//  - otlp: OTLP/HTTP to the configured endpoint
+	//  - file: JSON lines to the configured file, for offline debugging
+	Exporter string `yaml:"exporter"`
+
+	// Endpoint is the OTLP/HTTP endpoint url, e.g. http://localhost:4318/v1/traces
+	Endpoint string `yaml:"endpoint"`
+
+	// Headers are additional http headers sent to the OTLP endpoint, e.g. for authentication.
+	Headers map[string]secret.String `yaml:"headers"`
+
+	// File is the path of the file the file exporter writes to.
+	File string `yaml:"file"`
+
+	// SampleRatio is the fraction of traces sampled (0..1), default is 1 (all traces).
+	SampleRatio float64 `yaml:"sampleRatio"`
 }
 
 // MQTTConfig defines the struct of the mqtt client configuration and configuration file
@@ -85,9 +398,57 @@ type MQTTConfig struct {
 // NewConfig initializes and returns a new Config struct.
 func NewConfig() *Config {
 	return &Config{
+		LogBuffer: LogBufferConfig{
+			Size: 1000,
+		},
 		HttpsServer: WebserverConfig{
-			BlockedIPs: []string{},
-			AllowedIPs: []string{},
+			BlockedIPs:  []string{},
+			AllowedIPs:  []string{},
+			ErrorFormat: problem.FormatLegacy,
+		},
+		Authorization: AuthorizationConfig{
+			ApiKeys:     []authz.Grant{},
+			Users:       []authz.Grant{},
+			ClientCerts: []authz.Grant{},
+			Policies:    map[string]authz.Policy{},
+		},
+		Shutdown: ShutdownConfig{
+			DrainTimeout:     5 * time.Second,
+			CleanupTimeout:   5 * time.Second,
+			ComponentTimeout: 2 * time.Second,
+		},
+		Monitoring: MonitoringConfig{
+			CollectInterval: 10 * time.Second,
+			ResponseVersion: monitoring.ResponseV1,
+			Labels:          map[string]string{},
+			Thresholds:      map[string]monitoring.Threshold{},
+			Process: ProcessConfig{
+				Enabled: true,
+			},
+			Host: HostConfig{
+				Root:        "/",
+				MountPoints: []string{"/"},
+			},
+		},
+		Scheduler: SchedulerConfig{
+			Jobs: map[string]JobConfig{},
+		},
+		Tracing: TracingConfig{
+			Exporter:    "otlp",
+			Endpoint:    "http://localhost:4318/v1/traces",
+			Headers:     map[string]secret.String{},
+			SampleRatio: 1,
+		},
+		Debug: DebugConfig{
+			LoopbackOnly:       true,
+			MaxProfileDuration: 30 * time.Second,
+		},
+		Runtime: RuntimeConfig{
+			CgroupRoot:       "/",
+			MemoryLimitRatio: 0.9,
+		},
+		CrashReport: CrashReportConfig{
+			MaxReports: 10,
 		},
 	}
 }
@@ -108,11 +469,139 @@ func (c *Config) LoadConfig(fileName string) (*Config, error) {
 	}
 
 	replaced := os.ExpandEnv(string(content))
-	err = yaml.Unmarshal([]byte(replaced), c)
-	return c, err
+	if err = yaml.Unmarshal([]byte(replaced), c); err != nil {
+		return c, err
+	}
+	return c, c.validate()
+}
+
+// validate checks the values which can't be checked by the yaml decoder.
+func (c *Config) validate() error {
+	if v := c.Monitoring.ResponseVersion; v != monitoring.ResponseV1 && v != monitoring.ResponseV2 {
+		return fmt.Errorf("invalid monitoring.responseVersion %d: allowed values are %d and %d", v, monitoring.ResponseV1, monitoring.ResponseV2)
+	}
+	if c.Debug.MaxProfileDuration < time.Second {
+		return fmt.Errorf("invalid debug.maxProfileDuration %v: must be at least 1s", c.Debug.MaxProfileDuration)
+	}
+	return nil
 }
 
 // IsDevEnv returns true if "dev" is configured as app environment.
 func (c *Config) IsDevEnv() bool {
 	return c.Env == DevEnv
 }
+
+// Hash returns a short sha256 hash of the configuration, it identifies the configuration in effect (e.g. in crash reports).
+// The clear text of secrets is hashed, so rotating a key changes the hash.
+func (c *Config) Hash() string {
+	h := sha256.New()
+	writeHashValue(h, reflect.ValueOf(c))
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// writeHashValue writes a canonical representation of v to w. Unlike yaml.Marshal it ignores the marshalers
+// of the values, so secrets are written in clear text. Map entries are written in key order.
+func writeHashValue(w io.Writer, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			_, _ = io.WriteString(w, "nil;")
+			return
+		}
+		writeHashValue(w, v.Elem())
+	case reflect.Struct:
+		t := v.Type()
+		for i := range v.NumField() {
+			if f := t.Field(i); f.IsExported() {
+				_, _ = fmt.Fprintf(w, "%s:", f.Name)
+				writeHashValue(w, v.Field(i))
+			}
+		}
+	case reflect.Map:
+		type entry struct{ key, value []byte }
+		entries := make([]entry, 0, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			var key, value bytes.Buffer
+			writeHashValue(&key, iter.Key())
+			writeHashValue(&value, iter.Value())
+			entries = append(entries, entry{key.Bytes(), value.Bytes()})
+		}
+		slices.SortFunc(entries, func(a, b entry) int { return bytes.Compare(a.key, b.key) })
+
+		_, _ = fmt.Fprintf(w, "map[%d]", len(entries))
+		for _, e := range entries {
+			_, _ = w.Write(e.key)
+			_, _ = w.Write(e.value)
+		}
+	case reflect.Slice, reflect.Array:
+		_, _ = fmt.Fprintf(w, "[%d]", v.Len())
+		for i := range v.Len() {
+			writeHashValue(w, v.Index(i))
+		}
+	case reflect.String:
+		// reflect returns the underlying string without calling the String method, e.g. of secret.String
+		_, _ = fmt.Fprintf(w, "%q;", v.String())
+	case reflect.Bool:
+		_, _ = fmt.Fprintf(w, "%t;", v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, _ = fmt.Fprintf(w, "%d;", v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		_, _ = fmt.Fprintf(w, "%d;", v.Uint())
+	case reflect.Float32, reflect.Float64:
+		_, _ = fmt.Fprintf(w, "%g;", v.Float())
+	default:
+		_, _ = fmt.Fprintf(w, "%s;", v.Kind())
+	}
+}
+
+// LogBufferLevel returns the minimum log level of the log buffer, default is LogLevel.
+func (c *Config) LogBufferLevel() slog.Level {
+	if c.LogBuffer.Level == "" {
+		return logging.ParseLevel(c.LogLevel)
+	}
+	return logging.ParseLevel(c.LogBuffer.Level)
+}
+
+// Logging returns the configuration of the log sinks.
+// Without LogSinks, the only sink is LogDestination with LogLevel and LogRotation.
+func (c *Config) Logging() logging.Config {
+	rotate := func(r LogRotationConfig) logging.RotateOptions {
+		return logging.RotateOptions{
+			MaxSize:  int64(r.MaxSizeMB) * 1024 * 1024,
+			MaxAge:   r.MaxAge,
+			MaxFiles: r.MaxFiles,
+			Compress: r.Compress,
+		}
+	}
+
+	if len(c.LogSinks) == 0 {
+		sink := logging.Sink{Type: strings.ToLower(c.LogDestination), Level: c.LogLevel}
+		switch sink.Type {
+		case logging.SinkStdout, logging.SinkStderr, logging.SinkNull:
+		default:
+			sink.Type, sink.Path, sink.Rotate = logging.SinkFile, c.LogDestination, rotate(c.LogRotation)
+		}
+		return logging.Config{Sinks: []logging.Sink{sink}}
+	}
+
+	var cfg logging.Config
+	for _, s := range c.LogSinks {
+		level := s.Level
+		if level == "" {
+			level = c.LogLevel
+		}
+
+		cfg.Sinks = append(cfg.Sinks, logging.Sink{
+			Type:     s.Type,
+			Level:    level,
+			Format:   s.Format,
+			Path:     s.Path,
+			Rotate:   rotate(s.Rotation),
+			Network:  s.Network,
+			Address:  s.Address,
+			Facility: s.Facility,
+			Tag:      MODULE,
+		})
+	}
+	return cfg
+}
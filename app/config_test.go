@@ -0,0 +1,86 @@
+package app
+
+import (
+	"github.com/womat/go-api-template/app/service/secret"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadTestConfig writes content to a config file and loads it.
+func loadTestConfig(t *testing.T, content string) (*Config, error) {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return NewConfig().LoadConfig(file)
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"defaults", "logLevel: info\n", ""},
+		{"response version 2", "monitoring:\n  responseVersion: 2\n", ""},
+		{"invalid response version", "monitoring:\n  responseVersion: 3\n", "monitoring.responseVersion"},
+		{"max profile duration", "debug:\n  maxProfileDuration: 1s\n", ""},
+		{"max profile duration below 1s", "debug:\n  maxProfileDuration: 500ms\n", "debug.maxProfileDuration"},
+		{"zero max profile duration", "debug:\n  maxProfileDuration: 0s\n", "debug.maxProfileDuration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadTestConfig(t, tt.content)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("LoadConfig() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("LoadConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	base := NewConfig()
+	base.HttpsServer.ApiKey = "12345678"
+	base.Tracing.Headers = map[string]secret.String{"Authorization": "Bearer a", "X-Tenant": "t1"}
+	hash := base.Hash()
+
+	tests := []struct {
+		name     string
+		change   func(c *Config)
+		wantSame bool
+	}{
+		{"same configuration", func(c *Config) {}, true},
+		{"same map in another order", func(c *Config) {
+			c.Tracing.Headers = map[string]secret.String{"X-Tenant": "t1", "Authorization": "Bearer a"}
+		}, true},
+		{"rotated api key", func(c *Config) { c.HttpsServer.ApiKey = "87654321" }, false},
+		{"rotated jwt secret", func(c *Config) { c.HttpsServer.JwtSecret = "other" }, false},
+		{"rotated header secret", func(c *Config) { c.Tracing.Headers["Authorization"] = "Bearer b" }, false},
+		{"log level", func(c *Config) { c.LogLevel = "error" }, false},
+		{"duration", func(c *Config) { c.Debug.MaxProfileDuration++ }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig()
+			c.HttpsServer.ApiKey = "12345678"
+			c.Tracing.Headers = map[string]secret.String{"Authorization": "Bearer a", "X-Tenant": "t1"}
+			tt.change(c)
+
+			if got := c.Hash(); (got == hash) != tt.wantSame {
+				t.Errorf("Hash() = %s, base %s, want same %v", got, hash, tt.wantSame)
+			}
+			if len(c.Hash()) != 16 {
+				t.Errorf("Hash() = %s, want 16 hex digits", c.Hash())
+			}
+		})
+	}
+}
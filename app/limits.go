@@ -0,0 +1,88 @@
+package app
+
+import (
+	"errors"
+	"github.com/womat/go-api-template/app/service/cgroup"
+	"log/slog"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// The Go defaults at program start, they are restored if no limit applies (e.g. after a restart with a changed config).
+var (
+	defaultMaxProcs    = runtime.GOMAXPROCS(0)
+	defaultMemoryLimit = debug.SetMemoryLimit(-1)
+)
+
+// initRuntimeLimits detects the cgroup limits and sets GOMAXPROCS and GOMEMLIMIT
+// according to the runtime configuration, the environment variables and the cgroup limits.
+func (app *App) initRuntimeLimits() {
+	cfg := app.config.Runtime
+
+	var limits cgroup.Limits
+	reader, err := cgroup.NewReader(cfg.CgroupRoot)
+	switch {
+	case errors.Is(err, cgroup.ErrNoCgroup):
+		slog.Debug("No cgroup found, runtime limits are not derived from the cgroup")
+	case err != nil:
+		slog.Warn("Failed to detect the cgroup", "error", err)
+	default:
+		app.cgroup = cgroup.NewCollector(reader, app.config.Monitoring.CollectInterval)
+		limits = app.cgroup.Stats().Limits
+		slog.Info("Cgroup limits detected",
+			"version", limits.Version,
+			"cpuQuota", limits.CPUQuota,
+			"memoryLimitBytes", limits.MemoryLimitBytes)
+	}
+
+	procs, source := maxProcs(cfg, limits)
+	runtime.GOMAXPROCS(procs)
+	slog.Info("GOMAXPROCS set", "value", procs, "source", source)
+
+	memLimit, source := memoryLimit(cfg, limits)
+	debug.SetMemoryLimit(memLimit)
+	slog.Info("GOMEMLIMIT set", "value", memLimit, "source", source)
+}
+
+// maxProcs returns the GOMAXPROCS and its source: the config, the GOMAXPROCS environment variable,
+// the cgroup cpu quota or the Go default, in this order.
+func maxProcs(cfg RuntimeConfig, limits cgroup.Limits) (int, string) {
+	procs, source := cfg.MaxProcs, "config"
+	switch {
+	case procs < 0:
+		procs = 0
+	case procs == 0 && os.Getenv("GOMAXPROCS") != "":
+		source = "environment"
+	case procs == 0:
+		procs, source = limits.MaxProcs(), "cgroup"
+	}
+	if procs <= 0 {
+		procs = defaultMaxProcs
+		if source != "environment" {
+			source = "default"
+		}
+	}
+	return procs, source
+}
+
+// memoryLimit returns the GOMEMLIMIT and its source: the config, the GOMEMLIMIT environment variable,
+// the cgroup memory limit or the Go default, in this order.
+func memoryLimit(cfg RuntimeConfig, limits cgroup.Limits) (int64, string) {
+	memLimit, source := cfg.MemoryLimit, "config"
+	switch {
+	case memLimit < 0:
+		memLimit = 0
+	case memLimit == 0 && os.Getenv("GOMEMLIMIT") != "":
+		source = "environment"
+	case memLimit == 0:
+		memLimit, source = limits.MemoryLimit(cfg.MemoryLimitRatio), "cgroup"
+	}
+	if memLimit <= 0 {
+		memLimit = defaultMemoryLimit
+		if source != "environment" {
+			source = "default"
+		}
+	}
+	return memLimit, source
+}
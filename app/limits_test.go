@@ -0,0 +1,114 @@
+package app
+
+import (
+	"github.com/womat/go-api-template/app/service/cgroup"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+func TestMaxProcs(t *testing.T) {
+	cgroupLimits := cgroup.Limits{Version: 2, CPUQuota: 1}
+
+	tests := []struct {
+		name       string
+		maxProcs   int
+		env        string
+		limits     cgroup.Limits
+		want       int
+		wantSource string
+	}{
+		{"config", 3, "2", cgroupLimits, 3, "config"},
+		{"environment", 0, "2", cgroupLimits, defaultMaxProcs, "environment"},
+		{"cgroup", 0, "", cgroupLimits, 1, "cgroup"},
+		{"unlimited cgroup", 0, "", cgroup.Limits{Version: 2}, defaultMaxProcs, "default"},
+		{"no cgroup", 0, "", cgroup.Limits{}, defaultMaxProcs, "default"},
+		{"go default", -1, "", cgroupLimits, defaultMaxProcs, "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOMAXPROCS", tt.env)
+
+			got, source := maxProcs(RuntimeConfig{MaxProcs: tt.maxProcs}, tt.limits)
+			if got != tt.want || source != tt.wantSource {
+				t.Errorf("maxProcs() = %v, %q, want %v, %q", got, source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestMemoryLimit(t *testing.T) {
+	cgroupLimits := cgroup.Limits{Version: 2, MemoryLimitBytes: 1000}
+
+	tests := []struct {
+		name        string
+		memoryLimit int64
+		env         string
+		limits      cgroup.Limits
+		want        int64
+		wantSource  string
+	}{
+		{"config", 500, "2GiB", cgroupLimits, 500, "config"},
+		{"environment", 0, "2GiB", cgroupLimits, defaultMemoryLimit, "environment"},
+		{"cgroup", 0, "", cgroupLimits, 900, "cgroup"},
+		{"unlimited cgroup", 0, "", cgroup.Limits{Version: 2}, defaultMemoryLimit, "default"},
+		{"no cgroup", 0, "", cgroup.Limits{}, defaultMemoryLimit, "default"},
+		{"go default", -1, "", cgroupLimits, defaultMemoryLimit, "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOMEMLIMIT", tt.env)
+
+			cfg := RuntimeConfig{MemoryLimit: tt.memoryLimit, MemoryLimitRatio: 0.9}
+			got, source := memoryLimit(cfg, tt.limits)
+			if got != tt.want || source != tt.wantSource {
+				t.Errorf("memoryLimit() = %v, %q, want %v, %q", got, source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestInitRuntimeLimitsFromCgroup(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"proc/self/cgroup":                 "0::/\n",
+		"sys/fs/cgroup/cgroup.controllers": "cpu memory\n",
+		"sys/fs/cgroup/cpu.max":            "100000 100000\n",
+		"sys/fs/cgroup/memory.max":         "1073741824\n",
+	}
+	for name, data := range files {
+		file := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("GOMAXPROCS", "")
+	t.Setenv("GOMEMLIMIT", "")
+	t.Cleanup(func() {
+		runtime.GOMAXPROCS(defaultMaxProcs)
+		debug.SetMemoryLimit(defaultMemoryLimit)
+	})
+
+	config := NewConfig()
+	config.Runtime.CgroupRoot = root
+	app := &App{config: config}
+	app.initRuntimeLimits()
+
+	if app.cgroup == nil {
+		t.Fatal("initRuntimeLimits() didn't detect the cgroup")
+	}
+	if got := runtime.GOMAXPROCS(0); got != 1 {
+		t.Errorf("GOMAXPROCS = %v, want 1", got)
+	}
+	if got, want := debug.SetMemoryLimit(-1), int64(float64(1<<30)*config.Runtime.MemoryLimitRatio); got != want {
+		t.Errorf("GOMEMLIMIT = %v, want %v", got, want)
+	}
+}
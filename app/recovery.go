@@ -0,0 +1,114 @@
+package app
+
+import (
+	"fmt"
+	"github.com/womat/go-api-template/app/service/crash"
+	"github.com/womat/go-api-template/app/service/problem"
+	"github.com/womat/go-api-template/app/service/requestid"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// withRecovery is a middleware that recovers panics in the handlers.
+// The panic is logged with the stack and the request id, counted in http_panics_total
+// and a crash report is written, if enabled. The client gets a 500 response, if no response was written yet.
+// http.ErrAbortHandler is passed on to abort the response silently.
+func (app *App) withRecovery(h http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			rw := &recoveryWriter{ResponseWriter: w}
+
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				stack := debug.Stack()
+				id := requestid.FromContext(r.Context())
+
+				// the mux sets the pattern of the matched route
+				app.panics.Inc(r.Pattern)
+				slog.ErrorContext(r.Context(), "Panic in http handler recovered",
+					"panic", p,
+					"method", r.Method,
+					"path", r.URL.Path,
+					"route", r.Pattern,
+					"stack", string(stack))
+
+				if app.crash != nil {
+					file, err := app.crash.Write(crash.Report{
+						RequestID: id,
+						Method:    r.Method,
+						Path:      r.URL.Path,
+						Route:     r.Pattern,
+						Panic:     fmt.Sprint(p),
+						Stack:     string(stack),
+					})
+					if err != nil {
+						slog.ErrorContext(r.Context(), "Failed to write crash report", "file", file, "error", err)
+					} else {
+						slog.InfoContext(r.Context(), "Crash report written", "file", file)
+					}
+				}
+
+				if !rw.written {
+					problem.Write(w, r, problem.Internal(fmt.Errorf("internal server error, request id %s", id)))
+				}
+			}()
+
+			h.ServeHTTP(rw, r)
+		},
+	)
+}
+
+// recoveryWriter records whether the response was started.
+type recoveryWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+// WriteHeader records that the response was started.
+func (w *recoveryWriter) WriteHeader(status int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records that the response was started.
+func (w *recoveryWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter, it's used by http.ResponseController (e.g. to flush).
+func (w *recoveryWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// reportPreviousCrashes logs the crash reports written since the last start
+// and captures the traceback of fatal errors of this run.
+func (app *App) reportPreviousCrashes() {
+	reports, err := app.crash.Previous()
+	if err != nil {
+		slog.Error("Failed to read crash reports", "dir", app.config.CrashReport.Dir, "error", err)
+	}
+
+	for _, report := range reports {
+		slog.Warn("Crash report of a previous run",
+			"file", report.File,
+			"time", report.Time,
+			"version", report.Version,
+			"configHash", report.ConfigHash,
+			"requestId", report.RequestID,
+			"path", report.Path,
+			"panic", report.Panic)
+	}
+
+	if err = app.crash.CaptureFatal(); err != nil {
+		slog.Error("Failed to capture fatal errors", "dir", app.config.CrashReport.Dir, "error", err)
+	}
+}
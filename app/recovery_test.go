@@ -0,0 +1,190 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/womat/go-api-template/app/service/crash"
+	"github.com/womat/go-api-template/app/service/monitoring"
+	"github.com/womat/go-api-template/app/service/problem"
+	"github.com/womat/go-api-template/app/service/requestid"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// panicsTotal returns the value of http_panics_total of the route, 0 if not counted.
+func panicsTotal(a *App, route string) float64 {
+	for _, s := range a.metrics.Collect("", nil) {
+		if s.Service == `http_panics_total{route="`+route+`"}` {
+			v, _ := monitoring.ToFloat(s.Value)
+			return v
+		}
+	}
+	return 0
+}
+
+// recoverRequest serves a request to a route with handler h wrapped by withRecovery,
+// the request carries the request id req-1 and asks for problem details.
+func recoverRequest(a *App, h http.HandlerFunc) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.Handle("GET /boom", h)
+
+	r := httptest.NewRequest(http.MethodGet, "/boom", nil)
+	r = r.WithContext(requestid.WithRequestID(r.Context(), "req-1"))
+	r.Header.Set("Accept", problem.ContentType)
+
+	w := httptest.NewRecorder()
+	a.withRecovery(mux).ServeHTTP(w, r)
+	return w
+}
+
+func TestWithRecovery(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBody   bool
+		wantPanics float64
+	}{
+		{
+			name:       "no panic",
+			handler:    func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("ok")) },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "panic before the response",
+			handler:    func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   true,
+			wantPanics: 1,
+		},
+		{
+			name: "panic with an error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic(errors.New("boom"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   true,
+			wantPanics: 1,
+		},
+		{
+			name: "panic after the header was written",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				panic("boom")
+			},
+			wantStatus: http.StatusAccepted,
+			wantPanics: 1,
+		},
+		{
+			name: "panic after the body was written",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("partial"))
+				panic("boom")
+			},
+			wantStatus: http.StatusOK,
+			wantPanics: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(NewConfig())
+			w := recoverRequest(a, tt.handler)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := panicsTotal(a, "GET /boom"); got != tt.wantPanics {
+				t.Errorf("http_panics_total = %v, want %v", got, tt.wantPanics)
+			}
+			if !tt.wantBody {
+				return
+			}
+
+			if ct := w.Header().Get("Content-Type"); ct != problem.ContentType {
+				t.Errorf("Content-Type = %q, want %q", ct, problem.ContentType)
+			}
+			var p problem.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Status != http.StatusInternalServerError || p.RequestID != "req-1" {
+				t.Errorf("problem = %+v, want status 500 and request id req-1", p)
+			}
+		})
+	}
+}
+
+// headerRecorder counts the calls of WriteHeader.
+type headerRecorder struct {
+	*httptest.ResponseRecorder
+	calls int
+}
+
+func (w *headerRecorder) WriteHeader(status int) {
+	w.calls++
+	w.ResponseRecorder.WriteHeader(status)
+}
+
+func TestWithRecoveryWritesHeaderOnce(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	a := New(NewConfig())
+	h := a.withRecovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("boom")
+	}))
+
+	w := &headerRecorder{ResponseRecorder: httptest.NewRecorder()}
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.calls != 1 {
+		t.Errorf("WriteHeader called %d times, want 1", w.calls)
+	}
+}
+
+func TestWithRecoveryAbortHandler(t *testing.T) {
+	a := New(NewConfig())
+	h := a.withRecovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want %v", p, http.ErrAbortHandler)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestWithRecoveryCrashReport(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	dir := t.TempDir()
+	a := New(NewConfig())
+	a.crash = crash.NewReporter(dir, 5, "1.0.0", "hash")
+
+	recoverRequest(a, func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+
+	reports, err := a.crash.Previous()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("Previous() = %d reports, want 1", len(reports))
+	}
+	r := reports[0]
+	if r.RequestID != "req-1" || r.Route != "GET /boom" || r.Path != "/boom" || r.Panic != "boom" {
+		t.Errorf("report = %+v, want the request id, route, path and panic of the request", r)
+	}
+	if _, err = os.Stat(filepath.Join(dir, filepath.Base(r.File))); err != nil {
+		t.Errorf("report file: %v", err)
+	}
+}
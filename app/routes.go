@@ -1,41 +1,89 @@
 package app
 
 import (
+	"fmt"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"github.com/womat/go-api-template/app/service/authz"
+	"github.com/womat/go-api-template/app/service/problem"
+	"github.com/womat/go-api-template/app/service/requestid"
+	"github.com/womat/go-api-template/app/service/tracing"
 	"github.com/womat/golib/web"
 	"net/http"
 )
 
 // InitRoutes initializes and configures all HTTP routes for the application.
-// It sets up authentication, Swagger documentation, and middleware (CORS, IP filtering).
-// - Public routes without authentication
-// - Protected routes with authentication
+// It sets up authorization, Swagger documentation, and middleware (CORS, IP filtering).
+// - Every route declares its authorization policy (public, authenticated or required roles/scopes)
+// - Policies can be overridden in the authorization section of the config file
 // - Swagger documentation available at /swagger/
-// - Adds global middleware for CORS and IP filtering.
+// - Debug endpoints (pprof, runtime trace, expvar) available at /debug/, if enabled
+// - Recent log records available at /api/logs, if the log buffer is enabled
+// - Software bill of materials available at /api/sbom, if enabled
+// - Adds global middleware for CORS, IP filtering, request ids, request logging, panic recovery and the error response format.
+// - Adds tracing of every request, if tracing is enabled.
 //
 // This function must be called during application startup before the web server is launched.
-func (app *App) InitRoutes() {
+// It returns an error if a policy of the authorization section doesn't match a registered route.
+func (app *App) InitRoutes() error {
 
-	webCfg := web.Config{
-		ApiKey:    app.config.HttpsServer.ApiKey,
-		JwtSecret: app.config.HttpsServer.JwtSecret,
-		JwtID:     app.config.HttpsServer.JwtID,
-		AppName:   MODULE,
+	authCfg := authz.Config{
+		ApiKey:      app.config.HttpsServer.ApiKey.Value(),
+		JwtSecret:   app.config.HttpsServer.JwtSecret.Value(),
+		JwtID:       app.config.HttpsServer.JwtID,
+		AppName:     MODULE,
+		ApiKeys:     app.config.Authorization.ApiKeys,
+		Users:       app.config.Authorization.Users,
+		ClientCerts: app.config.Authorization.ClientCerts,
+		Policies:    app.config.Authorization.Policies,
 	}
 
 	mux := http.NewServeMux()
-	mux.Handle("OPTIONS /", web.HandlePreflight())
+	app.router = authz.NewRouter(mux, authz.New(authCfg))
+	app.router.Handle("OPTIONS /", web.HandlePreflight(), authz.Public())
 
 	if app.config.IsDevEnv() {
 		// Expose Swagger documentation only in development.
-		mux.Handle("GET /swagger/", httpSwagger.Handler(httpSwagger.PersistAuthorization(true)))
+		app.router.Handle("GET /swagger/", httpSwagger.Handler(httpSwagger.PersistAuthorization(true)), authz.Public())
 	}
 
-	mux.Handle("GET /api/version", app.HandleVersion())
-	mux.Handle("GET /api/health", app.HandleHealth())
-	mux.Handle("GET /api/monitoring", web.WithAuth(app.HandleMonitoring(), webCfg))
+	app.router.Handle("GET /api/version", app.HandleVersion(), authz.Public())
+	if app.config.Sbom.Enabled {
+		app.router.Handle("GET /api/sbom", app.HandleSBOM(), authz.Authenticated())
+	}
+	app.router.Handle("GET /api/health", app.HandleHealth(), authz.Public())
+	app.router.Handle("GET /api/ready", app.HandleReady(), authz.Public())
+	app.router.Handle("GET /api/monitoring", app.HandleMonitoring(), authz.Authenticated())
+	if app.history != nil {
+		app.router.Handle("GET /api/monitoring/history", app.HandleMonitoringHistory(), authz.Authenticated())
+	}
+	app.router.Handle("GET /api/jobs", app.HandleJobs(), authz.Authenticated())
+	app.router.Handle("POST /api/jobs/{name}/run", app.HandleJobRun(), authz.RequireRoles(authz.RoleAdmin))
+	if app.logs != nil && app.logs.Size() > 0 {
+		app.router.Handle("GET /api/logs", app.HandleLogs(), authz.RequireRoles(authz.RoleAdmin))
+	}
+	app.router.Handle("GET /api/authz/routes", app.HandleAuthzRoutes(), authz.RequireRoles(authz.RoleAdmin))
+
+	if app.config.Debug.Enabled {
+		app.initDebugRoutes()
+	}
+
+	// a misspelled pattern would leave the route with its default policy
+	if unknown := app.router.UnknownPolicies(); len(unknown) > 0 {
+		return fmt.Errorf("authorization policies for unknown routes %q: misspelled pattern or disabled route", unknown)
+	}
 
 	// Global middleware is added here.
-	app.web.Handler = web.WithCORS(mux)
+	app.web.Handler = web.WithCORS(app.withRecovery(mux))
+	app.web.Handler = app.withInFlight(app.web.Handler)
 	app.web.Handler = web.WithIPFilter(app.web.Handler, app.config.HttpsServer.AllowedIPs, app.config.HttpsServer.BlockedIPs)
+	app.web.Handler = problem.WithFormat(app.web.Handler, app.config.HttpsServer.ErrorFormat)
+	app.web.Handler = withRequestLog(app.web.Handler)
+	app.web.Handler = requestid.Middleware(app.web.Handler)
+
+	if app.tracer != nil {
+		// tracing is the outermost middleware to include the whole handler chain in the server span.
+		app.web.Handler = tracing.WithTracing(app.web.Handler, mux)
+	}
+
+	return nil
 }
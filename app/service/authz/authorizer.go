@@ -0,0 +1,175 @@
+package authz
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/womat/go-api-template/app/service/problem"
+	"github.com/womat/go-api-template/app/service/secret"
+	"github.com/womat/golib/jwt_util"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Grant maps an identity to roles and scopes.
+type Grant struct {
+	// Subject is the name of the identity:
+	//  - the name of the api key (used for logging only)
+	//  - the jwt user (claim "user")
+	//  - the common name of the client certificate
+	Subject string `yaml:"subject"`
+
+	// Key is the api key, it's only used for api key grants.
+	Key secret.String `yaml:"key"`
+
+	// Roles granted to the identity.
+	Roles []string `yaml:"roles"`
+
+	// Scopes granted to the identity.
+	Scopes []string `yaml:"scopes"`
+}
+
+// Config holds the authentication and authorization configuration.
+type Config struct {
+	// ApiKey is the global api key, callers using it are granted the admin role.
+	ApiKey string
+
+	// JwtSecret is a secret key used to validate jwt tokens.
+	JwtSecret string
+
+	// JwtID is the unique identifier of the jwt token.
+	JwtID string
+
+	// AppName is the issuer of the jwt token.
+	AppName string
+
+	// ApiKeys are additional api keys with their roles and scopes.
+	ApiKeys []Grant
+
+	// Users maps jwt users to roles and scopes.
+	// Jwt users without grant are authenticated but have no roles.
+	Users []Grant
+
+	// ClientCerts maps the common name of verified client certificates to roles and scopes.
+	// Client certificates without grant are authenticated but have no roles.
+	ClientCerts []Grant
+
+	// Policies overrides the policy of a route, the key is the route pattern, e.g. "GET /api/monitoring".
+	Policies map[string]Policy
+}
+
+// Authorizer authenticates requests and enforces route policies.
+type Authorizer struct {
+	config Config
+}
+
+// New returns a new Authorizer for the given configuration.
+func New(config Config) *Authorizer {
+	return &Authorizer{config: config}
+}
+
+// Authenticate identifies the caller of the request.
+// The api key (X-Api-Key header) is checked first, then the jwt token (Authorization header) and finally the verified client certificate.
+// It returns nil if the caller cannot be identified.
+func (a *Authorizer) Authenticate(r *http.Request) *Identity {
+	if key := r.Header.Get("X-Api-Key"); key != "" {
+		if len(a.config.ApiKey) > 0 && equal(key, a.config.ApiKey) {
+			return &Identity{Subject: "apikey", Method: MethodApiKey, Roles: []string{RoleAdmin}}
+		}
+		for _, g := range a.config.ApiKeys {
+			if g.Key.IsSet() && equal(key, g.Key.Value()) {
+				return g.identity(MethodApiKey)
+			}
+		}
+	}
+
+	if user, ok := a.checkJwtToken(r); ok {
+		return lookup(a.config.Users, user).identity(MethodJwt)
+	}
+
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+		return lookup(a.config.ClientCerts, cn).identity(MethodCert)
+	}
+
+	return nil
+}
+
+// Effective returns the configured policy override for the route pattern, or policy if there is none.
+func (a *Authorizer) Effective(pattern string, policy Policy) Policy {
+	if p, ok := a.config.Policies[pattern]; ok {
+		return p
+	}
+	return policy
+}
+
+// WithPolicy is a middleware that authenticates the request and checks the policy.
+//   - If authentication is required but fails, it returns a 401 Unauthorized response.
+//   - If the caller lacks a required role or scope, it returns a 403 Forbidden response.
+//   - Otherwise, the identity is stored in the request context and the next handler is called.
+func (a *Authorizer) WithPolicy(h http.Handler, policy Policy) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			id := a.Authenticate(r)
+
+			if err := policy.Check(id); err != nil {
+				status, perr := http.StatusForbidden, problem.Forbidden(err)
+				if errors.Is(err, ErrUnauthorized) {
+					status, perr = http.StatusUnauthorized, problem.Unauthorized(err)
+				}
+
+				slog.WarnContext(r.Context(), "Access denied",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"client_ip", r.RemoteAddr,
+					"status", status,
+					"error", err)
+				problem.Write(w, r, perr)
+				return
+			}
+
+			if id != nil {
+				r = r.WithContext(WithIdentity(r.Context(), id))
+			}
+			h.ServeHTTP(w, r)
+		},
+	)
+}
+
+// checkJwtToken checks if the request contains a valid jwt bearer token and returns the user claim.
+func (a *Authorizer) checkJwtToken(r *http.Request) (string, bool) {
+	if len(a.config.JwtSecret) == 0 || len(a.config.JwtID) == 0 {
+		return "", false
+	}
+
+	parts := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", false
+	}
+
+	claims, err := jwt_util.ValidateToken(parts[1], a.config.AppName, "auth", a.config.JwtID, a.config.JwtSecret)
+	if err != nil {
+		return "", false
+	}
+	return claims.User, true
+}
+
+// lookup returns the grant of the subject, or a grant without roles if the subject has no grant.
+func lookup(grants []Grant, subject string) Grant {
+	for _, g := range grants {
+		if g.Subject == subject {
+			return g
+		}
+	}
+	return Grant{Subject: subject}
+}
+
+// identity converts the grant into an identity.
+func (g Grant) identity(method string) *Identity {
+	return &Identity{Subject: g.Subject, Method: method, Roles: g.Roles, Scopes: g.Scopes}
+}
+
+// equal compares two secrets in constant time.
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,57 @@
+package authz
+
+import (
+	"context"
+	"slices"
+)
+
+// Authentication methods reported in Identity.Method.
+const (
+	MethodApiKey = "apikey"
+	MethodJwt    = "jwt"
+	MethodCert   = "cert"
+)
+
+// RoleAdmin is the role assigned to callers using the global api key.
+const RoleAdmin = "admin"
+
+// Identity describes an authenticated caller and the roles and scopes granted to it.
+type Identity struct {
+	// Subject identifies the caller, e.g. the api key name, the jwt user or the client certificate common name.
+	Subject string `json:"subject"`
+
+	// Method is the authentication method used to identify the caller (apikey, jwt or cert).
+	Method string `json:"method"`
+
+	// Roles are the roles granted to the caller.
+	Roles []string `json:"roles"`
+
+	// Scopes are the scopes granted to the caller.
+	Scopes []string `json:"scopes"`
+}
+
+// HasRole returns true if the identity has the given role.
+func (id *Identity) HasRole(role string) bool {
+	return id != nil && slices.Contains(id.Roles, role)
+}
+
+// HasScope returns true if the identity has the given scope.
+func (id *Identity) HasScope(scope string) bool {
+	return id != nil && slices.Contains(id.Scopes, scope)
+}
+
+// contextKey is used for storing values in context safely.
+type contextKey string
+
+const contextKeyIdentity contextKey = "identity"
+
+// WithIdentity returns a copy of ctx carrying the given identity.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, contextKeyIdentity, id)
+}
+
+// FromContext returns the identity stored in ctx, or nil if the request is not authenticated.
+func FromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(contextKeyIdentity).(*Identity)
+	return id
+}
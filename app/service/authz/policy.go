@@ -0,0 +1,98 @@
+package authz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrUnauthorized = errors.New("not authorized")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// Policy defines the requirements a caller must fulfil to access a route.
+//   - Public routes can be accessed without authentication.
+//   - Non-public routes require an authenticated caller.
+//   - If Roles is not empty, the caller must have at least one of the roles.
+//   - If Scopes is not empty, the caller must have all the scopes.
+type Policy struct {
+	// Public allows access without authentication.
+	Public bool `yaml:"public" json:"public"`
+
+	// Roles is the list of roles of which the caller must have at least one.
+	Roles []string `yaml:"roles" json:"roles,omitempty"`
+
+	// Scopes is the list of scopes the caller must have.
+	Scopes []string `yaml:"scopes" json:"scopes,omitempty"`
+}
+
+// Public returns a policy which allows access without authentication.
+func Public() Policy {
+	return Policy{Public: true}
+}
+
+// Authenticated returns a policy which allows access to every authenticated caller.
+func Authenticated() Policy {
+	return Policy{}
+}
+
+// RequireRoles returns a policy which requires at least one of the given roles.
+func RequireRoles(roles ...string) Policy {
+	return Policy{Roles: roles}
+}
+
+// RequireScopes returns a policy which requires all the given scopes.
+func RequireScopes(scopes ...string) Policy {
+	return Policy{Scopes: scopes}
+}
+
+// Check returns nil if the identity fulfils the policy.
+//   - ErrUnauthorized is returned if the policy requires authentication and id is nil.
+//   - ErrForbidden is returned if the identity lacks a required role or scope.
+func (p Policy) Check(id *Identity) error {
+	if p.Public {
+		return nil
+	}
+
+	if id == nil {
+		return ErrUnauthorized
+	}
+
+	if len(p.Roles) > 0 {
+		ok := false
+		for _, role := range p.Roles {
+			if id.HasRole(role) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("%w: requires one of the roles %s", ErrForbidden, strings.Join(p.Roles, ", "))
+		}
+	}
+
+	for _, scope := range p.Scopes {
+		if !id.HasScope(scope) {
+			return fmt.Errorf("%w: requires scope %s", ErrForbidden, scope)
+		}
+	}
+
+	return nil
+}
+
+// String returns a short human-readable description of the policy.
+func (p Policy) String() string {
+	if p.Public {
+		return "public"
+	}
+
+	s := "authenticated"
+	if len(p.Roles) > 0 {
+		s += " roles=" + strings.Join(p.Roles, "|")
+	}
+	if len(p.Scopes) > 0 {
+		s += " scopes=" + strings.Join(p.Scopes, ",")
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package authz
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	admin := &Identity{Subject: "apikey", Method: MethodApiKey, Roles: []string{RoleAdmin}}
+	reader := &Identity{Subject: "grafana", Method: MethodApiKey, Roles: []string{"reader"}, Scopes: []string{"metrics:read"}}
+	nobody := &Identity{Subject: "bob", Method: MethodJwt}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		id     *Identity
+		want   error
+	}{
+		{"public without identity", Public(), nil, nil},
+		{"public with identity", Public(), admin, nil},
+		{"authenticated without identity", Authenticated(), nil, ErrUnauthorized},
+		{"authenticated without roles", Authenticated(), nobody, nil},
+		{"role granted", RequireRoles(RoleAdmin), admin, nil},
+		{"one of the roles granted", RequireRoles(RoleAdmin, "reader"), reader, nil},
+		{"role missing", RequireRoles(RoleAdmin), reader, ErrForbidden},
+		{"role without identity", RequireRoles(RoleAdmin), nil, ErrUnauthorized},
+		{"scope granted", RequireScopes("metrics:read"), reader, nil},
+		{"scope missing", RequireScopes("metrics:read"), admin, ErrForbidden},
+		{"all scopes required", RequireScopes("metrics:read", "jobs:run"), reader, ErrForbidden},
+		{"role and scope granted", Policy{Roles: []string{"reader"}, Scopes: []string{"metrics:read"}}, reader, nil},
+		{"role granted, scope missing", Policy{Roles: []string{RoleAdmin}, Scopes: []string{"metrics:read"}}, admin, ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.id)
+			if tt.want == nil && err != nil {
+				t.Fatalf("Check() = %v, want nil", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("Check() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyString(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		want   string
+	}{
+		{Public(), "public"},
+		{Authenticated(), "authenticated"},
+		{RequireRoles(RoleAdmin, "reader"), "authenticated roles=admin|reader"},
+		{Policy{Roles: []string{"reader"}, Scopes: []string{"a", "b"}}, "authenticated roles=reader scopes=a,b"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.policy.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
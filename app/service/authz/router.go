@@ -0,0 +1,64 @@
+package authz
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Route describes a registered route and its effective policy.
+type Route struct {
+	// Pattern is the route pattern, e.g. "GET /api/monitoring".
+	Pattern string `json:"pattern"`
+
+	// Policy is the effective policy of the route.
+	Policy Policy `json:"policy"`
+
+	// Description is a human-readable description of the policy.
+	Description string `json:"description"`
+}
+
+// Router registers routes on a ServeMux and guards each of them with its policy.
+type Router struct {
+	mux        *http.ServeMux
+	authorizer *Authorizer
+	routes     []Route
+}
+
+// NewRouter returns a new Router registering routes on mux.
+func NewRouter(mux *http.ServeMux, authorizer *Authorizer) *Router {
+	return &Router{mux: mux, authorizer: authorizer}
+}
+
+// Handle registers the handler for the given pattern.
+// The policy can be overridden by the authorization configuration.
+func (rt *Router) Handle(pattern string, h http.Handler, policy Policy) {
+	policy = rt.authorizer.Effective(pattern, policy)
+	rt.routes = append(rt.routes, Route{Pattern: pattern, Policy: policy, Description: policy.String()})
+
+	if policy.Public {
+		rt.mux.Handle(pattern, h)
+		return
+	}
+	rt.mux.Handle(pattern, rt.authorizer.WithPolicy(h, policy))
+}
+
+// Routes returns the registered routes sorted by pattern.
+func (rt *Router) Routes() []Route {
+	routes := slices.Clone(rt.routes)
+	slices.SortFunc(routes, func(a, b Route) int { return strings.Compare(a.Pattern, b.Pattern) })
+	return routes
+}
+
+// UnknownPolicies returns the patterns of the policy overrides without registered route, sorted.
+// An override of a misspelled pattern would leave the route with its default policy.
+func (rt *Router) UnknownPolicies() []string {
+	var unknown []string
+	for pattern := range rt.authorizer.config.Policies {
+		if !slices.ContainsFunc(rt.routes, func(r Route) bool { return r.Pattern == pattern }) {
+			unknown = append(unknown, pattern)
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
@@ -0,0 +1,125 @@
+package authz
+
+import (
+	"github.com/womat/go-api-template/app/service/secret"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	cfg := Config{
+		ApiKey: "admin-key",
+		ApiKeys: []Grant{
+			{Subject: "grafana", Key: secret.String("reader-key"), Roles: []string{"reader"}},
+			{Subject: "disabled"},
+		},
+		Policies: map[string]Policy{
+			// overrides: the public version endpoint requires authentication, monitoring is opened to readers
+			"GET /api/version":    Authenticated(),
+			"GET /api/monitoring": RequireRoles(RoleAdmin, "reader"),
+		},
+	}
+
+	mux := http.NewServeMux()
+	router := NewRouter(mux, New(cfg))
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if FromContext(r.Context()) == nil && r.URL.Path != "/api/health" {
+			t.Errorf("%s: identity missing in request context", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	router.Handle("GET /api/health", ok, Public())
+	router.Handle("GET /api/version", ok, Public())
+	router.Handle("GET /api/monitoring", ok, Authenticated())
+	router.Handle("GET /api/logs", ok, RequireRoles(RoleAdmin))
+
+	tests := []struct {
+		path   string
+		key    string
+		status int
+	}{
+		{"/api/health", "", http.StatusOK},
+		{"/api/version", "", http.StatusUnauthorized},
+		{"/api/version", "reader-key", http.StatusOK},
+		{"/api/monitoring", "", http.StatusUnauthorized},
+		{"/api/monitoring", "wrong-key", http.StatusUnauthorized},
+		{"/api/monitoring", "reader-key", http.StatusOK},
+		{"/api/monitoring", "admin-key", http.StatusOK},
+		{"/api/logs", "reader-key", http.StatusForbidden},
+		{"/api/logs", "admin-key", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.key, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.key != "" {
+				r.Header.Set("X-Api-Key", tt.key)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+
+	want := map[string]string{
+		"GET /api/health":     "public",
+		"GET /api/logs":       "authenticated roles=admin",
+		"GET /api/monitoring": "authenticated roles=admin|reader",
+		"GET /api/version":    "authenticated",
+	}
+	routes := router.Routes()
+	if len(routes) != len(want) {
+		t.Fatalf("Routes() returned %d routes, want %d", len(routes), len(want))
+	}
+	for i, r := range routes {
+		if i > 0 && routes[i-1].Pattern > r.Pattern {
+			t.Errorf("Routes() not sorted: %s before %s", routes[i-1].Pattern, r.Pattern)
+		}
+		if want[r.Pattern] != r.Description {
+			t.Errorf("effective policy of %s = %q, want %q", r.Pattern, r.Description, want[r.Pattern])
+		}
+	}
+}
+
+func TestAuthenticateGrantWithoutKey(t *testing.T) {
+	// a grant without key must never match, even for an empty header value
+	a := New(Config{ApiKeys: []Grant{{Subject: "disabled", Roles: []string{RoleAdmin}}}})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Api-Key", " ")
+	if id := a.Authenticate(r); id != nil {
+		t.Fatalf("Authenticate() = %+v, want nil", id)
+	}
+}
+
+func TestRouterUnknownPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies map[string]Policy
+		want     []string
+	}{
+		{"no policies", nil, nil},
+		{"known routes", map[string]Policy{"GET /api/health": Authenticated(), "GET /api/version": Public()}, nil},
+		{"misspelled pattern", map[string]Policy{"GET /api/helth": Authenticated()}, []string{"GET /api/helth"}},
+		{"wrong method", map[string]Policy{"POST /api/health": Authenticated()}, []string{"POST /api/health"}},
+		{"missing method", map[string]Policy{"/api/health": Authenticated()}, []string{"/api/health"}},
+		{"sorted", map[string]Policy{"GET /b": Public(), "GET /api/health": Public(), "GET /a": Public()}, []string{"GET /a", "GET /b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter(http.NewServeMux(), New(Config{Policies: tt.policies}))
+			ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			router.Handle("GET /api/health", ok, Public())
+			router.Handle("GET /api/version", ok, Public())
+
+			if got := router.UnknownPolicies(); !slices.Equal(got, tt.want) {
+				t.Errorf("UnknownPolicies() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
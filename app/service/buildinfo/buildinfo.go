@@ -0,0 +1,124 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Info is the build information of the application.
+// Name, version and build time are set at build time (-ldflags), the others are read from the build info embedded in the binary.
+type Info struct {
+	// Name is the name of the application.
+	Name string `json:"name" yaml:"name"`
+
+	// Version is the version of the application.
+	Version string `json:"version" yaml:"version"`
+
+	// BuildTime is the time the binary was built, empty if not set at build time.
+	BuildTime string `json:"buildTime,omitempty" yaml:"buildTime,omitempty"`
+
+	// GoVersion is the version of the Go toolchain that built the binary.
+	GoVersion string `json:"goVersion" yaml:"goVersion"`
+
+	// Os and Arch are the target operating system and architecture of the binary.
+	Os   string `json:"os" yaml:"os"`
+	Arch string `json:"arch" yaml:"arch"`
+
+	// Path is the path of the main package, e.g. github.com/womat/go-api-template/cmd/app.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// Module is the path and version of the main module.
+	Module string `json:"module,omitempty" yaml:"module,omitempty"`
+
+	// Revision is the version control revision (commit) the binary was built from.
+	Revision string `json:"revision,omitempty" yaml:"revision,omitempty"`
+
+	// RevisionTime is the time of the revision.
+	RevisionTime string `json:"revisionTime,omitempty" yaml:"revisionTime,omitempty"`
+
+	// Dirty is true if the working tree had local modifications at build time.
+	Dirty bool `json:"dirty" yaml:"dirty"`
+
+	// Dependencies are the modules the binary was built with.
+	Dependencies []Dependency `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+}
+
+// Dependency is a module the binary was built with.
+type Dependency struct {
+	// Path is the module path.
+	Path string `json:"path" yaml:"path"`
+
+	// Version is the module version.
+	Version string `json:"version" yaml:"version"`
+
+	// Sum is the checksum of the module (go.sum), empty if the module is replaced.
+	Sum string `json:"sum,omitempty" yaml:"sum,omitempty"`
+
+	// Replace is the path and version of the replacement module, empty if the module isn't replaced.
+	Replace string `json:"replace,omitempty" yaml:"replace,omitempty"`
+}
+
+// Read returns the build information of the running binary.
+// name, version and buildTime are the values set at build time.
+func Read(name, version, buildTime string) Info {
+	info := Info{
+		Name:      name,
+		Version:   version,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+		Os:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		// the binary was built without module support
+		return info
+	}
+
+	info.GoVersion = bi.GoVersion
+	info.Path = bi.Path
+	if bi.Main.Path != "" {
+		info.Module = bi.Main.Path + "@" + bi.Main.Version
+	}
+
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.time":
+			info.RevisionTime = s.Value
+		case "vcs.modified":
+			info.Dirty = s.Value == "true"
+		case "GOOS":
+			info.Os = s.Value
+		case "GOARCH":
+			info.Arch = s.Value
+		}
+	}
+
+	for _, m := range bi.Deps {
+		d := Dependency{Path: m.Path, Version: m.Version, Sum: m.Sum}
+		if r := m.Replace; r != nil {
+			d.Replace = r.Path
+			if r.Version != "" {
+				d.Replace += "@" + r.Version
+			}
+		}
+		info.Dependencies = append(info.Dependencies, d)
+	}
+
+	return info
+}
+
+// ShortRevision returns the first 12 characters of the revision, followed by "-dirty" if the working tree was modified.
+func (i Info) ShortRevision() string {
+	r := i.Revision
+	if len(r) > 12 {
+		r = r[:12]
+	}
+	if r != "" && i.Dirty {
+		r += "-dirty"
+	}
+	return r
+}
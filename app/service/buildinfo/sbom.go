@@ -0,0 +1,90 @@
+package buildinfo
+
+import (
+	"strings"
+	"time"
+)
+
+// SBOM is a software bill of materials of the application in the CycloneDX json format.
+// See https://cyclonedx.org/docs/1.5/json/
+type SBOM struct {
+	BomFormat   string      `json:"bomFormat"`
+	SpecVersion string      `json:"specVersion"`
+	Version     int         `json:"version"`
+	Metadata    Metadata    `json:"metadata"`
+	Components  []Component `json:"components"`
+}
+
+// Metadata describes the application the SBOM belongs to.
+type Metadata struct {
+	Timestamp  string     `json:"timestamp"`
+	Component  Component  `json:"component"`
+	Properties []Property `json:"properties,omitempty"`
+}
+
+// Component is the application or a module the application was built with.
+type Component struct {
+	Type       string     `json:"type"`
+	Name       string     `json:"name"`
+	Version    string     `json:"version,omitempty"`
+	Purl       string     `json:"purl,omitempty"`
+	Properties []Property `json:"properties,omitempty"`
+}
+
+// Property is a name/value pair of additional information, e.g. the go.sum checksum of a module.
+type Property struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// SBOM returns the software bill of materials of the build information.
+// The components are the module dependencies, replaced modules are listed with the replacement.
+func (i Info) SBOM() SBOM {
+	app := Component{Type: "application", Name: i.Name, Version: i.Version}
+	if path, version, ok := strings.Cut(i.Module, "@"); ok {
+		app.Purl = purl(path, version)
+	}
+
+	meta := Metadata{Timestamp: time.Now().UTC().Format(time.RFC3339), Component: app}
+	for _, p := range []Property{
+		{Name: "go:version", Value: i.GoVersion},
+		{Name: "go:os", Value: i.Os},
+		{Name: "go:arch", Value: i.Arch},
+		{Name: "vcs:revision", Value: i.Revision},
+		{Name: "vcs:time", Value: i.RevisionTime},
+		{Name: "build:time", Value: i.BuildTime},
+	} {
+		if p.Value != "" {
+			meta.Properties = append(meta.Properties, p)
+		}
+	}
+
+	components := make([]Component, 0, len(i.Dependencies))
+	for _, d := range i.Dependencies {
+		c := Component{Type: "library", Name: d.Path, Version: d.Version, Purl: purl(d.Path, d.Version)}
+		if d.Sum != "" {
+			c.Properties = append(c.Properties, Property{Name: "go:sum", Value: d.Sum})
+		}
+		if d.Replace != "" {
+			c.Properties = append(c.Properties, Property{Name: "go:replace", Value: d.Replace})
+		}
+		components = append(components, c)
+	}
+
+	return SBOM{
+		BomFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Metadata:    meta,
+		Components:  components,
+	}
+}
+
+// purl returns the package url of a go module, e.g. pkg:golang/github.com/womat/golib@v1.0.2.
+func purl(path, version string) string {
+	p := "pkg:golang/" + path
+	if version != "" && version != "(devel)" {
+		p += "@" + strings.ReplaceAll(version, "+", "%2B")
+	}
+	return p
+}
@@ -0,0 +1,370 @@
+package cgroup
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoCgroup is returned if the process doesn't run in a cgroup with cpu or memory controller.
+var ErrNoCgroup = errors.New("no cgroup found")
+
+// unlimitedV1 is the smallest value treated as unlimited in cgroup v1 memory limits,
+// the kernel reports the page aligned maximum int64 value if no limit is set.
+const unlimitedV1 = 1 << 62
+
+// Limits are the resource limits of the cgroup.
+type Limits struct {
+	// Version is the cgroup version, 1 or 2.
+	Version int `json:"Version"`
+
+	// CPUQuota is the cpu quota in cores (e.g. 1.5), 0 if unlimited.
+	CPUQuota float64 `json:"CPUQuota"`
+
+	// MemoryLimitBytes is the memory limit, 0 if unlimited.
+	MemoryLimitBytes uint64 `json:"MemoryLimitBytes"`
+}
+
+// MaxProcs returns the GOMAXPROCS matching the cpu quota: the quota rounded up, at least 1 and at most the number of cpus.
+// It returns 0 if the cpu quota is unlimited.
+func (l Limits) MaxProcs() int {
+	if l.CPUQuota <= 0 {
+		return 0
+	}
+	return max(1, min(int(math.Ceil(l.CPUQuota)), runtime.NumCPU()))
+}
+
+// MemoryLimit returns the GOMEMLIMIT matching the memory limit, ratio is the fraction of the limit used by the Go runtime.
+// It returns 0 if the memory limit is unlimited.
+func (l Limits) MemoryLimit(ratio float64) int64 {
+	if l.MemoryLimitBytes == 0 || ratio <= 0 {
+		return 0
+	}
+	return int64(float64(l.MemoryLimitBytes) * min(ratio, 1))
+}
+
+// Stats holds the limits and the current usage of the cgroup.
+type Stats struct {
+	Limits
+
+	// Time is the time the stats were read.
+	Time time.Time `json:"Time"`
+
+	// CPUUsageCores is the cpu usage since the previous read in cores.
+	CPUUsageCores float64 `json:"CPUUsageCores"`
+
+	// CPUHeadroomCores is the cpu quota minus the cpu usage, 0 if unlimited.
+	CPUHeadroomCores float64 `json:"CPUHeadroomCores"`
+
+	// MemoryUsageBytes is the memory usage of the cgroup (including the page cache).
+	MemoryUsageBytes uint64 `json:"MemoryUsageBytes"`
+
+	// MemoryHeadroomBytes is the memory limit minus the memory usage, 0 if unlimited.
+	MemoryHeadroomBytes uint64 `json:"MemoryHeadroomBytes"`
+
+	// MemoryHeadroomPercent is the memory headroom in percent of the limit, 0 if unlimited.
+	MemoryHeadroomPercent float64 `json:"MemoryHeadroomPercent"`
+
+	// Errors lists the sources which couldn't be read.
+	Errors []string `json:"Errors,omitempty"`
+}
+
+// Reader reads the limits and the usage of the cgroup of the current process.
+type Reader struct {
+	fsys    fs.FS
+	version int
+
+	// directories of the controllers in fsys, empty if the controller isn't available
+	cpuDir, cpuacctDir, memoryDir string
+
+	mu       sync.Mutex
+	prevCPU  time.Duration
+	prevTime time.Time
+}
+
+// NewReader returns a Reader for the given file system root (usually "/").
+func NewReader(root string) (*Reader, error) {
+	return NewFSReader(os.DirFS(root))
+}
+
+// NewFSReader returns a Reader for the given file system, e.g. a directory with a fixture cgroup tree.
+// fsys must contain proc/self/cgroup and the cgroup file system mounted at sys/fs/cgroup.
+// It returns ErrNoCgroup if no cpu or memory controller is found.
+func NewFSReader(fsys fs.FS) (*Reader, error) {
+	b, err := fs.ReadFile(fsys, "proc/self/cgroup")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNoCgroup
+		}
+		return nil, err
+	}
+
+	r := &Reader{fsys: fsys}
+
+	// dir returns the directory of cgroup p below mount, or mount if it doesn't exist
+	// (e.g. in a container with a cgroup namespace the cgroup is mounted at the root).
+	dir := func(mount, p string) string {
+		if d := path.Join(mount, p); isDir(fsys, d) {
+			return d
+		}
+		return mount
+	}
+
+	if isFile(fsys, "sys/fs/cgroup/cgroup.controllers") {
+		// cgroup v2, unified hierarchy: 0::/path
+		for _, line := range strings.Split(string(b), "\n") {
+			if p, ok := strings.CutPrefix(line, "0::"); ok {
+				d := dir("sys/fs/cgroup", p)
+				r.version, r.cpuDir, r.cpuacctDir, r.memoryDir = 2, d, d, d
+				return r, nil
+			}
+		}
+		return nil, ErrNoCgroup
+	}
+
+	// cgroup v1, one hierarchy per controller: id:controller[,controller]:/path
+	for _, line := range strings.Split(string(b), "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		mount := path.Join("sys/fs/cgroup", fields[1])
+		for _, c := range strings.Split(fields[1], ",") {
+			switch c {
+			case "cpu":
+				r.cpuDir = dir(mount, fields[2])
+			case "cpuacct":
+				r.cpuacctDir = dir(mount, fields[2])
+			case "memory":
+				r.memoryDir = dir(mount, fields[2])
+			}
+		}
+	}
+
+	if r.cpuDir == "" && r.memoryDir == "" {
+		return nil, ErrNoCgroup
+	}
+	r.version = 1
+	return r, nil
+}
+
+// Limits returns the cpu and memory limits of the cgroup.
+func (r *Reader) Limits() (Limits, error) {
+	l := Limits{Version: r.version}
+
+	var err error
+	l.CPUQuota, err = r.cpuQuota()
+	if err != nil {
+		return l, fmt.Errorf("cpu: %w", err)
+	}
+	l.MemoryLimitBytes, err = r.memoryLimit()
+	if err != nil {
+		return l, fmt.Errorf("memory: %w", err)
+	}
+	return l, nil
+}
+
+// Read returns the current limits and usage. Sources which can't be read are listed in Stats.Errors.
+func (r *Reader) Read() *Stats {
+	s := &Stats{Time: time.Now()}
+
+	collect := func(name string, err error) {
+		if err != nil {
+			s.Errors = append(s.Errors, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	var err error
+	s.Limits, err = r.Limits()
+	collect("limits", err)
+	collect("cpu usage", r.readCPUUsage(s))
+	collect("memory usage", r.readMemoryUsage(s))
+
+	if s.CPUQuota > 0 {
+		s.CPUHeadroomCores = max(0, s.CPUQuota-s.CPUUsageCores)
+	}
+	if s.MemoryLimitBytes > 0 && s.MemoryUsageBytes < s.MemoryLimitBytes {
+		s.MemoryHeadroomBytes = s.MemoryLimitBytes - s.MemoryUsageBytes
+		s.MemoryHeadroomPercent = float64(s.MemoryHeadroomBytes) / float64(s.MemoryLimitBytes) * 100
+	}
+	return s
+}
+
+// cpuQuota reads the cpu quota in cores, 0 if unlimited.
+func (r *Reader) cpuQuota() (float64, error) {
+	if r.cpuDir == "" {
+		return 0, nil
+	}
+
+	var quota, period string
+	if r.version == 2 {
+		// cpu.max: $MAX $PERIOD, $MAX is "max" if unlimited
+		b, err := fs.ReadFile(r.fsys, path.Join(r.cpuDir, "cpu.max"))
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		fields := strings.Fields(string(b))
+		if len(fields) != 2 {
+			return 0, errors.New("unexpected format of cpu.max")
+		}
+		if fields[0] == "max" {
+			return 0, nil
+		}
+		quota, period = fields[0], fields[1]
+	} else {
+		// cpu.cfs_quota_us is -1 if unlimited
+		q, err := readString(r.fsys, path.Join(r.cpuDir, "cpu.cfs_quota_us"))
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if q == "-1" {
+			return 0, nil
+		}
+		p, err := readString(r.fsys, path.Join(r.cpuDir, "cpu.cfs_period_us"))
+		if err != nil {
+			return 0, err
+		}
+		quota, period = q, p
+	}
+
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil {
+		return 0, err
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil {
+		return 0, err
+	}
+	if q <= 0 || p <= 0 {
+		return 0, nil
+	}
+	return q / p, nil
+}
+
+// memoryLimit reads the memory limit in bytes, 0 if unlimited.
+func (r *Reader) memoryLimit() (uint64, error) {
+	if r.memoryDir == "" {
+		return 0, nil
+	}
+
+	name := "memory.limit_in_bytes"
+	if r.version == 2 {
+		name = "memory.max"
+	}
+
+	v, err := readString(r.fsys, path.Join(r.memoryDir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if v == "max" {
+		return 0, nil
+	}
+
+	limit, err := strconv.ParseUint(v, 10, 64)
+	if err != nil || limit >= unlimitedV1 {
+		return 0, err
+	}
+	return limit, nil
+}
+
+// readCPUUsage computes the cpu usage in cores since the previous read.
+func (r *Reader) readCPUUsage(s *Stats) error {
+	var usage time.Duration
+
+	switch {
+	case r.version == 2 && r.cpuDir != "":
+		// cpu.stat: usage_usec $USEC
+		b, err := fs.ReadFile(r.fsys, path.Join(r.cpuDir, "cpu.stat"))
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(string(b), "\n") {
+			if v, ok := strings.CutPrefix(line, "usage_usec "); ok {
+				usec, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+				if err != nil {
+					return err
+				}
+				usage = time.Duration(usec) * time.Microsecond
+			}
+		}
+	case r.version == 1 && r.cpuacctDir != "":
+		// cpuacct.usage: $NSEC
+		v, err := readString(r.fsys, path.Join(r.cpuacctDir, "cpuacct.usage"))
+		if err != nil {
+			return err
+		}
+		nsec, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		usage = time.Duration(nsec)
+	default:
+		return nil
+	}
+
+	r.mu.Lock()
+	prevCPU, prevTime := r.prevCPU, r.prevTime
+	r.prevCPU, r.prevTime = usage, s.Time
+	r.mu.Unlock()
+
+	if elapsed := s.Time.Sub(prevTime); !prevTime.IsZero() && elapsed > 0 && usage >= prevCPU {
+		s.CPUUsageCores = float64(usage-prevCPU) / float64(elapsed)
+	}
+	return nil
+}
+
+// readMemoryUsage reads the current memory usage.
+func (r *Reader) readMemoryUsage(s *Stats) error {
+	if r.memoryDir == "" {
+		return nil
+	}
+
+	name := "memory.usage_in_bytes"
+	if r.version == 2 {
+		name = "memory.current"
+	}
+
+	v, err := readString(r.fsys, path.Join(r.memoryDir, name))
+	if err != nil {
+		return err
+	}
+	s.MemoryUsageBytes, err = strconv.ParseUint(v, 10, 64)
+	return err
+}
+
+// readString returns the trimmed content of the file name.
+func readString(fsys fs.FS, name string) (string, error) {
+	b, err := fs.ReadFile(fsys, name)
+	return strings.TrimSpace(string(b)), err
+}
+
+// isDir reports whether name is a directory in fsys.
+func isDir(fsys fs.FS, name string) bool {
+	fi, err := fs.Stat(fsys, name)
+	return err == nil && fi.IsDir()
+}
+
+// isFile reports whether name is a regular file in fsys.
+func isFile(fsys fs.FS, name string) bool {
+	fi, err := fs.Stat(fsys, name)
+	return err == nil && fi.Mode().IsRegular()
+}
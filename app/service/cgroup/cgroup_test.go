@@ -0,0 +1,251 @@
+package cgroup
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// v2 returns a cgroup v2 tree with the process in cgroup p and the given controller files below it.
+func v2(p string, files map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{
+		"proc/self/cgroup":                 {Data: []byte("0::" + p + "\n")},
+		"sys/fs/cgroup/cgroup.controllers": {Data: []byte("cpuset cpu io memory pids\n")},
+	}
+	for name, data := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(data)}
+	}
+	return fsys
+}
+
+// v1 returns a cgroup v1 tree with the given proc/self/cgroup and controller files.
+func v1(cgroup string, files map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{"proc/self/cgroup": {Data: []byte(cgroup)}}
+	for name, data := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(data)}
+	}
+	return fsys
+}
+
+const v1Cgroup = `12:pids:/docker/abc
+4:cpu,cpuacct:/docker/abc
+3:memory:/docker/abc
+0::/system.slice/docker.service
+`
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    Limits
+		wantErr bool
+	}{
+		{
+			name: "v2 limited",
+			fsys: v2("/app", map[string]string{
+				"sys/fs/cgroup/app/cpu.max":    "150000 100000\n",
+				"sys/fs/cgroup/app/memory.max": "536870912\n",
+			}),
+			want: Limits{Version: 2, CPUQuota: 1.5, MemoryLimitBytes: 512 << 20},
+		},
+		{
+			name: "v2 unlimited",
+			fsys: v2("/app", map[string]string{
+				"sys/fs/cgroup/app/cpu.max":    "max 100000\n",
+				"sys/fs/cgroup/app/memory.max": "max\n",
+			}),
+			want: Limits{Version: 2},
+		},
+		{
+			name: "v2 root path in a cgroup namespace",
+			fsys: v2("/", map[string]string{
+				"sys/fs/cgroup/cpu.max":    "50000 100000\n",
+				"sys/fs/cgroup/memory.max": "1073741824\n",
+			}),
+			want: Limits{Version: 2, CPUQuota: 0.5, MemoryLimitBytes: 1 << 30},
+		},
+		{
+			name: "v2 path not mounted falls back to the mount",
+			fsys: v2("/kubepods/pod1/abc", map[string]string{
+				"sys/fs/cgroup/cpu.max":    "200000 100000\n",
+				"sys/fs/cgroup/memory.max": "max\n",
+			}),
+			want: Limits{Version: 2, CPUQuota: 2},
+		},
+		{
+			name: "v2 missing controller files",
+			fsys: v2("/", nil),
+			want: Limits{Version: 2},
+		},
+		{
+			name: "v2 invalid cpu.max",
+			fsys: v2("/", map[string]string{
+				"sys/fs/cgroup/cpu.max": "100000\n",
+			}),
+			want:    Limits{Version: 2},
+			wantErr: true,
+		},
+		{
+			name: "v1 limited",
+			fsys: v1(v1Cgroup, map[string]string{
+				"sys/fs/cgroup/cpu,cpuacct/docker/abc/cpu.cfs_quota_us":  "250000\n",
+				"sys/fs/cgroup/cpu,cpuacct/docker/abc/cpu.cfs_period_us": "100000\n",
+				"sys/fs/cgroup/memory/docker/abc/memory.limit_in_bytes":  "268435456\n",
+			}),
+			want: Limits{Version: 1, CPUQuota: 2.5, MemoryLimitBytes: 256 << 20},
+		},
+		{
+			name: "v1 unlimited",
+			fsys: v1(v1Cgroup, map[string]string{
+				"sys/fs/cgroup/cpu,cpuacct/docker/abc/cpu.cfs_quota_us":  "-1\n",
+				"sys/fs/cgroup/cpu,cpuacct/docker/abc/cpu.cfs_period_us": "100000\n",
+				"sys/fs/cgroup/memory/docker/abc/memory.limit_in_bytes":  "9223372036854771712\n",
+			}),
+			want: Limits{Version: 1},
+		},
+		{
+			name: "v1 path not mounted falls back to the mount",
+			fsys: v1(v1Cgroup, map[string]string{
+				"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  "100000\n",
+				"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+			}),
+			want: Limits{Version: 1, CPUQuota: 1},
+		},
+		{
+			name: "v1 memory controller only",
+			fsys: v1("3:memory:/\n", map[string]string{
+				"sys/fs/cgroup/memory/memory.limit_in_bytes": "1048576\n",
+			}),
+			want: Limits{Version: 1, MemoryLimitBytes: 1 << 20},
+		},
+		{
+			name: "v1 quota without period",
+			fsys: v1(v1Cgroup, map[string]string{
+				"sys/fs/cgroup/cpu,cpuacct/docker/abc/cpu.cfs_quota_us": "100000\n",
+			}),
+			want:    Limits{Version: 1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewFSReader(tt.fsys)
+			if err != nil {
+				t.Fatalf("NewFSReader() error = %v", err)
+			}
+
+			got, err := r.Limits()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Limits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Limits() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFSReaderNoCgroup(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"no proc/self/cgroup", fstest.MapFS{}},
+		{"v2 without unified entry", fstest.MapFS{
+			"proc/self/cgroup":                 {Data: []byte("1:name=systemd:/\n")},
+			"sys/fs/cgroup/cgroup.controllers": {Data: []byte("cpu memory\n")},
+		}},
+		{"v1 without cpu and memory controller", v1("12:pids:/docker/abc\n1:name=systemd:/\n0::/\n", nil)},
+		{"empty", v1("", nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewFSReader(tt.fsys); !errors.Is(err, ErrNoCgroup) {
+				t.Errorf("NewFSReader() error = %v, want %v", err, ErrNoCgroup)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	fsys := v2("/", map[string]string{
+		"sys/fs/cgroup/cpu.max":        "200000 100000\n",
+		"sys/fs/cgroup/memory.max":     "1000\n",
+		"sys/fs/cgroup/memory.current": "250\n",
+		"sys/fs/cgroup/cpu.stat":       "usage_usec 1000000\nuser_usec 600000\nsystem_usec 400000\n",
+	})
+	r, err := NewFSReader(fsys)
+	if err != nil {
+		t.Fatalf("NewFSReader() error = %v", err)
+	}
+
+	s := r.Read()
+	want := &Stats{
+		Limits:                Limits{Version: 2, CPUQuota: 2, MemoryLimitBytes: 1000},
+		Time:                  s.Time,
+		CPUHeadroomCores:      2,
+		MemoryUsageBytes:      250,
+		MemoryHeadroomBytes:   750,
+		MemoryHeadroomPercent: 75,
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("Read() = %+v, want %+v", s, want)
+	}
+
+	// one second later with 1.5s more cpu time
+	r.prevTime = s.Time.Add(-time.Second)
+	r.prevCPU = 0
+	fsys["sys/fs/cgroup/cpu.stat"] = &fstest.MapFile{Data: []byte("usage_usec 1500000\n")}
+	s2 := Stats{Time: s.Time}
+	if err := r.readCPUUsage(&s2); err != nil {
+		t.Fatalf("readCPUUsage() error = %v", err)
+	}
+	if s2.CPUUsageCores != 1.5 {
+		t.Errorf("readCPUUsage() = %v, want 1.5", s2.CPUUsageCores)
+	}
+}
+
+func TestReadMissingUsage(t *testing.T) {
+	r, err := NewFSReader(v2("/", nil))
+	if err != nil {
+		t.Fatalf("NewFSReader() error = %v", err)
+	}
+
+	want := []string{
+		"cpu usage: open sys/fs/cgroup/cpu.stat: file does not exist",
+		"memory usage: open sys/fs/cgroup/memory.current: file does not exist",
+	}
+	if s := r.Read(); !reflect.DeepEqual(s.Errors, want) {
+		t.Errorf("Read() errors = %q, want %q", s.Errors, want)
+	}
+}
+
+func TestLimitsRuntime(t *testing.T) {
+	tests := []struct {
+		name         string
+		limits       Limits
+		ratio        float64
+		wantProcs    int
+		wantMemLimit int64
+	}{
+		{"unlimited", Limits{}, 0.9, 0, 0},
+		{"fractional quota is rounded up", Limits{CPUQuota: 0.5, MemoryLimitBytes: 1000}, 0.9, 1, 900},
+		{"ratio above 1 is capped", Limits{CPUQuota: 1, MemoryLimitBytes: 1000}, 2, 1, 1000},
+		{"no ratio", Limits{MemoryLimitBytes: 1000}, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.limits.MaxProcs(); got != tt.wantProcs {
+				t.Errorf("MaxProcs() = %v, want %v", got, tt.wantProcs)
+			}
+			if got := tt.limits.MemoryLimit(tt.ratio); got != tt.wantMemLimit {
+				t.Errorf("MemoryLimit() = %v, want %v", got, tt.wantMemLimit)
+			}
+		})
+	}
+}
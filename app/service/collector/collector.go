@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Collector reads stats of type T in the background at a fixed interval, e.g. the host, process or cgroup stats.
+// It's a lifecycle component, the latest stats are returned by Stats.
+type Collector[T any] struct {
+	name     string
+	read     func() *T
+	interval time.Duration
+	stats    atomic.Pointer[T]
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// New returns a new Collector calling read at the given interval, the stats are read immediately.
+// name is the component name.
+func New[T any](name string, read func() *T, interval time.Duration) *Collector[T] {
+	c := &Collector[T]{name: name, read: read, interval: interval}
+	c.stats.Store(read())
+	return c
+}
+
+// Name returns the component name.
+func (c *Collector[T]) Name() string {
+	return c.name
+}
+
+// Start starts reading in the background until ctx is cancelled or Stop is called.
+func (c *Collector[T]) Start(ctx context.Context) error {
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.stats.Store(c.read())
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops reading.
+func (c *Collector[T]) Stop(ctx context.Context) error {
+	if c.cancel == nil {
+		return nil
+	}
+	c.cancel()
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the latest stats, they must not be modified.
+func (c *Collector[T]) Stats() *T {
+	return c.stats.Load()
+}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type stats struct {
+	n int64
+}
+
+// counter returns a read function returning the number of calls.
+func counter() (func() *stats, *atomic.Int64) {
+	var calls atomic.Int64
+	return func() *stats { return &stats{n: calls.Add(1)} }, &calls
+}
+
+func TestCollector(t *testing.T) {
+	read, calls := counter()
+	c := New("test", read, 5*time.Millisecond)
+
+	if c.Name() != "test" {
+		t.Errorf("Name() = %q, want %q", c.Name(), "test")
+	}
+	// the stats are read immediately
+	if got := c.Stats().n; got != 1 {
+		t.Errorf("Stats() = %d, want the first read", got)
+	}
+
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Stats().n < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Stats() = %d after 5s, want it to be read in the background", c.Stats().n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	stopped := calls.Load()
+	time.Sleep(20 * time.Millisecond)
+	if calls.Load() != stopped {
+		t.Error("read after Stop")
+	}
+}
+
+func TestCollectorStopOnCancel(t *testing.T) {
+	read, calls := counter()
+	c := New("test", read, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	select {
+	case <-c.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("collector didn't stop when the context was cancelled")
+	}
+	stopped := calls.Load()
+	time.Sleep(10 * time.Millisecond)
+	if calls.Load() != stopped {
+		t.Error("read after the context was cancelled")
+	}
+}
+
+func TestCollectorStopNotStarted(t *testing.T) {
+	read, _ := counter()
+	if err := New("test", read, time.Second).Stop(context.Background()); err != nil {
+		t.Errorf("Stop() error = %v, want nil", err)
+	}
+}
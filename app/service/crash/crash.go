@@ -0,0 +1,264 @@
+package crash
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// filePrefix and fileSuffix enclose the time of the crash in the report file names.
+	filePrefix = "crash-"
+	fileSuffix = ".yaml"
+
+	// fatalFile is the file the runtime writes the traceback of a fatal error or an unrecovered panic to.
+	fatalFile = "fatal.log"
+
+	// fatalSuffix is the suffix of the reports of fatal errors.
+	fatalSuffix = ".fatal.log"
+
+	// reportedSuffix is appended to the file name of a report once it was reported on start.
+	reportedSuffix = ".reported"
+
+	// timeFormat is the time format in the report file names.
+	timeFormat = "2006-01-02T15-04-05.000"
+
+	// maxDumpSize is the maximum size of the goroutine dump.
+	maxDumpSize = 8 << 20
+)
+
+// Report is a crash report, it's written if a panic is recovered.
+type Report struct {
+	// Time is the time of the panic.
+	Time time.Time `yaml:"time"`
+
+	// Version is the version of the application.
+	Version string `yaml:"version"`
+
+	// ConfigHash is the hash of the configuration in effect.
+	ConfigHash string `yaml:"configHash"`
+
+	// RequestID, Method, Path and Route identify the request which caused the panic.
+	RequestID string `yaml:"requestId,omitempty"`
+	Method    string `yaml:"method,omitempty"`
+	Path      string `yaml:"path,omitempty"`
+	Route     string `yaml:"route,omitempty"`
+
+	// Panic is the value passed to panic.
+	Panic string `yaml:"panic"`
+
+	// Stack is the stack trace of the panicking goroutine.
+	Stack string `yaml:"stack"`
+
+	// Goroutines is the stack dump of all goroutines.
+	Goroutines string `yaml:"goroutines"`
+
+	// File is the path of the report file, it's not written to the file.
+	File string `yaml:"-"`
+}
+
+// Reporter writes crash reports to a directory and reads the reports of a previous run.
+// The reports are yaml files named crash-<time>.yaml, only the newest maxReports files are retained.
+// Fatal errors and unrecovered panics which terminate the process are captured in fatal.log, see CaptureFatal.
+type Reporter struct {
+	mu         sync.Mutex
+	dir        string
+	maxReports int
+	version    string
+	configHash string
+}
+
+// NewReporter returns a reporter writing to dir, version and configHash are added to every report.
+// maxReports is the number of retained reports, 0 retains all reports.
+func NewReporter(dir string, maxReports int, version, configHash string) *Reporter {
+	return &Reporter{dir: dir, maxReports: maxReports, version: version, configHash: configHash}
+}
+
+// Write completes the report with the version, the config hash and the goroutine dump
+// and writes it to a new report file. The path of the file is returned.
+func (r *Reporter) Write(report Report) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if report.Time.IsZero() {
+		report.Time = time.Now()
+	}
+	report.Version = r.version
+	report.ConfigHash = r.configHash
+	report.Goroutines = goroutineDump()
+
+	b, err := yaml.Marshal(report)
+	if err != nil {
+		return "", err
+	}
+
+	if err = os.MkdirAll(r.dir, 0o750); err != nil {
+		return "", err
+	}
+
+	file := filepath.Join(r.dir, filePrefix+report.Time.UTC().Format(timeFormat)+fileSuffix)
+	if err = os.WriteFile(file, b, 0o640); err != nil {
+		return "", err
+	}
+
+	return file, r.prune()
+}
+
+// Previous returns the reports not yet reported, oldest first, and marks them as reported.
+// The report files are kept until they are pruned.
+func (r *Reporter) Previous() ([]Report, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var reports []Report
+	var errs []error
+
+	if report, err := r.previousFatal(); err != nil {
+		errs = append(errs, err)
+	} else if report != nil {
+		reports = append(reports, *report)
+	}
+
+	files, err := filepath.Glob(filepath.Join(r.dir, filePrefix+"*"+fileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		var report Report
+		if err = yaml.Unmarshal(b, &report); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+		}
+
+		report.File = file + reportedSuffix
+		if err = os.Rename(file, report.File); err != nil {
+			errs = append(errs, err)
+			report.File = file
+		}
+		reports = append(reports, report)
+	}
+
+	slices.SortFunc(reports, func(a, b Report) int { return a.Time.Compare(b.Time) })
+	return reports, errors.Join(errs...)
+}
+
+// CaptureFatal writes the traceback of a fatal error or an unrecovered panic to fatal.log in the report directory,
+// it's reported by Previous on the next start. Call Previous before CaptureFatal, the file is truncated.
+func (r *Reporter) CaptureFatal() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(r.dir, 0o750); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(r.dir, fatalFile), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// the header identifies the run, the runtime appends the traceback
+	b, err := yaml.Marshal(struct {
+		Version    string `yaml:"version"`
+		ConfigHash string `yaml:"configHash"`
+	}{r.version, r.configHash})
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+
+	// the runtime keeps a duplicate of the file descriptor
+	return debug.SetCrashOutput(f, debug.CrashOptions{})
+}
+
+// previousFatal returns the report of fatal.log if the previous run terminated with a traceback, nil otherwise.
+// fatal.log is renamed to crash-<time>.fatal.log.reported.
+func (r *Reporter) previousFatal() (*Report, error) {
+	file := filepath.Join(r.dir, fatalFile)
+	info, err := os.Stat(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// the header is separated from the traceback by an empty line
+	header, traceback, _ := strings.Cut(string(b), "\n\n")
+	if strings.TrimSpace(traceback) == "" {
+		return nil, nil
+	}
+
+	var report Report
+	if err = yaml.Unmarshal([]byte(header), &report); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	report.Time = info.ModTime()
+	report.Goroutines = traceback
+	report.Panic, _, _ = strings.Cut(strings.TrimSpace(traceback), "\n")
+	report.File = filepath.Join(r.dir, filePrefix+report.Time.UTC().Format(timeFormat)+fatalSuffix+reportedSuffix)
+
+	if err = os.Rename(file, report.File); err != nil {
+		return &report, err
+	}
+	return &report, nil
+}
+
+// prune removes the oldest reports exceeding maxReports; r.mu must be held.
+func (r *Reporter) prune() error {
+	if r.maxReports <= 0 {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(r.dir, filePrefix+"*"))
+	if err != nil {
+		return err
+	}
+
+	// the time in the file names sorts chronologically, reported files keep their position
+	slices.SortFunc(files, func(a, b string) int {
+		return strings.Compare(strings.TrimSuffix(a, reportedSuffix), strings.TrimSuffix(b, reportedSuffix))
+	})
+
+	var errs []error
+	for len(files) > r.maxReports {
+		errs = append(errs, os.Remove(files[0]))
+		files = files[1:]
+	}
+	return errors.Join(errs...)
+}
+
+// goroutineDump returns the stack traces of all goroutines, truncated to maxDumpSize.
+func goroutineDump() string {
+	for size := 64 << 10; ; size *= 2 {
+		buf := make([]byte, size)
+		n := runtime.Stack(buf, true)
+		if n < size || size >= maxDumpSize {
+			return string(buf[:n])
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package crash
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// files returns the sorted base names of the files in dir.
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestWritePrevious(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "crash")
+	r := NewReporter(dir, 0, "1.0.0", "abc")
+	t0 := time.Date(2025, 2, 24, 10, 15, 0, 0, time.UTC)
+
+	// written out of order, Previous returns the oldest first
+	for _, report := range []Report{
+		{Time: t0.Add(time.Second), RequestID: "req-2", Method: "POST", Path: "/b", Route: "POST /b", Panic: "second", Stack: "stack 2"},
+		{Time: t0, RequestID: "req-1", Method: "GET", Path: "/a", Route: "GET /a", Panic: "first", Stack: "stack 1"},
+	} {
+		file, err := r.Write(report)
+		if err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if want := filepath.Join(dir, "crash-"+report.Time.Format(timeFormat)+".yaml"); file != want {
+			t.Errorf("Write() = %s, want %s", file, want)
+		}
+	}
+
+	reports, err := r.Previous()
+	if err != nil {
+		t.Fatalf("Previous() error = %v", err)
+	}
+
+	tests := []struct {
+		requestID string
+		path      string
+		panic     string
+		time      time.Time
+	}{
+		{"req-1", "/a", "first", t0},
+		{"req-2", "/b", "second", t0.Add(time.Second)},
+	}
+	if len(reports) != len(tests) {
+		t.Fatalf("Previous() = %d reports, want %d", len(reports), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.requestID, func(t *testing.T) {
+			got := reports[i]
+			if got.RequestID != tt.requestID || got.Path != tt.path || got.Panic != tt.panic || !got.Time.Equal(tt.time) {
+				t.Errorf("report = %+v, want request id %s, path %s, panic %s and time %v", got, tt.requestID, tt.path, tt.panic, tt.time)
+			}
+			if got.Version != "1.0.0" || got.ConfigHash != "abc" {
+				t.Errorf("version and config hash = %s %s, want 1.0.0 abc", got.Version, got.ConfigHash)
+			}
+			if !strings.Contains(got.Goroutines, "goroutine ") {
+				t.Errorf("goroutine dump = %q, want the stack traces", got.Goroutines)
+			}
+			if want := filepath.Join(dir, "crash-"+tt.time.Format(timeFormat)+".yaml.reported"); got.File != want {
+				t.Errorf("File = %s, want %s", got.File, want)
+			}
+		})
+	}
+
+	// the reports are renamed and not reported again
+	want := []string{"crash-2025-02-24T10-15-00.000.yaml.reported", "crash-2025-02-24T10-15-01.000.yaml.reported"}
+	if got := files(t, dir); !slices.Equal(got, want) {
+		t.Errorf("files = %q, want %q", got, want)
+	}
+	if reports, err = r.Previous(); err != nil || len(reports) != 0 {
+		t.Errorf("Previous() = %d reports, %v, want none", len(reports), err)
+	}
+}
+
+func TestPreviousWithoutDir(t *testing.T) {
+	r := NewReporter(filepath.Join(t.TempDir(), "missing"), 0, "1.0.0", "abc")
+	if reports, err := r.Previous(); err != nil || len(reports) != 0 {
+		t.Errorf("Previous() = %d reports, %v, want none", len(reports), err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxReports int
+		reported   int
+		written    int
+		want       int
+	}{
+		{"all retained", 0, 2, 3, 5},
+		{"below the maximum", 5, 1, 2, 3},
+		{"exactly the maximum", 3, 1, 2, 3},
+		{"oldest removed", 3, 2, 4, 3},
+		{"single report", 1, 2, 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			r := NewReporter(dir, tt.maxReports, "1.0.0", "abc")
+			t0 := time.Date(2025, 2, 24, 10, 15, 0, 0, time.UTC)
+
+			// reported files of a previous run are older than the new reports
+			n := 0
+			for ; n < tt.reported; n++ {
+				if _, err := r.Write(Report{Time: t0.Add(time.Duration(n) * time.Second)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := r.Previous(); err != nil {
+				t.Fatal(err)
+			}
+			for ; n < tt.reported+tt.written; n++ {
+				if _, err := r.Write(Report{Time: t0.Add(time.Duration(n) * time.Second)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := files(t, dir)
+			if len(got) != tt.want {
+				t.Fatalf("files = %q, want %d files", got, tt.want)
+			}
+			// the newest report is always retained
+			newest := "crash-" + t0.Add(time.Duration(n-1)*time.Second).Format(timeFormat) + ".yaml"
+			if got[len(got)-1] != newest {
+				t.Errorf("files = %q, want the newest %s", got, newest)
+			}
+		})
+	}
+}
+
+func TestPreviousFatal(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantPanic string
+	}{
+		{"traceback", "version: 1.0.0\nconfigHash: abc\n\nfatal error: concurrent map writes\n\ngoroutine 1 [running]:\n", "fatal error: concurrent map writes"},
+		{"unrecovered panic", "version: 1.0.0\nconfigHash: abc\n\npanic: boom\n\ngoroutine 1 [running]:\n", "panic: boom"},
+		{"clean exit", "version: 1.0.0\nconfigHash: abc\n\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, fatalFile), []byte(tt.content), 0o640); err != nil {
+				t.Fatal(err)
+			}
+
+			reports, err := NewReporter(dir, 0, "2.0.0", "def").Previous()
+			if err != nil {
+				t.Fatalf("Previous() error = %v", err)
+			}
+
+			if tt.wantPanic == "" {
+				if len(reports) != 0 {
+					t.Errorf("Previous() = %+v, want no reports", reports)
+				}
+				return
+			}
+
+			if len(reports) != 1 {
+				t.Fatalf("Previous() = %d reports, want 1", len(reports))
+			}
+			got := reports[0]
+			// the version of the crashed run is reported
+			if got.Panic != tt.wantPanic || got.Version != "1.0.0" || got.ConfigHash != "abc" {
+				t.Errorf("report = %+v, want panic %q of version 1.0.0", got, tt.wantPanic)
+			}
+			if !strings.HasSuffix(got.File, ".fatal.log.reported") {
+				t.Errorf("File = %s, want the renamed fatal.log", got.File)
+			}
+			if _, err = os.Stat(filepath.Join(dir, fatalFile)); !os.IsNotExist(err) {
+				t.Errorf("fatal.log not renamed: %v", err)
+			}
+		})
+	}
+}
+
+func TestCaptureFatal(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "crash")
+	if err := NewReporter(dir, 0, "1.0.0", "abc").CaptureFatal(); err != nil {
+		t.Fatalf("CaptureFatal() error = %v", err)
+	}
+	defer func() { _ = debug.SetCrashOutput(nil, debug.CrashOptions{}) }()
+
+	b, err := os.ReadFile(filepath.Join(dir, fatalFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "version: 1.0.0\nconfigHash: abc\n\n"; string(b) != want {
+		t.Errorf("fatal.log = %q, want %q", b, want)
+	}
+}
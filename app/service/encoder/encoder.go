@@ -0,0 +1,225 @@
+package encoder
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/womat/go-api-template/app/service/problem"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Encoder writes the json representation of a response in a format.
+// v is a json object (keys in order), []any, string, json.Number, bool or nil.
+type Encoder interface {
+	Encode(w io.Writer, v any, opts Options) error
+}
+
+// Options are the options of an encoder.
+type Options struct {
+	// Pretty indents the output (json, xml), set by the query parameter pretty.
+	Pretty bool
+
+	// Rows is the dotted path of the array written as table rows (csv, text), e.g. services.
+	Rows string
+}
+
+// Option sets an option of the encoder.
+type Option func(*Options)
+
+// Rows sets the dotted path of the array written as table rows (csv, text).
+// Without rows, an array response is written as a row per element and an object as a row per key.
+func Rows(path string) Option {
+	return func(o *Options) {
+		o.Rows = path
+	}
+}
+
+// format is a registered format.
+type format struct {
+	name       string
+	mediaTypes []string
+	encoder    Encoder
+}
+
+// contentType returns the content type of the format, text media types are utf-8.
+func (f format) contentType() string {
+	if strings.HasPrefix(f.mediaTypes[0], "text/") {
+		return f.mediaTypes[0] + "; charset=utf-8"
+	}
+	return f.mediaTypes[0]
+}
+
+// Registry holds the response formats, a format is selected by the query parameter format
+// or by the Accept header of the request.
+//
+//	encoder.Write(w, r, http.StatusOK, resp, encoder.Rows("services"))
+type Registry struct {
+	formats []format
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Default is the registry with the formats json (default), yaml, csv, text and xml.
+var Default = NewRegistry().
+	Register("json", JSON{}, "application/json").
+	Register("yaml", YAML{}, "application/yaml", "application/x-yaml", "text/yaml").
+	Register("csv", CSV{}, "text/csv").
+	Register("text", Text{}, "text/plain").
+	Register("xml", XML{}, "application/xml", "text/xml")
+
+// Register adds a format with its name (query parameter format) and media types (Accept header),
+// the first media type is the content type of the response. The first registered format is the default.
+// A format with the same name is replaced.
+func (r *Registry) Register(name string, e Encoder, mediaTypes ...string) *Registry {
+	f := format{name: name, mediaTypes: mediaTypes, encoder: e}
+	if i := slices.IndexFunc(r.formats, func(f format) bool { return f.name == name }); i >= 0 {
+		r.formats[i] = f
+	} else {
+		r.formats = append(r.formats, f)
+	}
+	return r
+}
+
+// Formats returns the names of the registered formats.
+func (r *Registry) Formats() []string {
+	names := make([]string, len(r.formats))
+	for i, f := range r.formats {
+		names[i] = f.name
+	}
+	return names
+}
+
+// Write writes v with status in the format of the request. v is converted to its json representation first,
+// so the json tags define the names in all formats.
+// The query parameters are:
+//   - format: name of the format, e.g. yaml; it takes precedence over the Accept header
+//   - pretty: indents the output (json, xml)
+//   - fields: comma separated list of dotted fields to return, e.g. services.Service,services.Value
+//
+// If no format matches the Accept header, the default format is written.
+func (r *Registry) Write(w http.ResponseWriter, req *http.Request, status int, v any, opts ...Option) {
+	f, err := r.negotiate(req)
+	if err != nil {
+		problem.Write(w, req, err)
+		return
+	}
+
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	q := req.URL.Query()
+	if s := q.Get("pretty"); s != "" {
+		if o.Pretty, err = strconv.ParseBool(s); err != nil {
+			problem.Write(w, req, problem.Validation("invalid parameter", problem.Field("pretty", "expected a boolean")))
+			return
+		}
+	}
+
+	value, err := toValue(v)
+	if err != nil {
+		problem.Write(w, req, problem.Internal(err))
+		return
+	}
+	if fields := q.Get("fields"); fields != "" {
+		value = project(value, parseFields(fields))
+	}
+
+	var buf bytes.Buffer
+	if err = f.encoder.Encode(&buf, value, o); err != nil {
+		problem.Write(w, req, problem.Internal(fmt.Errorf("%s encoding failed: %w", f.name, err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", f.contentType())
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// negotiate returns the format of the query parameter format or the best match of the Accept header.
+// A format is only selected by the Accept header if the client doesn't prefer a media type without format:
+// a browser accepts text/html before application/xml;q=0.9, it gets the default format instead of xml.
+func (r *Registry) negotiate(req *http.Request) (format, error) {
+	if len(r.formats) == 0 {
+		return format{}, problem.Internal(fmt.Errorf("no response format registered"))
+	}
+
+	if name := req.URL.Query().Get("format"); name != "" {
+		for _, f := range r.formats {
+			if strings.EqualFold(f.name, name) {
+				return f, nil
+			}
+		}
+		return format{}, problem.Validation("invalid parameter",
+			problem.Field("format", "supported formats are "+strings.Join(r.Formats(), ", ")))
+	}
+
+	type accepted struct {
+		mediaType string
+		q         float64
+	}
+
+	var accepts []accepted
+	for _, accept := range req.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			q := 1.0
+			if s, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(s, 64); err != nil {
+					continue
+				}
+			}
+			if q > 0 {
+				accepts = append(accepts, accepted{mediaType: mediaType, q: q})
+			}
+		}
+	}
+	slices.SortStableFunc(accepts, func(a, b accepted) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	// preferred is the quality of the first accepted media type without format, e.g. text/html
+	preferred := 0.0
+	for _, a := range accepts {
+		for _, f := range r.formats {
+			for _, mt := range f.mediaTypes {
+				prefix, wildcard := strings.CutSuffix(a.mediaType, "/*")
+				if a.mediaType == mt || a.mediaType == "*/*" || wildcard && strings.HasPrefix(mt, prefix+"/") {
+					if a.q < preferred {
+						return r.formats[0], nil
+					}
+					return f, nil
+				}
+			}
+		}
+		if preferred == 0 && !strings.HasSuffix(a.mediaType, "/*") {
+			preferred = a.q
+		}
+	}
+
+	return r.formats[0], nil
+}
+
+// Write writes v in the format of the request using the Default registry, see Registry.Write.
+func Write(w http.ResponseWriter, r *http.Request, status int, v any, opts ...Option) {
+	Default.Write(w, r, status, v, opts...)
+}
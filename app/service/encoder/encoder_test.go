@@ -0,0 +1,195 @@
+package encoder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// service and response are a typical api response with a nested array.
+type service struct {
+	Service string `json:"service"`
+	Value   any    `json:"value"`
+	Unit    string `json:"unit,omitempty"`
+}
+
+type response struct {
+	Host     string    `json:"host"`
+	Services []service `json:"services"`
+}
+
+var testResponse = response{
+	Host: "pi",
+	Services: []service{
+		{Service: "Heap Alloc", Value: 1024, Unit: "bytes"},
+		{Service: "Version", Value: "1.0.0"},
+	},
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		accept  []string
+		want    string
+		wantErr bool
+	}{
+		{"default", "", nil, "json", false},
+		{"format parameter", "format=yaml", nil, "yaml", false},
+		{"format parameter is case insensitive", "format=CSV", nil, "csv", false},
+		{"format parameter overrides accept", "format=xml", []string{"text/csv"}, "xml", false},
+		{"unknown format parameter", "format=toml", nil, "", true},
+		{"accept", "", []string{"text/csv"}, "csv", false},
+		{"accept alias", "", []string{"application/x-yaml"}, "yaml", false},
+		{"accept with parameters", "", []string{"text/plain; charset=utf-8"}, "text", false},
+		{"quality", "", []string{"application/xml;q=0.5, text/csv;q=0.8"}, "csv", false},
+		{"first of equal quality", "", []string{"text/csv, application/xml"}, "csv", false},
+		{"q=0 is not acceptable", "", []string{"text/csv;q=0, application/xml;q=0.1"}, "xml", false},
+		{"invalid quality is skipped", "", []string{"text/csv;q=x, application/xml"}, "xml", false},
+		{"multiple headers", "", []string{"text/html", "application/yaml"}, "yaml", false},
+		{"type wildcard", "", []string{"text/*"}, "yaml", false},
+		{"wildcard", "", []string{"*/*"}, "json", false},
+		{"no match", "", []string{"image/png"}, "json", false},
+		{"browser", "", []string{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"}, "json", false},
+		{"browser with images", "", []string{"text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8"}, "json", false},
+		{"preferred media type without format", "", []string{"application/pdf, text/csv;q=0.5"}, "json", false},
+		{"media type without format of equal quality", "", []string{"text/html, application/xml"}, "xml", false},
+		{"browser with explicit format", "format=xml", []string{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"}, "xml", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/monitoring?"+tt.query, nil)
+			for _, a := range tt.accept {
+				r.Header.Add("Accept", a)
+			}
+
+			f, err := Default.negotiate(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("negotiate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if f.name != tt.want {
+				t.Errorf("negotiate() = %q, want %q", f.name, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry().
+		Register("json", JSON{}, "application/json").
+		Register("csv", CSV{}, "text/csv").
+		Register("json", JSON{}, "application/vnd.api+json")
+
+	if got := strings.Join(r.Formats(), ","); got != "json,csv" {
+		t.Errorf("Formats() = %s, want json,csv", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/vnd.api+json")
+	if f, err := r.negotiate(req); err != nil || f.contentType() != "application/vnd.api+json" {
+		t.Errorf("negotiate() = %v, %v, want the replaced json format", f, err)
+	}
+
+	w := httptest.NewRecorder()
+	NewRegistry().Write(w, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, testResponse)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status without formats = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		accept          string
+		opts            []Option
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "json",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `{"host":"pi","services":[{"service":"Heap Alloc","value":1024,"unit":"bytes"},{"service":"Version","value":"1.0.0"}]}`,
+		},
+		{
+			name:            "pretty json",
+			query:           "fields=host&pretty=true",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        "{\n  \"host\": \"pi\"\n}\n",
+		},
+		{
+			name:            "invalid pretty",
+			query:           "pretty=yes",
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: "application/json",
+			wantBody:        `{"error":"invalid parameter: pretty: expected a boolean"}`,
+		},
+		{
+			name:            "unknown format",
+			query:           "format=toml",
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: "application/json",
+			wantBody:        `{"error":"invalid parameter: format: supported formats are json, yaml, csv, text, xml"}`,
+		},
+		{
+			name:            "fields of array elements",
+			query:           "fields=services.service,services.value",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `{"services":[{"service":"Heap Alloc","value":1024},{"service":"Version","value":"1.0.0"}]}`,
+		},
+		{
+			name:            "csv rows",
+			query:           "format=csv",
+			opts:            []Option{Rows("services")},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			wantBody:        "service,value,unit\nHeap Alloc,1024,bytes\nVersion,1.0.0,\n",
+		},
+		{
+			name:            "text by accept",
+			accept:          "text/plain",
+			opts:            []Option{Rows("services")},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "SERVICE     VALUE  UNIT\nHeap Alloc  1024   bytes\nVersion     1.0.0  \n",
+		},
+		{
+			name:            "yaml",
+			query:           "format=yaml&fields=services.value",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/yaml",
+			wantBody:        "services:\n  - value: 1024\n  - value: 1.0.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/monitoring?"+tt.query, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			Write(w, r, http.StatusOK, testResponse, tt.opts...)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if got := strings.TrimSuffix(w.Body.String(), "\n"); got != strings.TrimSuffix(tt.wantBody, "\n") {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if tt.wantStatus == http.StatusOK && w.Header().Get("Vary") != "Accept" {
+				t.Errorf("Vary = %q, want Accept", w.Header().Get("Vary"))
+			}
+		})
+	}
+}
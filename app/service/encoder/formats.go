@@ -0,0 +1,185 @@
+package encoder
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+)
+
+// JSON writes json, indented with two spaces if pretty.
+type JSON struct{}
+
+// Encode writes v as json.
+func (JSON) Encode(w io.Writer, v any, opts Options) error {
+	var b []byte
+	var err error
+	if opts.Pretty {
+		b, err = json.MarshalIndent(v, "", "  ")
+		b = append(b, '\n')
+	} else {
+		b, err = json.Marshal(v)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// YAML writes yaml with the keys in the order of the json representation.
+type YAML struct{}
+
+// Encode writes v as yaml.
+func (YAML) Encode(w io.Writer, v any, _ Options) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(yamlNode(v)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// yamlNode returns the yaml node of a json value.
+func yamlNode(v any) *yaml.Node {
+	switch v := v.(type) {
+	case *object:
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		for _, k := range v.keys {
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, yamlNode(v.values[k]))
+		}
+		return n
+	case []any:
+		n := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, e := range v {
+			n.Content = append(n.Content, yamlNode(e))
+		}
+		return n
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(v.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: scalar(v)}
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: scalar(v)}
+	}
+}
+
+// CSV writes a csv table with a header row, see table for the rows and columns.
+type CSV struct{}
+
+// Encode writes v as csv.
+func (CSV) Encode(w io.Writer, v any, opts Options) error {
+	header, records := table(v, opts.Rows)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	return cw.WriteAll(records)
+}
+
+// Text writes a plain text table with aligned columns, see table for the rows and columns.
+type Text struct{}
+
+// Encode writes v as text table.
+func (Text) Encode(w io.Writer, v any, opts Options) error {
+	header, records := table(v, opts.Rows)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, h := range header {
+		header[i] = strings.ToUpper(h)
+	}
+	for _, record := range append([][]string{header}, records...) {
+		for i, field := range record {
+			// tabs and newlines would break the alignment
+			record[i] = strings.Join(strings.Fields(field), " ")
+		}
+		if _, err := fmt.Fprintln(tw, strings.Join(record, "\t")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// XML writes xml with the root element response, array elements are written as item elements.
+// Keys which aren't valid xml names are sanitized, e.g. "Heap Alloc" is written as Heap_Alloc.
+type XML struct{}
+
+// Encode writes v as xml.
+func (XML) Encode(w io.Writer, v any, opts Options) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	if opts.Pretty {
+		enc.Indent("", "  ")
+	}
+	if err := encodeXML(enc, "response", v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// encodeXML writes v as element with the given name.
+func encodeXML(enc *xml.Encoder, name string, v any) error {
+	start := xml.StartElement{Name: xml.Name{Local: xmlName(name)}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case *object:
+		for _, k := range v.keys {
+			if err := encodeXML(enc, k, v.values[k]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, e := range v {
+			if err := encodeXML(enc, "item", e); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := enc.EncodeToken(xml.CharData(scalar(v))); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// xmlName returns a valid xml element name, invalid characters are replaced by an underscore.
+func xmlName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			if i == 0 && unicode.IsDigit(r) {
+				b.WriteRune('_')
+				b.WriteRune(r)
+				continue
+			}
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 || strings.HasPrefix(strings.ToLower(b.String()), "xml") {
+		return "_" + b.String()
+	}
+	return b.String()
+}
@@ -0,0 +1,98 @@
+package encoder
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	nested := map[string]any{"b": 1, "a": []any{true, nil}}
+	mixed := []map[string]any{{"name": "a", "tags": []string{"x"}}, {"name": "b", "size": 2.5}}
+
+	tests := []struct {
+		name    string
+		encoder Encoder
+		v       any
+		opts    Options
+		want    string
+	}{
+		{"json", JSON{}, testResponse, Options{Rows: "services"},
+			`{"host":"pi","services":[{"service":"Heap Alloc","value":1024,"unit":"bytes"},{"service":"Version","value":"1.0.0"}]}`},
+		{"json pretty", JSON{}, []int{1}, Options{Pretty: true}, "[\n  1\n]\n"},
+
+		{"yaml keeps the key order", YAML{}, testResponse, Options{},
+			"host: pi\nservices:\n  - service: Heap Alloc\n    value: 1024\n    unit: bytes\n  - service: Version\n    value: 1.0.0\n"},
+		{"yaml scalars", YAML{}, map[string]any{"f": 1.5, "n": nil, "s": "true", "t": true}, Options{},
+			"f: 1.5\nn: null\ns: \"true\"\nt: true\n"},
+
+		{"csv rows", CSV{}, testResponse, Options{Rows: "services"},
+			"service,value,unit\nHeap Alloc,1024,bytes\nVersion,1.0.0,\n"},
+		{"csv object as key value", CSV{}, nested, Options{},
+			"key,value\na.0,true\na.1,\nb,1\n"},
+		{"csv rows path without array", CSV{}, testResponse, Options{Rows: "host"},
+			"key,value\nhost,pi\nservices.0.service,Heap Alloc\nservices.0.value,1024\nservices.0.unit,bytes\nservices.1.service,Version\nservices.1.value,1.0.0\n"},
+		{"csv union of columns", CSV{}, mixed, Options{},
+			"name,tags.0,size\na,x,\nb,,2.5\n"},
+		{"csv array of scalars", CSV{}, []string{"a", "b,c"}, Options{},
+			"value\na\n\"b,c\"\n"},
+		{"csv quoting", CSV{}, []map[string]string{{"msg": "say \"hi\"\nbye"}}, Options{},
+			"msg\n\"say \"\"hi\"\"\nbye\"\n"},
+
+		{"text", Text{}, testResponse, Options{Rows: "services"},
+			"SERVICE     VALUE  UNIT\nHeap Alloc  1024   bytes\nVersion     1.0.0  \n"},
+		{"text whitespace is collapsed", Text{}, map[string]string{"msg": "a\tb\n c"}, Options{},
+			"KEY  VALUE\nmsg  a b c\n"},
+
+		{"xml", XML{}, testResponse, Options{},
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<response><host>pi</host><services><item><service>Heap Alloc</service><value>1024</value><unit>bytes</unit></item>` +
+				`<item><service>Version</service><value>1.0.0</value></item></services></response>`},
+		{"xml pretty and escaping", XML{}, map[string]any{"Heap Alloc": "<1 & 2>", "n": nil}, Options{Pretty: true},
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				"<response>\n  <Heap_Alloc>&lt;1 &amp; 2&gt;</Heap_Alloc>\n  <n></n>\n</response>"},
+		{"xml scalar", XML{}, 42, Options{}, `<?xml version="1.0" encoding="UTF-8"?>` + "\n<response>42</response>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := toValue(tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var b bytes.Buffer
+			if err = tt.encoder.Encode(&b, v, tt.opts); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("Encode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXMLName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"host", "host"},
+		{"Heap Alloc", "Heap_Alloc"},
+		{"go-version.1", "go-version.1"},
+		{"1st", "_1st"},
+		{"-x", "_x"},
+		{"", "_"},
+		{"xmlns", "_xmlns"},
+		{"XMLData", "_XMLData"},
+		{"größe", "größe"},
+		{"a:b", "a_b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := xmlName(tt.name); got != tt.want {
+				t.Errorf("xmlName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package encoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// object is a json object which keeps the order of its keys.
+type object struct {
+	keys   []string
+	values map[string]any
+}
+
+// get returns the value of the key, the key is matched case-insensitively if there is no exact match.
+func (o *object) get(key string) (string, any, bool) {
+	if v, ok := o.values[key]; ok {
+		return key, v, true
+	}
+	for _, k := range o.keys {
+		if strings.EqualFold(k, key) {
+			return k, o.values[k], true
+		}
+	}
+	return "", nil, false
+}
+
+// MarshalJSON writes the object with the keys in their original order.
+func (o *object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// toValue converts v to its json representation: *object, []any, string, json.Number, bool or nil.
+// The json tags of v define the names, so all formats use the same names as the json format.
+func toValue(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return decodeValue(dec)
+}
+
+// decodeValue decodes the next json value of dec.
+func decodeValue(dec *json.Decoder) (any, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t {
+	case json.Delim('{'):
+		o := &object{values: map[string]any{}}
+		for dec.More() {
+			t, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := t.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected json token %v", t)
+			}
+			if o.values[key], err = decodeValue(dec); err != nil {
+				return nil, err
+			}
+			o.keys = append(o.keys, key)
+		}
+		_, err = dec.Token()
+		return o, err
+
+	case json.Delim('['):
+		a := []any{}
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		_, err = dec.Token()
+		return a, err
+
+	default:
+		if d, ok := t.(json.Delim); ok {
+			return nil, fmt.Errorf("unexpected json delimiter %v", d)
+		}
+		return t, nil
+	}
+}
+
+// project keeps the fields of v selected by the paths, e.g. services.Service.
+// A path selects a key of an object and is applied to every element of an array.
+// Keys are matched case-insensitively, the order of the keys is kept.
+func project(v any, paths [][]string) any {
+	switch v := v.(type) {
+	case *object:
+		selected := map[string][][]string{}
+		for _, path := range paths {
+			key, _, ok := v.get(path[0])
+			if !ok {
+				continue
+			}
+			switch rest := path[1:]; {
+			case len(rest) == 0:
+				// an empty rest selects the whole value
+				selected[key] = [][]string{{}}
+			case len(selected[key]) == 1 && len(selected[key][0]) == 0:
+				// the whole value is already selected
+			default:
+				selected[key] = append(selected[key], rest)
+			}
+		}
+
+		o := &object{values: map[string]any{}}
+		for _, k := range v.keys {
+			rest, ok := selected[k]
+			if !ok {
+				continue
+			}
+			o.keys = append(o.keys, k)
+			if len(rest[0]) == 0 {
+				o.values[k] = v.values[k]
+			} else {
+				o.values[k] = project(v.values[k], rest)
+			}
+		}
+		return o
+
+	case []any:
+		a := make([]any, len(v))
+		for i, e := range v {
+			a[i] = project(e, paths)
+		}
+		return a
+
+	default:
+		return v
+	}
+}
+
+// parseFields returns the paths of a comma separated list of dotted field names.
+func parseFields(fields string) [][]string {
+	var paths [][]string
+	for _, f := range strings.Split(fields, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			paths = append(paths, strings.Split(f, "."))
+		}
+	}
+	return paths
+}
+
+// lookup returns the value at the dotted path, keys are matched case-insensitively.
+func lookup(v any, path string) (any, bool) {
+	if path == "" {
+		return v, true
+	}
+	for _, key := range strings.Split(path, ".") {
+		o, ok := v.(*object)
+		if !ok {
+			return nil, false
+		}
+		if _, v, ok = o.get(key); !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// flatten appends the scalar values of v with their dotted keys, array elements are keyed by their index.
+func flatten(prefix string, v any, keys []string, values map[string]string) []string {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := v.(type) {
+	case *object:
+		for _, k := range v.keys {
+			keys = flatten(join(k), v.values[k], keys, values)
+		}
+	case []any:
+		for i, e := range v {
+			keys = flatten(join(strconv.Itoa(i)), e, keys, values)
+		}
+	default:
+		if _, ok := values[prefix]; !ok {
+			keys = append(keys, prefix)
+		}
+		values[prefix] = scalar(v)
+	}
+	return keys
+}
+
+// scalar returns the text of a scalar json value, null is an empty string.
+func scalar(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// table returns the header and the records of v in tabular form:
+//   - an array of objects is a row per element with the flattened keys of all elements as columns
+//   - an array of scalars is a row per element with the column value
+//   - any other value is a row per flattened key with the columns key and value
+//
+// rows is the dotted path of the array used as rows, it's ignored if v has no array at the path.
+func table(v any, rows string) (header []string, records [][]string) {
+	if a, ok := lookup(v, rows); ok {
+		if _, ok := a.([]any); ok {
+			v = a
+		}
+	}
+
+	a, ok := v.([]any)
+	if !ok {
+		values := map[string]string{}
+		for _, k := range flatten("", v, nil, values) {
+			records = append(records, []string{k, values[k]})
+		}
+		return []string{"key", "value"}, records
+	}
+
+	columns := map[string]bool{}
+	rowValues := make([]map[string]string, len(a))
+	for i, e := range a {
+		rowValues[i] = map[string]string{}
+		for _, k := range flatten("", e, nil, rowValues[i]) {
+			if !columns[k] {
+				columns[k] = true
+				header = append(header, k)
+			}
+		}
+	}
+	if len(header) == 1 && header[0] == "" {
+		header[0] = "value"
+		for i := range rowValues {
+			rowValues[i]["value"] = rowValues[i][""]
+		}
+	}
+
+	for _, values := range rowValues {
+		record := make([]string, len(header))
+		for i, k := range header {
+			record[i] = values[k]
+		}
+		records = append(records, record)
+	}
+	return header, records
+}
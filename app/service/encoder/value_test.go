@@ -0,0 +1,75 @@
+package encoder
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestToValue(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"struct keeps the field order", testResponse,
+			`{"host":"pi","services":[{"service":"Heap Alloc","value":1024,"unit":"bytes"},{"service":"Version","value":"1.0.0"}]}`},
+		{"large numbers are exact", map[string]uint64{"n": 1<<64 - 1}, `{"n":18446744073709551615}`},
+		{"empty array", []int{}, `[]`},
+		{"nil", nil, `null`},
+		{"scalar", "x", `"x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := toValue(tt.v)
+			if err != nil {
+				t.Fatalf("toValue() error = %v", err)
+			}
+			b, err := json.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("toValue() = %s, want %s", b, tt.want)
+			}
+		})
+	}
+
+	if _, err := toValue(func() {}); err == nil {
+		t.Error("toValue(func) error = nil, want an error")
+	}
+}
+
+func TestProject(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields string
+		want   string
+	}{
+		{"top level key", "host", `{"host":"pi"}`},
+		{"case insensitive", "HOST", `{"host":"pi"}`},
+		{"keys keep their order", "services,host", `{"host":"pi","services":[{"service":"Heap Alloc","value":1024,"unit":"bytes"},{"service":"Version","value":"1.0.0"}]}`},
+		{"array elements", "services.unit", `{"services":[{"unit":"bytes"},{}]}`},
+		{"whole value wins over a sub field", "services.unit,services", `{"services":[{"service":"Heap Alloc","value":1024,"unit":"bytes"},{"service":"Version","value":"1.0.0"}]}`},
+		{"sub field after the whole value", "services,services.unit", `{"services":[{"service":"Heap Alloc","value":1024,"unit":"bytes"},{"service":"Version","value":"1.0.0"}]}`},
+		{"unknown field", "nope", `{}`},
+		{"sub field of a scalar", "host.name", `{"host":"pi"}`},
+		{"spaces and empty fields", " host , ,", `{"host":"pi"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := toValue(testResponse)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(project(v, parseFields(tt.fields)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("project(%q) = %s, want %s", tt.fields, b, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package health
 
 import (
+	"github.com/womat/go-api-template/app/service/cgroup"
+	"github.com/womat/go-api-template/app/service/host"
+	"github.com/womat/go-api-template/app/service/lifecycle"
+	"github.com/womat/go-api-template/app/service/process"
+	"github.com/womat/go-api-template/app/service/runtimestats"
 	"os"
 	"runtime"
 	"time"
@@ -37,32 +42,51 @@ type Model struct {
 
 	// OperatingSystem is the name of the operating system on which the application is running.
 	OperatingSystem string `json:"OperatingSystem"`
+
+	// GC holds the garbage collector statistics and the GOMEMLIMIT/GOMAXPROCS in effect.
+	GC runtimestats.GCStats `json:"GC"`
+
+	// Container holds the cgroup cpu and memory limits and the headroom, nil if not running in a cgroup.
+	Container *cgroup.Stats `json:"Container,omitempty"`
+
+	// Process is the process-level health data (rss, file descriptors, threads, context switches), nil if disabled.
+	Process *process.Stats `json:"Process,omitempty"`
+
+	// Host is the host-level health data (cpu, load, memory, disks, temperatures, network), nil if disabled.
+	Host *host.Stats `json:"Host,omitempty"`
+
+	// Components is the lifecycle and health status of the application components.
+	Components []lifecycle.Status `json:"Components"`
 }
 
-// Health returns the current health data of the application and system.
-func Health(version string) Model {
+// Health returns the health data of the application and system.
+// snap is the latest runtime statistics snapshot, containerStats, processStats and hostStats the latest cgroup,
+// process and host stats (nil if disabled) and components is the status of the application components.
+func Health(version string, snap *runtimestats.Snapshot, containerStats *cgroup.Stats, processStats *process.Stats, hostStats *host.Stats, components []lifecycle.Status) Model {
 	bToMb := func(b uint64) float64 {
 		return float64(b) / (1024 * 1024)
 	}
-	host, err := os.Hostname()
+	hostName, err := os.Hostname()
 	if err != nil {
-		host = "unknown"
+		hostName = "unknown"
 	}
 
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
-
 	model := Model{
-		NumGoroutines:      runtime.NumGoroutine(),
-		HeapAllocatedBytes: m.Alloc,
-		HeapAllocatedMB:    bToMb(m.Alloc),
-		SysMemoryBytes:     m.Sys,
-		SysMemoryMB:        bToMb(m.Sys),
+		NumGoroutines:      int(snap.Goroutines),
+		HeapAllocatedBytes: snap.HeapAlloc,
+		HeapAllocatedMB:    bToMb(snap.HeapAlloc),
+		SysMemoryBytes:     snap.Sys,
+		SysMemoryMB:        bToMb(snap.Sys),
 		ProgLang:           runtime.Version(),
 		Version:            version,
-		HostName:           host,
-		Time:               time.Now().Format(time.RFC3339),
+		HostName:           hostName,
+		Time:               snap.Time.Format(time.RFC3339),
 		OperatingSystem:    runtime.GOOS,
+		GC:                 snap.GC,
+		Container:          containerStats,
+		Process:            processStats,
+		Host:               hostStats,
+		Components:         components,
 	}
 
 	return model
@@ -0,0 +1,348 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/womat/go-api-template/app/service/monitoring"
+	"log/slog"
+	"math"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownService = errors.New("unknown service")
+	ErrInvalidRange   = errors.New("invalid time range")
+)
+
+// Tier is a downsampling level of the history.
+// Values are aggregated into buckets of the tier resolution and kept for the tier retention.
+type Tier struct {
+	// Resolution is the duration of a bucket.
+	Resolution time.Duration `yaml:"resolution" json:"resolution"`
+
+	// Retention is the duration the buckets are kept.
+	Retention time.Duration `yaml:"retention" json:"retention"`
+}
+
+// DefaultTiers keeps 10s buckets for 1 hour, 1m buckets for 1 day and 10m buckets for 1 week.
+var DefaultTiers = []Tier{
+	{Resolution: 10 * time.Second, Retention: time.Hour},
+	{Resolution: time.Minute, Retention: 24 * time.Hour},
+	{Resolution: 10 * time.Minute, Retention: 7 * 24 * time.Hour},
+}
+
+// Source returns the current monitoring data to be recorded.
+type Source func() []monitoring.Model
+
+// Point is the aggregation of the values within a bucket.
+type Point struct {
+	// Time is the start time of the bucket.
+	Time time.Time `json:"time"`
+
+	// Min is the minimum value within the bucket.
+	Min float64 `json:"min"`
+
+	// Max is the maximum value within the bucket.
+	Max float64 `json:"max"`
+
+	// Avg is the average value within the bucket.
+	Avg float64 `json:"avg"`
+
+	// Count is the number of recorded values within the bucket.
+	Count uint32 `json:"count"`
+}
+
+// bucket aggregates the values recorded within a tier resolution.
+type bucket struct {
+	Start int64   `json:"s,omitempty"` // start time in unix seconds, 0 means empty
+	Min   float64 `json:"n,omitempty"`
+	Max   float64 `json:"x,omitempty"`
+	Sum   float64 `json:"u,omitempty"`
+	Count uint32  `json:"c,omitempty"`
+}
+
+// add records value v in the bucket.
+func (b *bucket) add(v float64) {
+	if b.Count == 0 {
+		b.Min, b.Max = v, v
+	}
+	b.Min = math.Min(b.Min, v)
+	b.Max = math.Max(b.Max, v)
+	b.Sum += v
+	b.Count++
+}
+
+// ring is a fixed size ring buffer of buckets of one tier and service.
+type ring []bucket
+
+// Store keeps a bounded in-memory time series of every numeric monitoring value.
+// Values are recorded at the resolution of the finest tier and downsampled into all tiers.
+// If a file is configured, the history is loaded on start and saved on stop.
+type Store struct {
+	mu     sync.RWMutex
+	tiers  []Tier
+	series map[string][]ring // service -> ring per tier
+	source Source
+	file   string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New returns a new Store with the given tiers (sorted by resolution), source provides the recorded values.
+// file is the path the history is persisted to, empty means in-memory only.
+func New(tiers []Tier, source Source, file string) (*Store, error) {
+	if len(tiers) == 0 {
+		tiers = DefaultTiers
+	}
+
+	tiers = slices.Clone(tiers)
+	slices.SortFunc(tiers, func(a, b Tier) int { return int(a.Resolution - b.Resolution) })
+	for _, t := range tiers {
+		if t.Resolution < time.Second || t.Resolution%time.Second != 0 || t.Retention < t.Resolution {
+			return nil, fmt.Errorf("invalid history tier %v/%v: resolution must be whole seconds and not exceed the retention", t.Resolution, t.Retention)
+		}
+	}
+
+	return &Store{tiers: tiers, series: map[string][]ring{}, source: source, file: file}, nil
+}
+
+// Name returns the component name.
+func (s *Store) Name() string {
+	return "history"
+}
+
+// Start loads the persisted history and records the source values at the finest tier resolution.
+func (s *Store) Start(ctx context.Context) error {
+	if s.file != "" {
+		if err := s.load(); err != nil {
+			slog.Warn("Failed to load monitoring history, starting with an empty history", "file", s.file, "error", err)
+		}
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.tiers[0].Resolution)
+		defer ticker.Stop()
+
+		for {
+			s.Record(time.Now(), s.source())
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops recording and saves the history if a file is configured.
+func (s *Store) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if s.file == "" {
+		return nil
+	}
+	return s.save()
+}
+
+// Record adds the numeric values of services at time t to all tiers.
+func (s *Store) Record(t time.Time, services []monitoring.Model) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, svc := range services {
+		v, ok := monitoring.ToFloat(svc.Value)
+		if !ok {
+			continue
+		}
+
+		rings, ok := s.series[svc.Service]
+		if !ok {
+			rings = make([]ring, len(s.tiers))
+			for i, tier := range s.tiers {
+				rings[i] = make(ring, tier.Retention/tier.Resolution)
+			}
+			s.series[svc.Service] = rings
+		}
+
+		for i, tier := range s.tiers {
+			start := t.Truncate(tier.Resolution).Unix()
+			b := &rings[i][start/int64(tier.Resolution.Seconds())%int64(len(rings[i]))]
+			if b.Start != start {
+				*b = bucket{Start: start}
+			}
+			b.add(v)
+		}
+	}
+}
+
+// Services returns the names of the recorded services.
+func (s *Store) Services() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.series))
+	for name := range s.series {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Query returns the values of service between from and to, aggregated into buckets of step.
+// The finest tier covering from is used, step is rounded up to a multiple of its resolution.
+// A step of 0 means the tier resolution. Empty buckets are omitted.
+// It returns the used step.
+func (s *Store) Query(service string, from, to time.Time, step time.Duration) ([]Point, time.Duration, error) {
+	if !from.Before(to) {
+		return nil, 0, ErrInvalidRange
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rings, ok := s.series[service]
+	if !ok {
+		return nil, 0, ErrUnknownService
+	}
+
+	// use the finest tier which covers the requested range, or the coarsest tier
+	tier := len(s.tiers) - 1
+	for i, t := range s.tiers {
+		if time.Since(from) <= t.Retention {
+			tier = i
+			break
+		}
+	}
+
+	resolution := s.tiers[tier].Resolution
+	if step < resolution {
+		step = resolution
+	}
+	step = ((step + resolution - 1) / resolution) * resolution
+
+	// aggregate the tier buckets into buckets of step
+	agg := map[int64]*bucket{}
+	for _, b := range rings[tier] {
+		if b.Count == 0 || b.Start < from.Truncate(step).Unix() || b.Start >= to.Unix() {
+			continue
+		}
+
+		start := time.Unix(b.Start, 0).Truncate(step).Unix()
+		a, ok := agg[start]
+		if !ok {
+			a = &bucket{Start: start, Min: b.Min, Max: b.Max}
+			agg[start] = a
+		}
+		a.Min = math.Min(a.Min, b.Min)
+		a.Max = math.Max(a.Max, b.Max)
+		a.Sum += b.Sum
+		a.Count += b.Count
+	}
+
+	points := make([]Point, 0, len(agg))
+	for _, a := range agg {
+		points = append(points, Point{
+			Time:  time.Unix(a.Start, 0).UTC(),
+			Min:   a.Min,
+			Max:   a.Max,
+			Avg:   a.Sum / float64(a.Count),
+			Count: a.Count,
+		})
+	}
+	slices.SortFunc(points, func(a, b Point) int { return a.Time.Compare(b.Time) })
+
+	return points, step, nil
+}
+
+// persisted is the file format of the history.
+type persisted struct {
+	Tiers  []Tier            `json:"tiers"`
+	Series map[string][]ring `json:"series"`
+}
+
+// save writes the history to the file, the file is replaced atomically.
+func (s *Store) save() error {
+	s.mu.RLock()
+	b, err := json.Marshal(persisted{Tiers: s.tiers, Series: s.series})
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := s.file + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.file)
+}
+
+// load reads the history from the file, it's ignored if the tiers have changed or the series are invalid.
+func (s *Store) load() error {
+	b, err := os.ReadFile(s.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var p persisted
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
+	}
+	if !slices.Equal(p.Tiers, s.tiers) {
+		return errors.New("history tiers have changed")
+	}
+	if err := s.validate(p.Series); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.series = p.Series
+	slog.Info("Monitoring history loaded", "file", s.file, "services", len(s.series))
+	return nil
+}
+
+// validate checks the series of a loaded file, every series must have a ring per tier with the size of the tier,
+// otherwise Record would panic. A corrupt or edited file is discarded.
+func (s *Store) validate(series map[string][]ring) error {
+	if series == nil {
+		return errors.New("history has no series")
+	}
+
+	for name, rings := range series {
+		if len(rings) != len(s.tiers) {
+			return fmt.Errorf("history of %s has %d tiers, want %d", name, len(rings), len(s.tiers))
+		}
+		for i, tier := range s.tiers {
+			if size := int(tier.Retention / tier.Resolution); len(rings[i]) != size {
+				return fmt.Errorf("history of %s has %d buckets in tier %d, want %d", name, len(rings[i]), i, size)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/womat/go-api-template/app/service/monitoring"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testTiers = []Tier{
+	{Resolution: time.Second, Retention: time.Minute},
+	{Resolution: 10 * time.Second, Retention: 10 * time.Minute},
+	{Resolution: time.Minute, Retention: time.Hour},
+}
+
+func TestNewTiers(t *testing.T) {
+	tests := []struct {
+		name    string
+		tiers   []Tier
+		wantErr bool
+	}{
+		{"default", nil, false},
+		{"whole seconds", testTiers, false},
+		{"sub-second resolution", []Tier{{Resolution: 500 * time.Millisecond, Retention: time.Minute}}, true},
+		{"fractional resolution", []Tier{{Resolution: 1500 * time.Millisecond, Retention: time.Minute}}, true},
+		{"retention shorter than resolution", []Tier{{Resolution: time.Minute, Retention: time.Second}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.tiers, nil, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// record records the values one second apart, the last value at end.
+func record(s *Store, end time.Time, values ...float64) {
+	for i, v := range values {
+		t := end.Add(time.Duration(i-len(values)+1) * time.Second)
+		s.Record(t, []monitoring.Model{
+			{Service: "heap", Value: v},
+			{Service: "version", Value: "1.0.0"},
+		})
+	}
+}
+
+func TestQueryTierSelection(t *testing.T) {
+	s, _ := New(testTiers, nil, "")
+	now := time.Now()
+	record(s, now, 1, 2, 3)
+
+	tests := []struct {
+		name     string
+		from     time.Time
+		step     time.Duration
+		wantStep time.Duration
+	}{
+		{"finest tier", now.Add(-30 * time.Second), 0, time.Second},
+		{"step rounded up to the resolution", now.Add(-30 * time.Second), 2500 * time.Millisecond, 3 * time.Second},
+		{"second tier", now.Add(-5 * time.Minute), 0, 10 * time.Second},
+		{"step below the resolution", now.Add(-5 * time.Minute), time.Second, 10 * time.Second},
+		{"coarsest tier", now.Add(-30 * time.Minute), 0, time.Minute},
+		{"beyond all retentions", now.Add(-48 * time.Hour), 0, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, step, err := s.Query("heap", tt.from, now.Add(time.Second), tt.step)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if step != tt.wantStep {
+				t.Fatalf("step = %v, want %v", step, tt.wantStep)
+			}
+
+			var count uint32
+			for _, p := range points {
+				count += p.Count
+			}
+			if count != 3 {
+				t.Fatalf("points %+v contain %d values, want 3", points, count)
+			}
+		})
+	}
+}
+
+func TestQueryDownsampling(t *testing.T) {
+	s, _ := New(testTiers, nil, "")
+	// the values are within one 10s bucket of the second tier
+	end := time.Now().Truncate(10 * time.Second).Add(-20 * time.Second).Add(5 * time.Second)
+	record(s, end, 4, 8, 6, 2)
+
+	points, _, err := s.Query("heap", time.Now().Add(-5*time.Minute), time.Now(), 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 {
+		t.Fatalf("Query() returned %d points, want 1: %+v", len(points), points)
+	}
+
+	p := points[0]
+	want := Point{Time: end.Truncate(10 * time.Second).UTC(), Min: 2, Max: 8, Avg: 5, Count: 4}
+	if p != want {
+		t.Fatalf("Query() = %+v, want %+v", p, want)
+	}
+
+	// the finest tier keeps a point per second
+	points, _, _ = s.Query("heap", end.Add(-10*time.Second), end.Add(time.Second), 0)
+	if len(points) != 4 || points[0].Avg != 4 || points[3].Avg != 2 {
+		t.Fatalf("Query() on the finest tier = %+v, want the 4 recorded values", points)
+	}
+}
+
+func TestRecordOverwritesExpiredBuckets(t *testing.T) {
+	s, _ := New([]Tier{{Resolution: time.Second, Retention: 10 * time.Second}}, nil, "")
+	now := time.Now()
+
+	// 15 values in a ring of 10 buckets, the first 5 are overwritten
+	values := make([]float64, 15)
+	for i := range values {
+		values[i] = float64(i)
+	}
+	record(s, now, values...)
+
+	points, _, _ := s.Query("heap", now.Add(-time.Minute), now.Add(time.Second), 0)
+	if len(points) != 10 || points[0].Min != 5 || points[9].Min != 14 {
+		t.Fatalf("Query() = %+v, want the last 10 values", points)
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	s, _ := New(testTiers, nil, "")
+	now := time.Now()
+	record(s, now, 1)
+
+	if _, _, err := s.Query("heap", now, now.Add(-time.Second), 0); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("Query() with from after to = %v, want %v", err, ErrInvalidRange)
+	}
+	if _, _, err := s.Query("version", now.Add(-time.Minute), now, 0); !errors.Is(err, ErrUnknownService) {
+		t.Errorf("Query() of a non-numeric service = %v, want %v", err, ErrUnknownService)
+	}
+	if got := s.Services(); len(got) != 1 || got[0] != "heap" {
+		t.Errorf("Services() = %v, want [heap]", got)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history.json")
+	now := time.Now()
+
+	s, _ := New(testTiers, nil, file)
+	record(s, now, 1, 2, 3)
+	if err := s.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, _ := New(testTiers, nil, file)
+	if err := loaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	points, _, err := loaded.Query("heap", now.Add(-30*time.Second), now.Add(time.Second), 0)
+	if err != nil || len(points) != 3 {
+		t.Fatalf("Query() after load = %+v, %v, want 3 points", points, err)
+	}
+
+	// the history isn't loaded if the tiers have changed
+	changed, _ := New(testTiers[1:], nil, file)
+	if err := changed.load(); err == nil {
+		t.Fatal("load() with changed tiers returned nil")
+	}
+}
+
+func TestLoadCorruptFile(t *testing.T) {
+	tiers, _ := json.Marshal(testTiers)
+	ring := func(n int) string { return "[" + strings.TrimSuffix(strings.Repeat("{},", n), ",") + "]" }
+	valid := "[" + ring(60) + "," + ring(60) + "," + ring(60) + "]"
+
+	tests := []struct {
+		name    string
+		series  string
+		wantErr bool
+	}{
+		{"empty", `{}`, false},
+		{"valid", `{"heap":` + valid + `}`, false},
+		{"null series", `null`, true},
+		{"missing tier", `{"heap":[` + ring(60) + `,` + ring(60) + `]}`, true},
+		{"no rings", `{"heap":null}`, true},
+		{"empty ring", `{"heap":[` + ring(60) + `,` + ring(60) + `,[]]}`, true},
+		{"ring of another size", `{"heap":[` + ring(60) + `,` + ring(30) + `,` + ring(60) + `]}`, true},
+		{"one corrupt series", `{"heap":` + valid + `,"cpu":[[],[],[]]}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "history.json")
+			content := `{"tiers":` + string(tiers) + `,"series":` + tt.series + `}`
+			if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			s, _ := New(testTiers, nil, file)
+			if err := s.load(); (err != nil) != tt.wantErr {
+				t.Fatalf("load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// recording after a discarded file doesn't panic
+			now := time.Now()
+			record(s, now, 1)
+			s.Record(now, []monitoring.Model{{Service: "cpu", Value: 1.0}})
+			if got := s.Services(); len(got) != 2 {
+				t.Errorf("Services() = %q, want cpu and heap", got)
+			}
+		})
+	}
+}
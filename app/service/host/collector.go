@@ -0,0 +1,19 @@
+package host
+
+import (
+	"github.com/womat/go-api-template/app/service/collector"
+	"log/slog"
+	"time"
+)
+
+// Collector reads the host stats in the background at a fixed interval.
+type Collector = collector.Collector[Stats]
+
+// NewCollector returns a new Collector reading with reader at the given interval, the stats are read immediately.
+func NewCollector(reader *Reader, interval time.Duration) *Collector {
+	c := collector.New("host", reader.Read, interval)
+	if errs := c.Stats().Errors; len(errs) > 0 {
+		slog.Warn("Some host stats are not available", "errors", errs)
+	}
+	return c
+}
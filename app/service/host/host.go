@@ -0,0 +1,298 @@
+package host
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stats holds the host-level health data.
+type Stats struct {
+	// Time is the time the stats were read.
+	Time time.Time `json:"Time"`
+
+	// CPUUsagePercent is the CPU usage of all cores since the previous read, in percent.
+	CPUUsagePercent float64 `json:"CPUUsagePercent"`
+
+	// Load1, Load5 and Load15 are the load averages over 1, 5 and 15 minutes.
+	Load1  float64 `json:"Load1"`
+	Load5  float64 `json:"Load5"`
+	Load15 float64 `json:"Load15"`
+
+	// MemTotalBytes is the total usable memory.
+	MemTotalBytes uint64 `json:"MemTotalBytes"`
+
+	// MemAvailableBytes is the memory available for starting new applications without swapping.
+	MemAvailableBytes uint64 `json:"MemAvailableBytes"`
+
+	// Disks is the usage of the configured mount points.
+	Disks []DiskUsage `json:"Disks,omitempty"`
+
+	// Temperatures are the thermal zone temperatures (e.g. the SoC temperature on a Raspberry Pi).
+	Temperatures []Temperature `json:"Temperatures,omitempty"`
+
+	// Network are the counters of the network interfaces.
+	Network []NetInterface `json:"Network,omitempty"`
+
+	// Errors lists the sources which couldn't be read.
+	Errors []string `json:"Errors,omitempty"`
+}
+
+// DiskUsage is the usage of a mount point.
+type DiskUsage struct {
+	MountPoint  string  `json:"MountPoint"`
+	TotalBytes  uint64  `json:"TotalBytes"`
+	FreeBytes   uint64  `json:"FreeBytes"`
+	UsedPercent float64 `json:"UsedPercent"`
+}
+
+// Temperature is the temperature of a thermal zone.
+type Temperature struct {
+	Zone    string  `json:"Zone"`
+	Type    string  `json:"Type"`
+	Celsius float64 `json:"Celsius"`
+}
+
+// NetInterface holds the counters of a network interface.
+type NetInterface struct {
+	Name      string `json:"Name"`
+	RxBytes   uint64 `json:"RxBytes"`
+	RxPackets uint64 `json:"RxPackets"`
+	RxErrors  uint64 `json:"RxErrors"`
+	TxBytes   uint64 `json:"TxBytes"`
+	TxPackets uint64 `json:"TxPackets"`
+	TxErrors  uint64 `json:"TxErrors"`
+}
+
+// StatFS returns the total and free bytes of the file system mounted at path.
+type StatFS func(path string) (total, free uint64, err error)
+
+// Reader reads the host stats from the proc and sys file systems.
+type Reader struct {
+	fsys   fs.FS
+	mounts []string
+	statfs StatFS
+
+	mu      sync.Mutex
+	prevCPU cpuTimes
+}
+
+// cpuTimes are the cumulative cpu times of all cores, in jiffies.
+type cpuTimes struct {
+	idle, total uint64
+}
+
+// NewReader returns a Reader for the given file system root (usually "/") and the mount points reported as disks.
+// The mount points are relative to root, e.g. "/" is read from "/host" if root is "/host".
+func NewReader(root string, mounts []string) *Reader {
+	return NewFSReader(os.DirFS(root), mounts, func(mount string) (uint64, uint64, error) {
+		return statFS(filepath.Join(root, mount))
+	})
+}
+
+// NewFSReader returns a Reader for the given file system, e.g. a directory with fixture files.
+// fsys must contain the proc and sys trees (proc/stat, sys/class/thermal, ...), statfs reports the disk usage.
+func NewFSReader(fsys fs.FS, mounts []string, statfs StatFS) *Reader {
+	return &Reader{fsys: fsys, mounts: mounts, statfs: statfs}
+}
+
+// Read returns the current host stats. Sources which can't be read are listed in Stats.Errors.
+func (r *Reader) Read() *Stats {
+	s := &Stats{Time: time.Now()}
+
+	collect := func(name string, err error) {
+		if err != nil {
+			s.Errors = append(s.Errors, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	collect("cpu", r.readCPU(s))
+	collect("loadavg", r.readLoadAvg(s))
+	collect("meminfo", r.readMemInfo(s))
+	collect("thermal", r.readThermal(s))
+	collect("net", r.readNetDev(s))
+
+	for _, m := range r.mounts {
+		total, free, err := r.statfs(m)
+		if err != nil {
+			collect("disk "+m, err)
+			continue
+		}
+
+		d := DiskUsage{MountPoint: m, TotalBytes: total, FreeBytes: free}
+		if total > 0 {
+			d.UsedPercent = float64(total-free) / float64(total) * 100
+		}
+		s.Disks = append(s.Disks, d)
+	}
+
+	return s
+}
+
+// readCPU computes the cpu usage since the previous read from proc/stat.
+func (r *Reader) readCPU(s *Stats) error {
+	b, err := fs.ReadFile(r.fsys, "proc/stat")
+	if err != nil {
+		return err
+	}
+
+	line, _, _ := bytes.Cut(b, []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return errors.New("unexpected format")
+	}
+
+	var cur cpuTimes
+	for i, f := range fields[1:] {
+		v, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return err
+		}
+		cur.total += v
+		// idle and iowait
+		if i == 3 || i == 4 {
+			cur.idle += v
+		}
+	}
+
+	r.mu.Lock()
+	prev := r.prevCPU
+	r.prevCPU = cur
+	r.mu.Unlock()
+
+	if total := cur.total - prev.total; prev.total > 0 && total > 0 {
+		s.CPUUsagePercent = float64(total-(cur.idle-prev.idle)) / float64(total) * 100
+	}
+	return nil
+}
+
+// readLoadAvg reads the load averages from proc/loadavg.
+func (r *Reader) readLoadAvg(s *Stats) error {
+	b, err := fs.ReadFile(r.fsys, "proc/loadavg")
+	if err != nil {
+		return err
+	}
+
+	fields := strings.Fields(string(b))
+	if len(fields) < 3 {
+		return errors.New("unexpected format")
+	}
+
+	loads := make([]float64, 3)
+	for i := range loads {
+		if loads[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return err
+		}
+	}
+
+	s.Load1, s.Load5, s.Load15 = loads[0], loads[1], loads[2]
+	return nil
+}
+
+// readMemInfo reads the total and available memory from proc/meminfo.
+func (r *Reader) readMemInfo(s *Stats) error {
+	f, err := r.fsys.Open("proc/meminfo")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 2 && fields[2] == "kB" {
+			v *= 1024
+		}
+
+		switch fields[0] {
+		case "MemTotal:":
+			s.MemTotalBytes = v
+		case "MemAvailable:":
+			s.MemAvailableBytes = v
+		}
+	}
+	return scanner.Err()
+}
+
+// readThermal reads the temperature of all thermal zones from sys/class/thermal.
+func (r *Reader) readThermal(s *Stats) error {
+	zones, err := fs.Glob(r.fsys, "sys/class/thermal/thermal_zone*")
+	if err != nil {
+		return err
+	}
+
+	for _, zone := range zones {
+		b, err := fs.ReadFile(r.fsys, path.Join(zone, "temp"))
+		if err != nil {
+			continue
+		}
+		milli, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		typ, _ := fs.ReadFile(r.fsys, path.Join(zone, "type"))
+		s.Temperatures = append(s.Temperatures, Temperature{
+			Zone:    path.Base(zone),
+			Type:    strings.TrimSpace(string(typ)),
+			Celsius: float64(milli) / 1000,
+		})
+	}
+	return nil
+}
+
+// readNetDev reads the network interface counters from proc/net/dev, the loopback interface is skipped.
+func (r *Reader) readNetDev(s *Stats) error {
+	b, err := fs.ReadFile(r.fsys, "proc/net/dev")
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(string(b), "\n") {
+		name, data, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		fields := strings.Fields(data)
+		if name == "lo" || len(fields) < 16 {
+			continue
+		}
+
+		v := make([]uint64, 16)
+		for i := range v {
+			v[i], _ = strconv.ParseUint(fields[i], 10, 64)
+		}
+
+		s.Network = append(s.Network, NetInterface{
+			Name:      name,
+			RxBytes:   v[0],
+			RxPackets: v[1],
+			RxErrors:  v[2],
+			TxBytes:   v[8],
+			TxPackets: v[9],
+			TxErrors:  v[10],
+		})
+	}
+
+	slices.SortFunc(s.Network, func(a, b NetInterface) int { return strings.Compare(a.Name, b.Name) })
+	return nil
+}
@@ -0,0 +1,279 @@
+package host
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const netDev = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:  1000      10    0    0    0     0          0         0     1000      10    0    0    0     0       0          0
+  wlan0:  300       3    1    0    0     0          0         0      400       4    2    0    0     0       0          0
+  eth0:  100       1    0    0    0     0          0         0      200       2    0    0    0     0       0          0
+`
+
+func noStatFS(string) (uint64, uint64, error) { return 0, 0, errors.New("not mounted") }
+
+func TestReadCPU(t *testing.T) {
+	tests := []struct {
+		name    string
+		first   string
+		second  string
+		want    float64
+		wantErr bool
+	}{
+		{"half busy", "cpu 100 0 100 700 100 0 0 0\n", "cpu 150 0 150 750 150 0 0 0\n", 50, false},
+		{"idle", "cpu 100 0 100 700 100\n", "cpu 100 0 100 800 100\n", 0, false},
+		{"iowait counts as idle", "cpu 100 0 100 700 100\n", "cpu 100 0 100 700 200\n", 0, false},
+		{"busy", "cpu 100 0 100 700 100\n", "cpu 200 0 100 700 100\n", 100, false},
+		{"no change", "cpu 100 0 100 700 100\n", "cpu 100 0 100 700 100\n", 0, false},
+		{"not the total line", "cpu0 100 0 100 700 100\n", "cpu0 100 0 100 700 100\n", 0, true},
+		{"too few fields", "cpu 100 0 100\n", "cpu 100 0 100\n", 0, true},
+		{"invalid number", "cpu 100 0 x 700 100\n", "cpu 100 0 x 700 100\n", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"proc/stat": {Data: []byte(tt.first)}}
+			r := NewFSReader(fsys, nil, noStatFS)
+
+			var s Stats
+			if err := r.readCPU(&s); (err != nil) != tt.wantErr {
+				t.Fatalf("readCPU() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if s.CPUUsagePercent != 0 {
+				t.Errorf("first readCPU() = %v, want 0", s.CPUUsagePercent)
+			}
+
+			fsys["proc/stat"] = &fstest.MapFile{Data: []byte(tt.second)}
+			s = Stats{}
+			if err := r.readCPU(&s); (err != nil) != tt.wantErr {
+				t.Fatalf("readCPU() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if s.CPUUsagePercent != tt.want {
+				t.Errorf("readCPU() = %v, want %v", s.CPUUsagePercent, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadLoadAvg(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    [3]float64
+		wantErr bool
+	}{
+		{"valid", "0.52 0.58 0.59 1/467 12345\n", [3]float64{0.52, 0.58, 0.59}, false},
+		{"too few fields", "0.52 0.58\n", [3]float64{}, true},
+		{"invalid number", "0.52 x 0.59 1/467 12345\n", [3]float64{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewFSReader(fstest.MapFS{"proc/loadavg": {Data: []byte(tt.data)}}, nil, noStatFS)
+
+			var s Stats
+			if err := r.readLoadAvg(&s); (err != nil) != tt.wantErr {
+				t.Fatalf("readLoadAvg() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := [3]float64{s.Load1, s.Load5, s.Load15}; !tt.wantErr && got != tt.want {
+				t.Errorf("readLoadAvg() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadMemInfo(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		wantTotal     uint64
+		wantAvailable uint64
+	}{
+		{
+			name:          "kB",
+			data:          "MemTotal:        8000000 kB\nMemFree:          500000 kB\nMemAvailable:    4000000 kB\n",
+			wantTotal:     8000000 * 1024,
+			wantAvailable: 4000000 * 1024,
+		},
+		{
+			name:          "without unit",
+			data:          "MemTotal: 2048\nMemAvailable: 1024\n",
+			wantTotal:     2048,
+			wantAvailable: 1024,
+		},
+		{
+			name:      "malformed lines are skipped",
+			data:      "garbage\nMemTotal: x kB\nMemAvailable: 1 kB\nMemTotal: 2 kB\n",
+			wantTotal: 2 * 1024, wantAvailable: 1024,
+		},
+		{
+			name: "no MemAvailable",
+			data: "MemTotal: 1 kB\n", wantTotal: 1024,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewFSReader(fstest.MapFS{"proc/meminfo": {Data: []byte(tt.data)}}, nil, noStatFS)
+
+			var s Stats
+			if err := r.readMemInfo(&s); err != nil {
+				t.Fatalf("readMemInfo() error = %v", err)
+			}
+			if s.MemTotalBytes != tt.wantTotal || s.MemAvailableBytes != tt.wantAvailable {
+				t.Errorf("readMemInfo() = %d/%d, want %d/%d", s.MemTotalBytes, s.MemAvailableBytes, tt.wantTotal, tt.wantAvailable)
+			}
+		})
+	}
+}
+
+func TestReadThermal(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want []Temperature
+	}{
+		{
+			name: "zones",
+			fsys: fstest.MapFS{
+				"sys/class/thermal/thermal_zone0/temp": {Data: []byte("48312\n")},
+				"sys/class/thermal/thermal_zone0/type": {Data: []byte("cpu-thermal\n")},
+				"sys/class/thermal/thermal_zone1/temp": {Data: []byte("-1500\n")},
+			},
+			want: []Temperature{
+				{Zone: "thermal_zone0", Type: "cpu-thermal", Celsius: 48.312},
+				{Zone: "thermal_zone1", Celsius: -1.5},
+			},
+		},
+		{
+			name: "unreadable zones are skipped",
+			fsys: fstest.MapFS{
+				"sys/class/thermal/thermal_zone0/type":  {Data: []byte("acpitz\n")},
+				"sys/class/thermal/thermal_zone1/temp":  {Data: []byte("n/a\n")},
+				"sys/class/thermal/thermal_zone2/temp":  {Data: []byte("30000\n")},
+				"sys/class/thermal/cooling_device0/cur": {Data: []byte("0\n")},
+			},
+			want: []Temperature{{Zone: "thermal_zone2", Celsius: 30}},
+		},
+		{
+			name: "no thermal zones",
+			fsys: fstest.MapFS{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewFSReader(tt.fsys, nil, noStatFS)
+
+			var s Stats
+			if err := r.readThermal(&s); err != nil {
+				t.Fatalf("readThermal() error = %v", err)
+			}
+			if !reflect.DeepEqual(s.Temperatures, tt.want) {
+				t.Errorf("readThermal() = %+v, want %+v", s.Temperatures, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadNetDev(t *testing.T) {
+	r := NewFSReader(fstest.MapFS{"proc/net/dev": {Data: []byte(netDev)}}, nil, noStatFS)
+
+	var s Stats
+	if err := r.readNetDev(&s); err != nil {
+		t.Fatalf("readNetDev() error = %v", err)
+	}
+
+	want := []NetInterface{
+		{Name: "eth0", RxBytes: 100, RxPackets: 1, TxBytes: 200, TxPackets: 2},
+		{Name: "wlan0", RxBytes: 300, RxPackets: 3, RxErrors: 1, TxBytes: 400, TxPackets: 4, TxErrors: 2},
+	}
+	if !reflect.DeepEqual(s.Network, want) {
+		t.Errorf("readNetDev() = %+v, want %+v", s.Network, want)
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name       string
+		fsys       fstest.MapFS
+		mounts     []string
+		wantDisks  []DiskUsage
+		wantErrors []string
+	}{
+		{
+			name: "all sources",
+			fsys: fstest.MapFS{
+				"proc/stat":    {Data: []byte("cpu 100 0 100 700 100\n")},
+				"proc/loadavg": {Data: []byte("1 2 3 1/100 1\n")},
+				"proc/meminfo": {Data: []byte("MemTotal: 2 kB\nMemAvailable: 1 kB\n")},
+				"proc/net/dev": {Data: []byte(netDev)},
+			},
+			mounts:    []string{"/", "/data"},
+			wantDisks: []DiskUsage{{MountPoint: "/", TotalBytes: 1000, FreeBytes: 250, UsedPercent: 75}},
+			wantErrors: []string{
+				"disk /data: not mounted",
+			},
+		},
+		{
+			name:      "missing files",
+			fsys:      fstest.MapFS{},
+			mounts:    []string{"/"},
+			wantDisks: []DiskUsage{{MountPoint: "/", TotalBytes: 1000, FreeBytes: 250, UsedPercent: 75}},
+			wantErrors: []string{
+				"cpu: open proc/stat: file does not exist",
+				"loadavg: open proc/loadavg: file does not exist",
+				"meminfo: open proc/meminfo: file does not exist",
+				"net: open proc/net/dev: file does not exist",
+			},
+		},
+	}
+
+	statfs := func(path string) (uint64, uint64, error) {
+		if path != "/" {
+			return noStatFS(path)
+		}
+		return 1000, 250, nil
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewFSReader(tt.fsys, tt.mounts, statfs).Read()
+
+			if !reflect.DeepEqual(s.Disks, tt.wantDisks) {
+				t.Errorf("Read() disks = %+v, want %+v", s.Disks, tt.wantDisks)
+			}
+			if !reflect.DeepEqual(s.Errors, tt.wantErrors) {
+				t.Errorf("Read() errors = %q, want %q", s.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestNewReaderJoinsMountPointsWithRoot(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("disk usage is only supported on linux")
+	}
+
+	root := t.TempDir()
+	mount := "/" + strings.ReplaceAll(filepath.Base(root), " ", "_")
+	if err := os.Mkdir(filepath.Join(root, mount), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(mount); err == nil {
+		t.Skipf("%s exists outside of the root", mount)
+	}
+
+	s := NewReader(root, []string{mount}).Read()
+	if len(s.Disks) != 1 || s.Disks[0].MountPoint != mount || s.Disks[0].TotalBytes == 0 {
+		t.Errorf("Read() disks = %+v, errors %q, want the usage of %s below %s", s.Disks, s.Errors, mount, root)
+	}
+}
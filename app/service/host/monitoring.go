@@ -0,0 +1,45 @@
+package host
+
+import (
+	"fmt"
+	"github.com/womat/go-api-template/app/service/monitoring"
+)
+
+// Monitoring returns the monitoring entries of the host stats.
+func Monitoring(host string, s *Stats) []monitoring.Model {
+	gauge := func(service string, value any, unit, desc string) monitoring.Model {
+		return monitoring.Model{Service: service, Host: host, State: monitoring.StateOK, Value: value, Unit: unit, Description: desc, Metric: monitoring.MetricGauge}
+	}
+	counter := func(service string, value any, unit, desc string) monitoring.Model {
+		return monitoring.Model{Service: service, Host: host, State: monitoring.StateOK, Value: value, Unit: unit, Description: desc, Metric: monitoring.MetricCounter}
+	}
+
+	services := []monitoring.Model{
+		gauge("CPU Usage", s.CPUUsagePercent, monitoring.UnitPercent, fmt.Sprintf("CPU Usage: %.1f%%", s.CPUUsagePercent)),
+		gauge("Load Average 1m", s.Load1, "", fmt.Sprintf("Load Average 1m: %.2f", s.Load1)),
+		gauge("Load Average 5m", s.Load5, "", fmt.Sprintf("Load Average 5m: %.2f", s.Load5)),
+		gauge("Load Average 15m", s.Load15, "", fmt.Sprintf("Load Average 15m: %.2f", s.Load15)),
+		gauge("Memory Available", s.MemAvailableBytes, monitoring.UnitBytes,
+			fmt.Sprintf("Memory Available: %vkB of %vkB", s.MemAvailableBytes/1024, s.MemTotalBytes/1024)),
+	}
+
+	for _, d := range s.Disks {
+		services = append(services, gauge("Disk Usage "+d.MountPoint, d.UsedPercent, monitoring.UnitPercent,
+			fmt.Sprintf("Disk Usage %s: %.1f%% (%vMB free of %vMB)", d.MountPoint, d.UsedPercent, d.FreeBytes/(1024*1024), d.TotalBytes/(1024*1024))))
+	}
+
+	for _, t := range s.Temperatures {
+		services = append(services, gauge("Temperature "+t.Type, t.Celsius, monitoring.UnitCelsius,
+			fmt.Sprintf("Temperature %s (%s): %.1f°C", t.Type, t.Zone, t.Celsius)))
+	}
+
+	for _, n := range s.Network {
+		services = append(services,
+			counter("Network "+n.Name+" Rx Bytes", n.RxBytes, monitoring.UnitBytes, fmt.Sprintf("Network %s Rx: %vkB, %v packets, %v errors", n.Name, n.RxBytes/1024, n.RxPackets, n.RxErrors)),
+			counter("Network "+n.Name+" Tx Bytes", n.TxBytes, monitoring.UnitBytes, fmt.Sprintf("Network %s Tx: %vkB, %v packets, %v errors", n.Name, n.TxBytes/1024, n.TxPackets, n.TxErrors)),
+			counter("Network "+n.Name+" Errors", n.RxErrors+n.TxErrors, monitoring.UnitPackets, fmt.Sprintf("Network %s Errors: %v", n.Name, n.RxErrors+n.TxErrors)),
+		)
+	}
+
+	return services
+}
@@ -0,0 +1,14 @@
+//go:build linux
+
+package host
+
+import "syscall"
+
+// statFS returns the total and free (available to unprivileged users) bytes of the file system mounted at path.
+func statFS(path string) (total, free uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return st.Blocks * uint64(st.Bsize), st.Bavail * uint64(st.Bsize), nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package host
+
+import "errors"
+
+// statFS is not supported on this platform.
+func statFS(string) (total, free uint64, err error) {
+	return 0, 0, errors.New("disk usage is not supported on this platform")
+}
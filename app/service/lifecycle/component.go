@@ -0,0 +1,77 @@
+package lifecycle
+
+import (
+	"context"
+	"time"
+)
+
+// Component is a part of the application with a lifecycle, e.g. a database pool, a mqtt client or a scheduler.
+// Components are started in dependency order when the application starts and stopped in reverse order
+// when the application is shut down or restarted.
+type Component interface {
+	// Name returns the unique name of the component.
+	Name() string
+
+	// Start starts the component, it should return once the component is ready to use.
+	Start(ctx context.Context) error
+
+	// Stop stops the component and releases its resources, it must return when ctx is done.
+	Stop(ctx context.Context) error
+}
+
+// HealthChecker is optionally implemented by components to report their health.
+type HealthChecker interface {
+	// HealthCheck returns nil if the component is healthy.
+	HealthCheck(ctx context.Context) error
+}
+
+// State is the lifecycle state of a component.
+type State string
+
+// Lifecycle states of a component.
+const (
+	StateRegistered State = "registered"
+	StateStarting   State = "starting"
+	StateRunning    State = "running"
+	StateStopping   State = "stopping"
+	StateStopped    State = "stopped"
+	StateFailed     State = "failed"
+)
+
+// Option configures the registration of a component.
+type Option func(*entry)
+
+// DependsOn declares the names of components which must be started before the component.
+func DependsOn(names ...string) Option {
+	return func(e *entry) {
+		e.dependsOn = append(e.dependsOn, names...)
+	}
+}
+
+// WithStopTimeout overrides the default stop timeout of the component.
+func WithStopTimeout(d time.Duration) Option {
+	return func(e *entry) {
+		e.stopTimeout = d
+	}
+}
+
+// Status is the lifecycle and health status of a component.
+type Status struct {
+	// Name is the name of the component.
+	Name string `json:"Name"`
+
+	// State is the lifecycle state of the component.
+	State State `json:"State"`
+
+	// Healthy reports the result of the health check, components without health check are healthy while running.
+	Healthy bool `json:"Healthy"`
+
+	// Error is the last start, stop or health check error.
+	Error string `json:"Error,omitempty"`
+
+	// DependsOn lists the components the component depends on.
+	DependsOn []string `json:"DependsOn,omitempty"`
+
+	// Since is the time of the last state change, in RFC3339 format.
+	Since string `json:"Since"`
+}
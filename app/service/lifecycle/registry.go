@@ -0,0 +1,329 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout is the maximum duration of a single component health check.
+const healthCheckTimeout = 2 * time.Second
+
+// entry holds a registered component and its lifecycle state.
+type entry struct {
+	component   Component
+	dependsOn   []string
+	stopTimeout time.Duration
+	state       State
+	err         error
+	since       time.Time
+
+	// healthErr is the result of the last health check.
+	healthErr error
+}
+
+// Registry manages the lifecycle of the application components.
+type Registry struct {
+	mu             sync.Mutex
+	entries        []*entry
+	started        []*entry
+	stopTimeout    time.Duration
+	healthInterval time.Duration
+	cancel         context.CancelFunc
+	done           chan struct{}
+}
+
+// NewRegistry returns a new Registry, stopTimeout is the default timeout for stopping a single component.
+// The health checks of the running components are executed in the background every healthInterval,
+// Status returns the result of the last health checks.
+func NewRegistry(stopTimeout, healthInterval time.Duration) *Registry {
+	return &Registry{stopTimeout: stopTimeout, healthInterval: healthInterval}
+}
+
+// Register adds a component to the registry.
+// Components must be registered before Start is called and names must be unique.
+func (r *Registry) Register(c Component, opts ...Option) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.find(c.Name()) != nil {
+		return fmt.Errorf("component %s already registered", c.Name())
+	}
+
+	e := &entry{component: c, stopTimeout: r.stopTimeout, state: StateRegistered, since: time.Now()}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+// Names returns the names of the registered components in registration order.
+func (r *Registry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.entries))
+	for _, e := range r.entries {
+		names = append(names, e.component.Name())
+	}
+	return names
+}
+
+// Start starts all components in dependency order and the background health checks.
+// If a component fails to start, the already started components are stopped in reverse order
+// and the error of the failed component is returned.
+func (r *Registry) Start(ctx context.Context) error {
+	order, err := r.order()
+	if err != nil {
+		return err
+	}
+
+	for _, e := range order {
+		slog.Info("Starting component", "component", e.component.Name())
+		r.setState(e, StateStarting, nil)
+
+		if err := e.component.Start(ctx); err != nil {
+			slog.Error("Component failed to start, rolling back started components", "component", e.component.Name(), "error", err)
+			r.setState(e, StateFailed, err)
+			_ = r.Stop(context.Background())
+			return fmt.Errorf("start component %s: %w", e.component.Name(), err)
+		}
+
+		r.setState(e, StateRunning, nil)
+		r.mu.Lock()
+		r.started = append(r.started, e)
+		r.mu.Unlock()
+	}
+
+	r.CheckHealth(ctx)
+	r.startHealthChecks(ctx)
+	return nil
+}
+
+// startHealthChecks runs the health checks every healthInterval until ctx is cancelled or Stop is called.
+func (r *Registry) startHealthChecks(ctx context.Context) {
+	if r.healthInterval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	r.mu.Lock()
+	r.cancel, r.done = cancel, done
+	r.mu.Unlock()
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(r.healthInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.CheckHealth(ctx)
+			}
+		}
+	}()
+}
+
+// stopHealthChecks stops the background health checks and waits until a running check returns.
+func (r *Registry) stopHealthChecks() {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel, r.done = nil, nil
+	r.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Stop stops the started components in reverse start order.
+// Every component is stopped within its stop timeout, errors are logged and returned joined.
+func (r *Registry) Stop(ctx context.Context) error {
+	r.stopHealthChecks()
+
+	r.mu.Lock()
+	started := r.started
+	r.started = nil
+	r.mu.Unlock()
+
+	var errs []error
+	for _, e := range slices.Backward(started) {
+		slog.Info("Stopping component", "component", e.component.Name(), "timeout", e.stopTimeout)
+		r.setState(e, StateStopping, nil)
+
+		err := r.stop(ctx, e)
+		if err != nil {
+			slog.Error("Component failed to stop", "component", e.component.Name(), "error", err)
+			r.setState(e, StateFailed, err)
+			errs = append(errs, fmt.Errorf("stop component %s: %w", e.component.Name(), err))
+			continue
+		}
+
+		r.setState(e, StateStopped, nil)
+	}
+
+	return errors.Join(errs...)
+}
+
+// stop stops the component and returns an error if it doesn't stop within its timeout.
+func (r *Registry) stop(ctx context.Context, e *entry) error {
+	if e.stopTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.stopTimeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- e.component.Stop(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CheckHealth executes the health check of the running components implementing HealthChecker
+// and stores the results for Status.
+func (r *Registry) CheckHealth(ctx context.Context) {
+	r.mu.Lock()
+	entries := slices.Clone(r.entries)
+	r.mu.Unlock()
+
+	for _, e := range entries {
+		hc, ok := e.component.(HealthChecker)
+		if !ok {
+			continue
+		}
+
+		r.mu.Lock()
+		running := e.state == StateRunning
+		r.mu.Unlock()
+		if !running {
+			continue
+		}
+
+		hctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		err := hc.HealthCheck(hctx)
+		cancel()
+
+		r.mu.Lock()
+		e.healthErr = err
+		r.mu.Unlock()
+	}
+}
+
+// Status returns the lifecycle and health status of all components in registration order.
+// The health is the result of the last health check, the checks aren't executed by Status.
+func (r *Registry) Status() []Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := make([]Status, 0, len(r.entries))
+	for _, e := range r.entries {
+		s := Status{
+			Name:      e.component.Name(),
+			State:     e.state,
+			Healthy:   e.state == StateRunning,
+			DependsOn: e.dependsOn,
+			Since:     e.since.Format(time.RFC3339),
+		}
+		if e.err != nil {
+			s.Error = e.err.Error()
+		}
+		if e.state == StateRunning && e.healthErr != nil {
+			s.Healthy = false
+			s.Error = e.healthErr.Error()
+		}
+
+		status = append(status, s)
+	}
+
+	return status
+}
+
+// order returns the components sorted by dependencies (topological order).
+// Components without dependencies between them keep the registration order.
+func (r *Registry) order() ([]*entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	marks := map[*entry]int{}
+	order := make([]*entry, 0, len(r.entries))
+
+	var visit func(e *entry) error
+	visit = func(e *entry) error {
+		switch marks[e] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle detected at component %s", e.component.Name())
+		}
+
+		marks[e] = visiting
+		for _, name := range e.dependsOn {
+			dep := r.find(name)
+			if dep == nil {
+				return fmt.Errorf("component %s depends on unknown component %s", e.component.Name(), name)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		marks[e] = visited
+		order = append(order, e)
+		return nil
+	}
+
+	for _, e := range r.entries {
+		if err := visit(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// find returns the entry of the named component or nil, r.mu must be held.
+func (r *Registry) find(name string) *entry {
+	for _, e := range r.entries {
+		if e.component.Name() == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// setState updates the lifecycle state of the entry.
+func (r *Registry) setState(e *entry, state State, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e.state = state
+	e.err = err
+	e.since = time.Now()
+	if state != StateRunning {
+		e.healthErr = nil
+	}
+}
@@ -0,0 +1,143 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fake is a component recording its start and stop calls.
+type fake struct {
+	name     string
+	startErr error
+	log      *[]string
+	checks   atomic.Int32
+	health   atomic.Pointer[error]
+}
+
+func (f *fake) Name() string { return f.name }
+
+func (f *fake) Start(context.Context) error {
+	*f.log = append(*f.log, "start "+f.name)
+	return f.startErr
+}
+
+func (f *fake) Stop(context.Context) error {
+	*f.log = append(*f.log, "stop "+f.name)
+	return nil
+}
+
+func (f *fake) HealthCheck(context.Context) error {
+	f.checks.Add(1)
+	if err := f.health.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+func TestRegistryStartStop(t *testing.T) {
+	tests := []struct {
+		name    string
+		fail    string
+		wantErr bool
+		want    []string
+	}{
+		{
+			name: "dependency order",
+			want: []string{"start db", "start mqtt", "start api", "stop api", "stop mqtt", "stop db"},
+		},
+		{
+			name:    "rollback",
+			fail:    "mqtt",
+			wantErr: true,
+			want:    []string{"start db", "start mqtt", "stop db"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			newFake := func(name string) *fake {
+				f := &fake{name: name, log: &log}
+				if name == tt.fail {
+					f.startErr = errors.New("failed")
+				}
+				return f
+			}
+
+			r := NewRegistry(time.Second, 0)
+			// registered in reverse dependency order
+			_ = r.Register(newFake("api"), DependsOn("mqtt"))
+			_ = r.Register(newFake("mqtt"), DependsOn("db"))
+			_ = r.Register(newFake("db"))
+
+			err := r.Start(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Start() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				_ = r.Stop(context.Background())
+			}
+
+			if !slices.Equal(log, tt.want) {
+				t.Fatalf("calls = %v, want %v", log, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryCycle(t *testing.T) {
+	var log []string
+	r := NewRegistry(time.Second, 0)
+	_ = r.Register(&fake{name: "a", log: &log}, DependsOn("b"))
+	_ = r.Register(&fake{name: "b", log: &log}, DependsOn("a"))
+
+	if err := r.Start(context.Background()); err == nil {
+		t.Fatal("Start() with dependency cycle returned nil")
+	}
+	if len(log) != 0 {
+		t.Fatalf("components started despite dependency cycle: %v", log)
+	}
+}
+
+func TestRegistryStatusCachesHealth(t *testing.T) {
+	var log []string
+	f := &fake{name: "db", log: &log}
+	r := NewRegistry(time.Second, 10*time.Millisecond)
+	_ = r.Register(f)
+
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Stop(context.Background()) }()
+
+	// Status doesn't execute the health check
+	checks := f.checks.Load()
+	for range 10 {
+		r.Status()
+	}
+	if f.checks.Load() > checks+1 {
+		t.Fatalf("Status() executed the health check")
+	}
+
+	if s := r.Status()[0]; !s.Healthy || s.State != StateRunning {
+		t.Fatalf("Status() = %+v, want healthy and running", s)
+	}
+
+	// the background check picks up the failure
+	err := errors.New("connection lost")
+	f.health.Store(&err)
+	deadline := time.Now().Add(time.Second)
+	for r.Status()[0].Healthy {
+		if time.Now().After(deadline) {
+			t.Fatal("health check failure not reported")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if s := r.Status()[0]; s.Error != err.Error() {
+		t.Fatalf("Status().Error = %q, want %q", s.Error, err.Error())
+	}
+}
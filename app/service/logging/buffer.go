@@ -0,0 +1,298 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"github.com/womat/go-api-template/app/service/requestid"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// subscriberQueue is the number of records queued per subscriber, records are dropped if a subscriber falls behind.
+const subscriberQueue = 256
+
+// Record is a log record kept in the Buffer.
+type Record struct {
+	// Seq is the sequence number of the record, it increases with every record.
+	Seq uint64 `json:"seq"`
+
+	// Time is the time of the record.
+	Time time.Time `json:"time"`
+
+	// Level is the log level of the record.
+	Level slog.Level `json:"level"`
+
+	// Message is the log message.
+	Message string `json:"message"`
+
+	// RequestID is the id of the request the record was logged for, if any.
+	RequestID string `json:"requestId,omitempty"`
+
+	// Attrs are the attributes of the record, groups are flattened to keys separated by dots.
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
+// Filter selects records of the Buffer, zero values match all records.
+type Filter struct {
+	// MinLevel is the minimum log level.
+	MinLevel slog.Level
+
+	// From and To limit the records to a time range.
+	From, To time.Time
+
+	// RequestID is the request id of the records.
+	RequestID string
+
+	// Text is searched case-insensitively in the message and the attribute values.
+	Text string
+
+	// Limit is the maximum number of records returned by Query, the newest records are returned.
+	Limit int
+}
+
+// Match reports whether the record matches the filter.
+func (f Filter) Match(r Record) bool {
+	switch {
+	case r.Level < f.MinLevel:
+		return false
+	case !f.From.IsZero() && r.Time.Before(f.From):
+		return false
+	case !f.To.IsZero() && r.Time.After(f.To):
+		return false
+	case f.RequestID != "" && r.RequestID != f.RequestID:
+		return false
+	case f.Text == "":
+		return true
+	}
+
+	text := strings.ToLower(f.Text)
+	if strings.Contains(strings.ToLower(r.Message), text) {
+		return true
+	}
+	for k, v := range r.Attrs {
+		if strings.Contains(strings.ToLower(k+"="+fmt.Sprint(v)), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Buffer keeps the last records in memory, e.g. to query the logs of a remote instance by the api.
+// Records are added by the slog.Handler returned by Handler, subscribers receive new records as they are added.
+// The Buffer is safe for concurrent use and survives a restart of the logger.
+type Buffer struct {
+	mu      sync.Mutex
+	records []Record
+	start   int
+	count   int
+	seq     uint64
+	level   slog.LevelVar
+	subs    map[chan Record]struct{}
+}
+
+// NewBuffer returns a buffer keeping the last size records with at least the given level.
+// A size of 0 disables the buffer.
+func NewBuffer(size int, level slog.Level) *Buffer {
+	b := &Buffer{subs: map[chan Record]struct{}{}}
+	b.Configure(size, level)
+	return b
+}
+
+// Configure changes the size and the level of the buffer, the newest records are kept.
+func (b *Buffer) Configure(size int, level slog.Level) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.level.Set(level)
+	if size < 0 {
+		size = 0
+	}
+	if size == len(b.records) {
+		return
+	}
+
+	records := b.snapshot()
+	if len(records) > size {
+		records = records[len(records)-size:]
+	}
+
+	b.records = make([]Record, size)
+	b.start, b.count = 0, copy(b.records, records)
+}
+
+// Size returns the maximum number of records kept.
+func (b *Buffer) Size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.records)
+}
+
+// Query returns the records matching the filter, oldest first.
+func (b *Buffer) Query(f Filter) []Record {
+	b.mu.Lock()
+	records := b.snapshot()
+	b.mu.Unlock()
+
+	matched := make([]Record, 0, len(records))
+	for _, r := range records {
+		if f.Match(r) {
+			matched = append(matched, r)
+		}
+	}
+	if f.Limit > 0 && len(matched) > f.Limit {
+		matched = matched[len(matched)-f.Limit:]
+	}
+	return matched
+}
+
+// Subscribe returns a channel receiving the records added to the buffer until cancel is called.
+// Records are dropped if the subscriber doesn't keep up.
+func (b *Buffer) Subscribe() (records <-chan Record, cancel func()) {
+	ch := make(chan Record, subscriberQueue)
+
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}
+}
+
+// Handler returns a slog.Handler adding the records to the buffer.
+func (b *Buffer) Handler() slog.Handler {
+	return &bufferHandler{buffer: b}
+}
+
+// snapshot returns a copy of the records, oldest first; b.mu must be held.
+func (b *Buffer) snapshot() []Record {
+	records := make([]Record, b.count)
+	for i := range records {
+		records[i] = b.records[(b.start+i)%len(b.records)]
+	}
+	return records
+}
+
+// add adds the record to the buffer and sends it to the subscribers.
+func (b *Buffer) add(r Record) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.records) == 0 {
+		return
+	}
+
+	b.seq++
+	r.Seq = b.seq
+
+	if b.count < len(b.records) {
+		b.records[(b.start+b.count)%len(b.records)] = r
+		b.count++
+	} else {
+		b.records[b.start] = r
+		b.start = (b.start + 1) % len(b.records)
+	}
+
+	for ch := range b.subs {
+		select {
+		case ch <- r:
+		default:
+		}
+	}
+}
+
+// bufferHandler is the slog.Handler of the Buffer.
+type bufferHandler struct {
+	buffer *Buffer
+	attrs  []slog.Attr
+	prefix string
+}
+
+// Enabled reports whether the level is at least the level of the buffer.
+func (h *bufferHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.buffer.level.Level()
+}
+
+// Handle adds the record to the buffer.
+func (h *bufferHandler) Handle(_ context.Context, r slog.Record) error {
+	rec := Record{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Attrs:   map[string]any{},
+	}
+
+	for _, a := range h.attrs {
+		rec.addAttr("", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		rec.addAttr(h.prefix, a)
+		return true
+	})
+	if len(rec.Attrs) == 0 {
+		rec.Attrs = nil
+	}
+
+	h.buffer.add(rec)
+	return nil
+}
+
+// WithAttrs returns a handler adding the attributes to every record.
+func (h *bufferHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.attrs = make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	c.attrs = append(c.attrs, h.attrs...)
+	for _, a := range attrs {
+		if h.prefix != "" {
+			a.Key = h.prefix + a.Key
+		}
+		c.attrs = append(c.attrs, a)
+	}
+	return &c
+}
+
+// WithGroup returns a handler prefixing the keys of the record attributes with the group.
+func (h *bufferHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.prefix = h.prefix + name + "."
+	return &c
+}
+
+// addAttr adds the attribute to the record, groups are flattened to keys separated by dots.
+// The request id attribute is stored in RequestID.
+func (r *Record) addAttr(prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+
+	switch {
+	case a.Equal(slog.Attr{}):
+		return
+	case v.Kind() == slog.KindGroup:
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			r.addAttr(prefix, ga)
+		}
+		return
+	case prefix == "" && a.Key == requestid.LogKey:
+		r.RequestID = v.String()
+		return
+	}
+
+	switch v.Kind() {
+	case slog.KindAny:
+		// store a string to keep the record immutable and json encodable (e.g. errors)
+		r.Attrs[prefix+a.Key] = fmt.Sprint(v.Any())
+	case slog.KindDuration:
+		r.Attrs[prefix+a.Key] = v.Duration().String()
+	default:
+		r.Attrs[prefix+a.Key] = v.Any()
+	}
+}
@@ -0,0 +1,232 @@
+package logging
+
+import (
+	"errors"
+	"github.com/womat/go-api-template/app/service/requestid"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// messages returns the messages of the records.
+func messages(records []Record) []string {
+	var m []string
+	for _, r := range records {
+		m = append(m, r.Message)
+	}
+	return m
+}
+
+// logN logs the messages 0..n-1 to the buffer.
+func logN(b *Buffer, n int) {
+	l := slog.New(b.Handler())
+	for i := range n {
+		l.Info(strconv.Itoa(i))
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	now := time.Now()
+	r := Record{
+		Time:      now,
+		Level:     slog.LevelWarn,
+		Message:   "Disk almost full",
+		RequestID: "req-1",
+		Attrs:     map[string]any{"mount": "/data", "free": 42},
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"zero filter", Filter{}, true},
+		{"level below", Filter{MinLevel: slog.LevelInfo}, true},
+		{"level equal", Filter{MinLevel: slog.LevelWarn}, true},
+		{"level above", Filter{MinLevel: slog.LevelError}, false},
+		{"from before", Filter{From: now.Add(-time.Second)}, true},
+		{"from after", Filter{From: now.Add(time.Second)}, false},
+		{"to after", Filter{To: now.Add(time.Second)}, true},
+		{"to before", Filter{To: now.Add(-time.Second)}, false},
+		{"request id", Filter{RequestID: "req-1"}, true},
+		{"other request id", Filter{RequestID: "req-2"}, false},
+		{"text in message", Filter{Text: "ALMOST"}, true},
+		{"text in attribute value", Filter{Text: "/data"}, true},
+		{"text in attribute key and value", Filter{Text: "free=42"}, true},
+		{"text not found", Filter{Text: "memory"}, false},
+		{"all criteria", Filter{MinLevel: slog.LevelWarn, RequestID: "req-1", Text: "disk"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(r); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBufferQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		logged int
+		filter Filter
+		want   []string
+	}{
+		{"empty", 3, 0, Filter{}, nil},
+		{"not full", 3, 2, Filter{}, []string{"0", "1"}},
+		{"ring overwrites the oldest", 3, 5, Filter{}, []string{"2", "3", "4"}},
+		{"limit returns the newest", 5, 5, Filter{Limit: 2}, []string{"3", "4"}},
+		{"limit above count", 5, 2, Filter{Limit: 10}, []string{"0", "1"}},
+		{"text", 5, 5, Filter{Text: "3"}, []string{"3"}},
+		{"disabled", 0, 5, Filter{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuffer(tt.size, slog.LevelInfo)
+			logN(b, tt.logged)
+
+			got := b.Query(tt.filter)
+			if m := messages(got); !slices.Equal(m, tt.want) {
+				t.Errorf("Query() = %q, want %q", m, tt.want)
+			}
+			for i := 1; i < len(got); i++ {
+				if got[i].Seq != got[i-1].Seq+1 {
+					t.Errorf("Query() sequence %d follows %d", got[i].Seq, got[i-1].Seq)
+				}
+			}
+		})
+	}
+}
+
+func TestBufferConfigure(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int
+		level slog.Level
+		want  []string
+	}{
+		{"shrink keeps the newest", 2, slog.LevelInfo, []string{"4", "x"}},
+		{"grow keeps all", 10, slog.LevelInfo, []string{"1", "2", "3", "4", "x"}},
+		{"same size", 4, slog.LevelInfo, []string{"2", "3", "4", "x"}},
+		{"level", 4, slog.LevelWarn, []string{"1", "2", "3", "4"}},
+		{"disable", 0, slog.LevelInfo, nil},
+		{"negative size disables", -1, slog.LevelInfo, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuffer(4, slog.LevelInfo)
+			logN(b, 5)
+
+			b.Configure(tt.size, tt.level)
+			slog.New(b.Handler()).Info("x")
+
+			if got := messages(b.Query(Filter{})); !slices.Equal(got, tt.want) {
+				t.Errorf("Query() = %q, want %q", got, tt.want)
+			}
+			if want := max(tt.size, 0); b.Size() != want {
+				t.Errorf("Size() = %d, want %d", b.Size(), want)
+			}
+		})
+	}
+}
+
+func TestBufferHandler(t *testing.T) {
+	b := NewBuffer(10, slog.LevelInfo)
+	l := slog.New(b.Handler())
+
+	l.Debug("below level")
+	l.With("component", "api").WithGroup("request").With("method", "GET").Info("served",
+		requestid.LogKey, "req-1",
+		"duration", 1500*time.Millisecond,
+		"error", errors.New("timeout"),
+		slog.Group("client", "ip", "127.0.0.1"),
+		slog.Group("", "inline", true),
+		"status", 200)
+
+	got := b.Query(Filter{})
+	if len(got) != 1 {
+		t.Fatalf("Query() = %d records, want 1", len(got))
+	}
+
+	want := map[string]any{
+		"component":         "api",
+		"request.method":    "GET",
+		"request.duration":  "1.5s",
+		"request.error":     "timeout",
+		"request.client.ip": "127.0.0.1",
+		"request.inline":    true,
+		"request.status":    int64(200),
+		// the request id is only recognized at the top level
+		"request." + requestid.LogKey: "req-1",
+	}
+	if !reflect.DeepEqual(got[0].Attrs, want) {
+		t.Errorf("Attrs = %v, want %v", got[0].Attrs, want)
+	}
+
+	l.Warn("top level", requestid.LogKey, "req-2")
+	if got := b.Query(Filter{RequestID: "req-2"}); len(got) != 1 || got[0].Attrs != nil || got[0].Level != slog.LevelWarn {
+		t.Errorf("Query() = %+v, want the record with request id req-2 and no attributes", got)
+	}
+}
+
+func TestBufferSubscribe(t *testing.T) {
+	b := NewBuffer(10, slog.LevelInfo)
+	logN(b, 2)
+
+	records, cancel := b.Subscribe()
+	logN(b, 3)
+
+	// only records added after subscribing are received
+	for _, want := range []string{"0", "1", "2"} {
+		select {
+		case r := <-records:
+			if r.Message != want {
+				t.Errorf("received %q, want %q", r.Message, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no record received, want %q", want)
+		}
+	}
+
+	cancel()
+	logN(b, 1)
+	select {
+	case r := <-records:
+		t.Errorf("received %q after cancel", r.Message)
+	default:
+	}
+}
+
+func TestBufferSubscribeDropsRecords(t *testing.T) {
+	b := NewBuffer(10, slog.LevelInfo)
+	records, cancel := b.Subscribe()
+	defer cancel()
+
+	// the subscriber doesn't read, records exceeding the queue are dropped without blocking the logger
+	logN(b, subscriberQueue+10)
+
+	if n := len(records); n != subscriberQueue {
+		t.Errorf("queued %d records, want %d", n, subscriberQueue)
+	}
+	if r := <-records; r.Message != "0" || r.Seq != 1 {
+		t.Errorf("first record = %+v, want the first logged record", r)
+	}
+}
+
+func TestBufferDisabledSubscribe(t *testing.T) {
+	b := NewBuffer(0, slog.LevelInfo)
+	records, cancel := b.Subscribe()
+	defer cancel()
+
+	logN(b, 1)
+	if n := len(records); n != 0 {
+		t.Errorf("received %d records from a disabled buffer", n)
+	}
+}
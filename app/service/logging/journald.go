@@ -0,0 +1,167 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultJournalSocket is the socket of the systemd journal native protocol.
+const defaultJournalSocket = "/run/systemd/journal/socket"
+
+// journalConn is the connection to the systemd journal shared by a journalHandler and its derived handlers.
+type journalConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Close closes the connection to the journal.
+func (c *journalConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.Close()
+}
+
+// journalHandler sends records to the systemd journal with the native protocol.
+// The message, priority, identifier and source code location are sent as journal fields (MESSAGE, PRIORITY, ...),
+// attributes as upper case fields with the group names as prefix, e.g. REQUEST_METHOD.
+type journalHandler struct {
+	conn   *journalConn
+	level  slog.Leveler
+	tag    string
+	fields []byte // preformatted fields of WithAttrs
+	prefix string // prefix of the current group
+}
+
+// newJournalHandler returns a handler connected to the journal socket (default /run/systemd/journal/socket).
+func newJournalHandler(address, tag string, level slog.Leveler) (*journalHandler, error) {
+	if address == "" {
+		address = defaultJournalSocket
+	}
+
+	conn, err := net.Dial("unixgram", address)
+	if err != nil {
+		return nil, err
+	}
+	return &journalHandler{conn: &journalConn{conn: conn}, level: level, tag: tag}, nil
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *journalHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle sends the record as one journal entry.
+func (h *journalHandler) Handle(_ context.Context, r slog.Record) error {
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", r.Message)
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(severity(r.Level)))
+	if h.tag != "" {
+		writeJournalField(&b, "SYSLOG_IDENTIFIER", h.tag)
+	}
+	if r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		writeJournalField(&b, "CODE_FILE", f.File)
+		writeJournalField(&b, "CODE_LINE", strconv.Itoa(f.Line))
+		writeJournalField(&b, "CODE_FUNC", f.Function)
+	}
+
+	b.Write(h.fields)
+	r.Attrs(func(a slog.Attr) bool {
+		appendJournalAttr(&b, h.prefix, a)
+		return true
+	})
+
+	h.conn.mu.Lock()
+	defer h.conn.mu.Unlock()
+	_, err := h.conn.conn.Write(b.Bytes())
+	return err
+}
+
+// WithAttrs returns a handler whose entries contain the attributes.
+func (h *journalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	b := bytes.NewBuffer(bytes.Clone(h.fields))
+	for _, a := range attrs {
+		appendJournalAttr(b, h.prefix, a)
+	}
+	h2.fields = b.Bytes()
+	return &h2
+}
+
+// WithGroup returns a handler which prefixes the field names of following attributes with the group name.
+func (h *journalHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "_"
+	return &h2
+}
+
+// appendJournalAttr appends the attribute as journal field, groups are flattened with the group name as prefix.
+func appendJournalAttr(b *bytes.Buffer, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		if a.Key != "" {
+			prefix += a.Key + "_"
+		}
+		for _, ga := range a.Value.Group() {
+			appendJournalAttr(b, prefix, ga)
+		}
+	case slog.KindTime:
+		writeJournalField(b, journalFieldName(prefix+a.Key), a.Value.Time().Format(time.RFC3339Nano))
+	default:
+		writeJournalField(b, journalFieldName(prefix+a.Key), a.Value.String())
+	}
+}
+
+// journalFieldName converts key to a valid journal field name: upper case letters, digits and underscores,
+// not starting with an underscore (reserved for trusted fields) or a digit.
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+
+	name = strings.TrimLeft(name, "_")
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "F_" + name
+	}
+	return name
+}
+
+// writeJournalField writes a field in the journal native protocol format,
+// values containing a newline are written with their length as 64-bit little endian.
+func writeJournalField(b *bytes.Buffer, name, value string) {
+	b.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+
+	b.WriteByte('\n')
+	_ = binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
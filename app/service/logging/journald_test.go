@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJournalFieldName(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"method", "METHOD"},
+		{"Request_ID", "REQUEST_ID"},
+		{"client.ip", "CLIENT_IP"},
+		{"http-status", "HTTP_STATUS"},
+		{"_trusted", "TRUSTED"},
+		{"__", "F_"},
+		{"", "F_"},
+		{"1st", "F_1ST"},
+		{"größe", "GR__E"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := journalFieldName(tt.key); got != tt.want {
+				t.Errorf("journalFieldName(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteJournalField(t *testing.T) {
+	multiline := func(name, value string) string {
+		var b bytes.Buffer
+		b.WriteString(name + "\n")
+		_ = binary.Write(&b, binary.LittleEndian, uint64(len(value)))
+		b.WriteString(value + "\n")
+		return b.String()
+	}
+
+	tests := []struct {
+		name  string
+		field string
+		value string
+		want  string
+	}{
+		{"simple", "MESSAGE", "hello", "MESSAGE=hello\n"},
+		{"empty", "MESSAGE", "", "MESSAGE=\n"},
+		{"equal sign", "QUERY", "a=b", "QUERY=a=b\n"},
+		{"newline", "STACK", "line 1\nline 2", multiline("STACK", "line 1\nline 2")},
+		{"trailing newline", "MESSAGE", "hello\n", multiline("MESSAGE", "hello\n")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			writeJournalField(&b, tt.field, tt.value)
+			if got := b.String(); got != tt.want {
+				t.Errorf("writeJournalField() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// journalFields parses a journal native protocol entry into name=value pairs.
+func journalFields(t *testing.T, entry string) []string {
+	t.Helper()
+
+	var fields []string
+	for entry != "" {
+		line, rest, _ := strings.Cut(entry, "\n")
+		if name, value, ok := strings.Cut(line, "="); ok {
+			fields = append(fields, name+"="+value)
+			entry = rest
+			continue
+		}
+
+		// binary field: NAME\n<64-bit length><value>\n
+		if len(rest) < 8 {
+			t.Fatalf("invalid entry %q", entry)
+		}
+		n := binary.LittleEndian.Uint64([]byte(rest[:8]))
+		fields = append(fields, line+"="+rest[8:8+n])
+		entry = rest[8+n+1:]
+	}
+	return fields
+}
+
+func TestJournalHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	l := listenUnixgram(t, path)
+
+	h, err := newJournalHandler(path, "app", slog.LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.conn.Close()
+
+	logger := slog.New(h).With("component", "api").WithGroup("request")
+	logger.Debug("not sent")
+	logger.Error("request failed",
+		"method", "GET",
+		slog.Group("client", "ip", "127.0.0.1"),
+		"time", time.Date(2025, 2, 24, 10, 15, 0, 0, time.UTC),
+		"error", "line 1\nline 2",
+		slog.Group("", "inline", true),
+		slog.Group("empty"))
+
+	// the source location is tested in TestJournalHandlerSource
+	got := slices.DeleteFunc(journalFields(t, receive(t, l)), func(f string) bool { return strings.HasPrefix(f, "CODE_") })
+	want := []string{
+		"MESSAGE=request failed",
+		"PRIORITY=3",
+		"SYSLOG_IDENTIFIER=app",
+		"COMPONENT=api",
+		"REQUEST_METHOD=GET",
+		"REQUEST_CLIENT_IP=127.0.0.1",
+		"REQUEST_TIME=2025-02-24T10:15:00Z",
+		"REQUEST_ERROR=line 1\nline 2",
+		"REQUEST_INLINE=true",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("entry = %q, want %q", got, want)
+	}
+}
+
+func TestJournalHandlerSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	l := listenUnixgram(t, path)
+
+	h, err := newJournalHandler(path, "", slog.LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.conn.Close()
+
+	slog.New(h).Info("hello")
+
+	got := journalFields(t, receive(t, l))
+	if len(got) != 5 || got[0] != "MESSAGE=hello" || got[1] != "PRIORITY=6" ||
+		!strings.HasPrefix(got[2], "CODE_FILE=") || !strings.HasSuffix(got[2], "journald_test.go") ||
+		!strings.HasPrefix(got[3], "CODE_LINE=") ||
+		got[4] != "CODE_FUNC=github.com/womat/go-api-template/app/service/logging.TestJournalHandlerSource" {
+		t.Errorf("entry = %q, want the message, priority and source location", got)
+	}
+}
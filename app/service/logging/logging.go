@@ -0,0 +1,205 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// Supported sink types.
+const (
+	SinkStdout   = "stdout"
+	SinkStderr   = "stderr"
+	SinkNull     = "null"
+	SinkFile     = "file"
+	SinkSyslog   = "syslog"
+	SinkJournald = "journald"
+)
+
+// Config defines the log sinks, every record is written to all sinks enabled for its level.
+type Config struct {
+	Sinks []Sink
+
+	// Buffer keeps the last records in memory, nil if disabled.
+	Buffer *Buffer
+}
+
+// Sink defines a log destination with its own level and format.
+type Sink struct {
+	// Type is the sink type: stdout | stderr | null | file | syslog | journald
+	Type string
+
+	// Level is the minimum log level: debug | info | warning | error
+	// If debug, the source code location is added to the log records.
+	Level string
+
+	// Format is the record format: text | json | logfmt (default text), ignored for journald.
+	Format string
+
+	// Path is the path of the log file (file).
+	Path string
+
+	// Rotate defines the rotation of the log file (file).
+	Rotate RotateOptions
+
+	// Network is the syslog network: unix | udp (default unix).
+	Network string
+
+	// Address is the syslog socket path or host:port (default /dev/log)
+	// or the journal socket path (default /run/systemd/journal/socket).
+	Address string
+
+	// Facility is the syslog facility, e.g. daemon | user | local0 (default daemon).
+	Facility string
+
+	// Tag is the application name sent to syslog and journald.
+	Tag string
+}
+
+// Logger holds the slog.Logger and the resources of the sinks.
+type Logger struct {
+	*slog.Logger
+	files   []*RotatingFile
+	closers []io.Closer
+}
+
+// Init initializes the slog logger with the given log sinks and the optional in-memory buffer.
+// Sensitive attributes (e.g. an Authorization header or a password) are redacted.
+// If a sink can't be initialized, the already initialized sinks are closed.
+func Init(cfg Config) (*Logger, error) {
+	if len(cfg.Sinks) == 0 {
+		return nil, errors.New("no log sink configured")
+	}
+
+	l := &Logger{}
+	handlers := make(multiHandler, 0, len(cfg.Sinks))
+	for _, sink := range cfg.Sinks {
+		h, err := l.newHandler(sink)
+		if err != nil {
+			_ = l.Close()
+			return nil, fmt.Errorf("log sink %s: %w", sink.Type, err)
+		}
+		handlers = append(handlers, h)
+	}
+	if cfg.Buffer != nil {
+		handlers = append(handlers, cfg.Buffer.Handler())
+	}
+
+	// sensitive attributes are redacted in all sinks
+	if len(handlers) == 1 {
+		l.Logger = slog.New(redactHandler{handlers[0]})
+	} else {
+		l.Logger = slog.New(redactHandler{handlers})
+	}
+	return l, nil
+}
+
+// newHandler returns the handler of the sink, its resources are added to the logger.
+func (l *Logger) newHandler(sink Sink) (slog.Handler, error) {
+	level := ParseLevel(sink.Level)
+	opts := &slog.HandlerOptions{
+		AddSource: level == slog.LevelDebug,
+		Level:     level,
+	}
+
+	switch strings.ToLower(sink.Type) {
+	case SinkStdout:
+		return newFormatHandler(os.Stdout, sink.Format, opts)
+	case SinkStderr:
+		return newFormatHandler(os.Stderr, sink.Format, opts)
+	case SinkNull:
+		return newFormatHandler(io.Discard, sink.Format, opts)
+	case SinkFile:
+		if sink.Path == "" {
+			return nil, errors.New("no path configured")
+		}
+		file, err := OpenRotatingFile(sink.Path, sink.Rotate)
+		if err != nil {
+			return nil, err
+		}
+		l.files = append(l.files, file)
+		l.closers = append(l.closers, file)
+		return newFormatHandler(file, sink.Format, opts)
+	case SinkSyslog:
+		w, err := newSyslogWriter(sink.Network, sink.Address, sink.Facility, sink.Tag)
+		if err != nil {
+			return nil, err
+		}
+		l.closers = append(l.closers, w)
+		return newSyslogHandler(w, sink.Format, opts)
+	case SinkJournald:
+		h, err := newJournalHandler(sink.Address, sink.Tag, level)
+		if err != nil {
+			return nil, err
+		}
+		l.closers = append(l.closers, h.conn)
+		return h, nil
+	default:
+		return nil, fmt.Errorf("unsupported log sink type %q", sink.Type)
+	}
+}
+
+// ParseLevel returns the slog level of the given log level name, default is info.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "error":
+		return slog.LevelError
+	case "warning", "warn":
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// Reopen reopens the log files, e.g. after they were moved by an external logrotate.
+func (l *Logger) Reopen() error {
+	var errs []error
+	for _, f := range l.files {
+		errs = append(errs, f.Reopen())
+	}
+	return errors.Join(errs...)
+}
+
+// HandleReopenSignal reopens the log files on SIGUSR1 (not supported on windows) until the returned stop function is called.
+func (l *Logger) HandleReopenSignal() (stop func()) {
+	sig := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	if len(reopenSignals) > 0 {
+		signal.Notify(sig, reopenSignals...)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case s := <-sig:
+				if err := l.Reopen(); err != nil {
+					slog.Error("Failed to reopen log file", "signal", s, "error", err)
+					continue
+				}
+				slog.Info("Log files reopened", "signal", s)
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
+
+// Close closes the log files and the connections to syslog and journald.
+func (l *Logger) Close() error {
+	var errs []error
+	for _, c := range l.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"context"
+	"github.com/womat/go-api-template/app/service/secret"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// sensitiveHeaders are the http headers whose values are redacted in logs.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// sensitiveKeys are the attribute keys whose values are redacted in logs,
+// compared in lower case without "-" and "_", e.g. "X-Api-Key" matches "xapikey".
+var sensitiveKeys = map[string]bool{
+	"authorization": true, "proxyauthorization": true, "cookie": true, "setcookie": true,
+	"xapikey": true, "apikey": true, "password": true, "secret": true, "token": true, "jwtsecret": true,
+}
+
+// RedactHeader returns a copy of h with the values of sensitive headers (Authorization, Cookie, X-Api-Key, ...) redacted.
+func RedactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range sensitiveHeaders {
+		if values, ok := h[name]; ok {
+			for i := range values {
+				values[i] = secret.Redacted
+			}
+		}
+	}
+	return h
+}
+
+// isSensitiveKey reports whether the value of the attribute key must be redacted.
+func isSensitiveKey(key string) bool {
+	key = strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+	return sensitiveKeys[key]
+}
+
+// redactAttr redacts the attribute if its key is sensitive, http headers are redacted with RedactHeader.
+func redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	switch {
+	case a.Value.Kind() == slog.KindGroup:
+		attrs := a.Value.Group()
+		redacted := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			redacted[i] = redactAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	case isSensitiveKey(a.Key) && a.Value.String() != "":
+		return slog.String(a.Key, secret.Redacted)
+	case a.Value.Kind() == slog.KindAny:
+		if h, ok := a.Value.Any().(http.Header); ok {
+			return slog.Any(a.Key, RedactHeader(h))
+		}
+	}
+	return a
+}
+
+// redactHandler redacts sensitive attributes (e.g. an Authorization header) before they're passed to the wrapped handler.
+type redactHandler struct {
+	slog.Handler
+}
+
+// Handle passes the record with redacted attributes to the wrapped handler.
+func (h redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, redacted)
+}
+
+// WithAttrs returns a redactHandler whose wrapped handler has the redacted attributes.
+func (h redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return redactHandler{h.Handler.WithAttrs(redacted)}
+}
+
+// WithGroup returns a redactHandler whose wrapped handler has the group.
+func (h redactHandler) WithGroup(name string) slog.Handler {
+	return redactHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"bytes"
+	"github.com/womat/go-api-template/app/service/secret"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRedactHeader(t *testing.T) {
+	h := http.Header{
+		"Authorization": {"Bearer token"},
+		"Cookie":        {"a=1", "b=2"},
+		"X-Api-Key":     {"12345678"},
+		"Accept":        {"application/json"},
+	}
+
+	got := RedactHeader(h)
+	want := http.Header{
+		"Authorization": {secret.Redacted},
+		"Cookie":        {secret.Redacted, secret.Redacted},
+		"X-Api-Key":     {secret.Redacted},
+		"Accept":        {"application/json"},
+	}
+	for name, values := range want {
+		if !slices.Equal(got[name], values) {
+			t.Errorf("RedactHeader()[%s] = %q, want %q", name, got[name], values)
+		}
+	}
+
+	if h.Get("Authorization") != "Bearer token" {
+		t.Errorf("RedactHeader() modified the original header: %q", h)
+	}
+}
+
+func TestIsSensitiveKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"password", true},
+		{"Password", true},
+		{"X-Api-Key", true},
+		{"api_key", true},
+		{"jwtSecret", true},
+		{"Set-Cookie", true},
+		{"token", true},
+		{"tokens", false},
+		{"user", false},
+		{"method", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := isSensitiveKey(tt.key); got != tt.want {
+				t.Errorf("isSensitiveKey(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l *slog.Logger)
+		want string
+	}{
+		{
+			name: "sensitive key",
+			log:  func(l *slog.Logger) { l.Info("login", "user", "alice", "password", "s3cret") },
+			want: `msg=login user=alice password=[REDACTED]`,
+		},
+		{
+			name: "empty sensitive value",
+			log:  func(l *slog.Logger) { l.Info("login", "password", "") },
+			want: `msg=login password=""`,
+		},
+		{
+			name: "group",
+			log:  func(l *slog.Logger) { l.Info("request", slog.Group("auth", "token", "abc", "scheme", "bearer")) },
+			want: `msg=request auth.token=[REDACTED] auth.scheme=bearer`,
+		},
+		{
+			name: "http header",
+			log: func(l *slog.Logger) {
+				l.Info("request", "headers", http.Header{"Authorization": {"Bearer abc"}, "Accept": {"*/*"}})
+			},
+			want: `msg=request headers="map[Accept:[*/*] Authorization:[[REDACTED]]]"`,
+		},
+		{
+			name: "secret value",
+			log:  func(l *slog.Logger) { l.Info("config", "key", secret.String("12345678")) },
+			want: `msg=config key=[REDACTED]`,
+		},
+		{
+			name: "with attrs",
+			log:  func(l *slog.Logger) { l.With("apiKey", "12345678", "env", "dev").Info("start") },
+			want: `msg=start apiKey=[REDACTED] env=dev`,
+		},
+		{
+			name: "with group",
+			log:  func(l *slog.Logger) { l.WithGroup("db").Info("connect", "secret", "abc", "host", "localhost") },
+			want: `msg=connect db.secret=[REDACTED] db.host=localhost`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			h := slog.NewTextHandler(&b, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+						return slog.Attr{}
+					}
+					return a
+				},
+			})
+			tt.log(slog.New(redactHandler{h}))
+
+			if got := strings.TrimSpace(b.String()); got != tt.want {
+				t.Errorf("logged %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package logging
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedFormat is the time format of the suffix of rotated files, e.g. app.log.2025-02-24T10-15-00.000
+const rotatedFormat = "2006-01-02T15-04-05.000"
+
+// openFile opens the log file, it's replaced in tests.
+var openFile = os.OpenFile
+
+// RotateOptions defines when a log file is rotated and how many rotated files are retained.
+type RotateOptions struct {
+	// MaxSize is the size in bytes after which the file is rotated, 0 disables rotation by size.
+	MaxSize int64
+
+	// MaxAge is the age after which the file is rotated, 0 disables rotation by age.
+	// The age is measured from the time the file was opened.
+	MaxAge time.Duration
+
+	// MaxFiles is the number of retained rotated files, older files are removed. 0 retains all files.
+	MaxFiles int
+
+	// Compress compresses rotated files with gzip.
+	Compress bool
+}
+
+// RotatingFile is a log file which is rotated by size and/or age.
+// It's safe for concurrent use.
+type RotatingFile struct {
+	path string
+	opts RotateOptions
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// rotated is the name of the renamed file if a new file couldn't be opened after the rename,
+	// the renamed file is written until the new file is opened by the next Write.
+	rotated string
+
+	// wg tracks the background compression and cleanup of rotated files,
+	// cleanupDone is closed when the last scheduled cleanup is done
+	wg          sync.WaitGroup
+	cleanupDone chan struct{}
+}
+
+// OpenRotatingFile opens (or creates) the log file at path for appending.
+func OpenRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, opts: opts}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open opens the log file, the caller must hold the lock.
+func (f *RotatingFile) open() error {
+	file, err := openFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	fi, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	f.file, f.size, f.openedAt = file, fi.Size(), time.Now()
+	return nil
+}
+
+// Write writes p to the log file, the file is rotated before if p exceeds the maximum size or the file is too old.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.rotated != "" || f.size > 0 && (f.opts.MaxSize > 0 && f.size+int64(len(p)) > f.opts.MaxSize ||
+		f.opts.MaxAge > 0 && time.Since(f.openedAt) >= f.opts.MaxAge) {
+		if err := f.rotate(); err != nil {
+			// keep logging to the current file
+			fmt.Fprintf(os.Stderr, "Failed to rotate log file %s: %v\n", f.path, err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the log file immediately.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// rotate renames the current file and opens a new one, the caller must hold the lock.
+// If the new file can't be opened, the renamed file is still written and the next rotate retries to open the new file.
+// The rotated file is compressed and old files are removed in the background.
+func (f *RotatingFile) rotate() error {
+	if f.rotated == "" {
+		rotated := f.path + "." + time.Now().Format(rotatedFormat)
+		if err := os.Rename(f.path, rotated); err != nil {
+			return err
+		}
+		f.rotated = rotated
+	}
+
+	old := f.file
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	closeErr := old.Close()
+	f.cleanup(f.rotated)
+	f.rotated = ""
+	return closeErr
+}
+
+// cleanup compresses the rotated file and removes old files in the background, the caller must hold the lock.
+// The cleanups run one after the other in the order of the rotations.
+func (f *RotatingFile) cleanup(rotated string) {
+	prev, done := f.cleanupDone, make(chan struct{})
+	f.cleanupDone = done
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		defer close(done)
+
+		if prev != nil {
+			<-prev
+		}
+
+		if f.opts.Compress {
+			if err := compress(rotated); err != nil {
+				slog.Error("Failed to compress rotated log file", "file", rotated, "error", err)
+			}
+		}
+		if err := f.removeOld(); err != nil {
+			slog.Error("Failed to remove old log files", "error", err)
+		}
+	}()
+}
+
+// Reopen reopens the log file, e.g. after it was moved by an external logrotate.
+// If the file can't be opened, the current file is still written.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	old := f.file
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	var err error
+	if old != nil {
+		err = old.Close()
+	}
+	if f.rotated != "" {
+		f.cleanup(f.rotated)
+		f.rotated = ""
+	}
+	return err
+}
+
+// Close closes the log file and waits for the background compression of rotated files.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	f.wg.Wait()
+	return err
+}
+
+// removeOld removes the oldest rotated files exceeding the maximum number of retained files.
+func (f *RotatingFile) removeOld() error {
+	if f.opts.MaxFiles <= 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return err
+	}
+
+	var rotated []string
+	for _, m := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(m, f.path+"."), ".gz")
+		if _, err := time.Parse(rotatedFormat, suffix); err == nil {
+			rotated = append(rotated, m)
+		}
+	}
+	if len(rotated) <= f.opts.MaxFiles {
+		return nil
+	}
+
+	// the time suffix sorts chronologically
+	slices.SortFunc(rotated, func(a, b string) int {
+		return strings.Compare(strings.TrimSuffix(a, ".gz"), strings.TrimSuffix(b, ".gz"))
+	})
+
+	var errs []error
+	for _, name := range rotated[:len(rotated)-f.opts.MaxFiles] {
+		errs = append(errs, os.Remove(name))
+	}
+	return errors.Join(errs...)
+}
+
+// compress compresses the file name to name.gz and removes it.
+func compress(name string) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(name + ".gz")
+		}
+	}()
+
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+
+	_ = src.Close()
+	return os.Remove(name)
+}
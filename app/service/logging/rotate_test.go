@@ -0,0 +1,237 @@
+package logging
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rotatedFiles returns the base names of the rotated files of path in chronological order.
+func rotatedFiles(t *testing.T, path string) []string {
+	t.Helper()
+
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range matches {
+		matches[i] = filepath.Base(m)
+	}
+	slices.Sort(matches)
+	return matches
+}
+
+// readFile returns the content of name, gzip compressed files are decompressed.
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(name, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// writeLines writes the lines to f, each line is written after the clock advanced, so rotated files get distinct names.
+func writeLines(t *testing.T, f *RotatingFile, lines ...string) {
+	t.Helper()
+
+	for _, line := range lines {
+		time.Sleep(2 * time.Millisecond)
+		if _, err := f.Write([]byte(line + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        RotateOptions
+		lines       []string
+		wantCurrent string
+		wantRotated []string
+	}{
+		{
+			name:        "no rotation",
+			opts:        RotateOptions{},
+			lines:       []string{"one", "two", "three"},
+			wantCurrent: "one\ntwo\nthree\n",
+		},
+		{
+			name:        "rotation by size",
+			opts:        RotateOptions{MaxSize: 8},
+			lines:       []string{"one", "two", "three"},
+			wantCurrent: "three\n",
+			wantRotated: []string{"one\ntwo\n"},
+		},
+		{
+			name:        "oversized line is written to an empty file",
+			opts:        RotateOptions{MaxSize: 4},
+			lines:       []string{"first line", "second line"},
+			wantCurrent: "second line\n",
+			wantRotated: []string{"first line\n"},
+		},
+		{
+			name:        "retention",
+			opts:        RotateOptions{MaxSize: 4, MaxFiles: 2},
+			lines:       []string{"1", "2", "3", "4", "5", "6", "7"},
+			wantCurrent: "7\n",
+			wantRotated: []string{"3\n4\n", "5\n6\n"},
+		},
+		{
+			name:        "retention with compression",
+			opts:        RotateOptions{MaxSize: 4, MaxFiles: 2, Compress: true},
+			lines:       []string{"1", "2", "3", "4", "5", "6", "7"},
+			wantCurrent: "7\n",
+			wantRotated: []string{"3\n4\n", "5\n6\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			f, err := OpenRotatingFile(path, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeLines(t, f, tt.lines...)
+			if err = f.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := readFile(t, path); got != tt.wantCurrent {
+				t.Errorf("current file = %q, want %q", got, tt.wantCurrent)
+			}
+
+			rotated := rotatedFiles(t, path)
+			var got []string
+			for _, name := range rotated {
+				if tt.opts.Compress != strings.HasSuffix(name, ".gz") {
+					t.Errorf("rotated file %s, want compressed %v", name, tt.opts.Compress)
+				}
+				got = append(got, readFile(t, filepath.Join(filepath.Dir(path), name)))
+			}
+			if !slices.Equal(got, tt.wantRotated) {
+				t.Errorf("rotated files = %q, want %q", got, tt.wantRotated)
+			}
+		})
+	}
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := OpenRotatingFile(path, RotateOptions{MaxAge: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	writeLines(t, f, "old")
+	time.Sleep(30 * time.Millisecond)
+	writeLines(t, f, "new")
+
+	if got := readFile(t, path); got != "new\n" {
+		t.Errorf("current file = %q, want %q", got, "new\n")
+	}
+	if got := rotatedFiles(t, path); len(got) != 1 {
+		t.Errorf("rotated files = %q, want 1 file", got)
+	}
+}
+
+func TestRotatingFileOpenFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := OpenRotatingFile(path, RotateOptions{MaxSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	writeLines(t, f, "1")
+
+	// the file is renamed, but the new file can't be opened
+	openFile = func(string, int, os.FileMode) (*os.File, error) { return nil, errors.New("too many open files") }
+	defer func() { openFile = os.OpenFile }()
+
+	writeLines(t, f, "2", "3")
+	if got := rotatedFiles(t, path); len(got) != 1 {
+		t.Fatalf("rotated files = %q, want 1 file", got)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Stat() error = %v, want the file to be renamed", err)
+	}
+
+	// the next write opens the new file
+	openFile = os.OpenFile
+	writeLines(t, f, "4")
+
+	rotated := rotatedFiles(t, path)
+	if len(rotated) != 1 {
+		t.Fatalf("rotated files = %q, want 1 file", rotated)
+	}
+	if got := readFile(t, filepath.Join(filepath.Dir(path), rotated[0])); got != "1\n2\n3\n" {
+		t.Errorf("rotated file = %q, want the writes during the failure", got)
+	}
+	if got := readFile(t, path); got != "4\n" {
+		t.Errorf("current file = %q, want %q", got, "4\n")
+	}
+}
+
+func TestRotatingFileReopenFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := OpenRotatingFile(path, RotateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	openFile = func(string, int, os.FileMode) (*os.File, error) { return nil, errors.New("too many open files") }
+	err = f.Reopen()
+	openFile = os.OpenFile
+	if err == nil {
+		t.Fatal("Reopen() error = nil, want the open error")
+	}
+
+	writeLines(t, f, "still logged")
+	if got := readFile(t, path); got != "still logged\n" {
+		t.Errorf("current file = %q, want %q", got, "still logged\n")
+	}
+}
+
+func TestRotatingFileClosed(t *testing.T) {
+	f, err := OpenRotatingFile(filepath.Join(t.TempDir(), "app.log"), RotateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = f.Write([]byte("x")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write() error = %v, want %v", err, os.ErrClosed)
+	}
+	if err = f.Rotate(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Rotate() error = %v, want %v", err, os.ErrClosed)
+	}
+}
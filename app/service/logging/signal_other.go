@@ -0,0 +1,8 @@
+//go:build !unix
+
+package logging
+
+import "os"
+
+// reopenSignals are the signals reopening the log file, none on this platform.
+var reopenSignals []os.Signal
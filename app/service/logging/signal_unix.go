@@ -0,0 +1,11 @@
+//go:build unix
+
+package logging
+
+import (
+	"os"
+	"syscall"
+)
+
+// reopenSignals are the signals reopening the log file.
+var reopenSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,220 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// facilities are the syslog facility codes by name.
+var facilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// defaultSyslogAddress is the local syslog socket.
+const defaultSyslogAddress = "/dev/log"
+
+// severity returns the syslog severity of the slog level.
+func severity(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3 // error
+	case level >= slog.LevelWarn:
+		return 4 // warning
+	case level >= slog.LevelInfo:
+		return 6 // informational
+	default:
+		return 7 // debug
+	}
+}
+
+// syslogWriter sends RFC 5424 messages to a syslog server over a unix or udp socket.
+// Every Write is sent as one message with the severity of the record being handled.
+type syslogWriter struct {
+	network, address string
+	facility         int
+	hostname, tag    string
+	pid              int
+
+	mu     sync.Mutex
+	conn   net.Conn
+	stream bool
+	level  slog.Level
+}
+
+// newSyslogWriter returns a syslogWriter connected to the syslog server.
+// network is unix (datagram or stream, default) or udp, address is the socket path or host:port (default /dev/log).
+func newSyslogWriter(network, address, facility, tag string) (*syslogWriter, error) {
+	if network == "" {
+		network = "unix"
+	}
+	if address == "" {
+		address = defaultSyslogAddress
+	}
+	if facility == "" {
+		facility = "daemon"
+	}
+
+	code, ok := facilities[strings.ToLower(facility)]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q", facility)
+	}
+
+	hostname, _ := os.Hostname()
+	w := &syslogWriter{
+		network:  strings.ToLower(network),
+		address:  address,
+		facility: code,
+		hostname: hostname,
+		tag:      tag,
+		pid:      os.Getpid(),
+	}
+
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// errNotConnected is returned if the connection to the syslog server was lost and couldn't be reestablished.
+var errNotConnected = errors.New("not connected to syslog")
+
+// connect connects to the syslog server, unix tries a datagram socket first and then a stream socket.
+// The current connection is replaced only if the connection succeeds.
+func (w *syslogWriter) connect() error {
+	var (
+		conn   net.Conn
+		err    error
+		stream bool
+	)
+
+	switch w.network {
+	case "unix":
+		if conn, err = net.Dial("unixgram", w.address); err != nil {
+			conn, err = net.Dial("unix", w.address)
+			stream = true
+		}
+	case "unixgram", "udp", "udp4", "udp6":
+		conn, err = net.Dial(w.network, w.address)
+	default:
+		err = fmt.Errorf("unsupported syslog network %q", w.network)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.conn, w.stream = conn, stream
+	return nil
+}
+
+// message returns p as RFC 5424 message with the severity of the current level.
+func (w *syslogWriter) message(p []byte) []byte {
+	var b bytes.Buffer
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d - - ",
+		w.facility*8+severity(w.level),
+		time.Now().Format(time.RFC3339Nano),
+		nilValue(w.hostname), nilValue(w.tag), w.pid)
+	b.Write(bytes.TrimRight(p, "\n"))
+
+	if w.stream {
+		// stream sockets need a message delimiter
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// Write sends p as one RFC 5424 message, the caller must hold the lock (see levelHandler).
+// If sending fails or the connection was lost before, Write reconnects and sends the message again.
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	if w.conn != nil {
+		if _, err := w.conn.Write(w.message(p)); err == nil {
+			return len(p), nil
+		}
+		// the syslog server may have been restarted
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+
+	if err := w.connect(); err != nil {
+		return 0, errors.Join(errNotConnected, err)
+	}
+	if _, err := w.conn.Write(w.message(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the connection to the syslog server.
+func (w *syslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return errNotConnected
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// nilValue returns the RFC 5424 NILVALUE "-" for empty header fields, spaces are replaced.
+func nilValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(s, " ", "_")
+}
+
+// levelHandler passes the level of the handled record to the syslogWriter of the wrapped handler.
+// The wrapped handler writes every record with exactly one Write call.
+type levelHandler struct {
+	slog.Handler
+	w *syslogWriter
+}
+
+// Handle sets the level of the writer and passes the record to the wrapped handler.
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.w.mu.Lock()
+	defer h.w.mu.Unlock()
+
+	h.w.level = r.Level
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs returns a levelHandler whose wrapped handler has the attributes.
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), w: h.w}
+}
+
+// WithGroup returns a levelHandler whose wrapped handler has the group.
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithGroup(name), w: h.w}
+}
+
+// newSyslogHandler returns a handler sending records in the given format to syslog.
+// The time and level are part of the syslog header and omitted from the message.
+func newSyslogHandler(w *syslogWriter, format string, opts *slog.HandlerOptions) (slog.Handler, error) {
+	o := *opts
+	o.ReplaceAttr = chainReplace(opts.ReplaceAttr, func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+			return slog.Attr{}
+		}
+		return a
+	})
+
+	h, err := newFormatHandler(w, format, &o)
+	if err != nil {
+		return nil, err
+	}
+	return &levelHandler{Handler: h, w: w}, nil
+}
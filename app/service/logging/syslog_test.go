@@ -0,0 +1,193 @@
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// listenUnixgram listens on a unix datagram socket in a temporary directory.
+func listenUnixgram(t *testing.T, path string) *net.UnixConn {
+	t.Helper()
+
+	l, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+// receive returns the next datagram received by l.
+func receive(t *testing.T, l *net.UnixConn) string {
+	t.Helper()
+
+	b := make([]byte, 64*1024)
+	_ = l.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := l.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b[:n])
+}
+
+func TestSyslogMessage(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	tests := []struct {
+		name     string
+		facility string
+		tag      string
+		hostname string
+		level    slog.Level
+		stream   bool
+		msg      string
+		want     string
+	}{
+		{"info", "daemon", "app", "host", slog.LevelInfo, false, "msg=hello\n",
+			`^<30>1 \S+ host app ` + pid + ` - - msg=hello$`},
+		{"error local0", "local0", "app", "host", slog.LevelError, false, "failed",
+			`^<131>1 \S+ host app ` + pid + ` - - failed$`},
+		{"warning", "user", "app", "host", slog.LevelWarn, false, "warn",
+			`^<12>1 \S+ host app ` + pid + ` - - warn$`},
+		{"debug", "daemon", "app", "host", slog.LevelDebug, false, "debug",
+			`^<31>1 \S+ host app ` + pid + ` - - debug$`},
+		{"nil values", "daemon", "", "", slog.LevelInfo, false, "msg",
+			`^<30>1 \S+ - - ` + pid + ` - - msg$`},
+		{"spaces are replaced", "daemon", "my app", "my host", slog.LevelInfo, false, "msg",
+			`^<30>1 \S+ my_host my_app ` + pid + ` - - msg$`},
+		{"stream delimiter", "daemon", "app", "host", slog.LevelInfo, true, "msg\n",
+			`^<30>1 \S+ host app ` + pid + ` - - msg\n$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &syslogWriter{
+				facility: facilities[tt.facility],
+				hostname: tt.hostname,
+				tag:      tt.tag,
+				pid:      os.Getpid(),
+				stream:   tt.stream,
+				level:    tt.level,
+			}
+
+			got := string(w.message([]byte(tt.msg)))
+			if !regexp.MustCompile(tt.want).MatchString(got) {
+				t.Errorf("message() = %q, want %s", got, tt.want)
+			}
+
+			// the timestamp is RFC 3339
+			ts := regexp.MustCompile(`^<\d+>1 (\S+) `).FindStringSubmatch(got)
+			if _, err := time.Parse(time.RFC3339Nano, ts[1]); err != nil {
+				t.Errorf("timestamp %q: %v", ts[1], err)
+			}
+		})
+	}
+}
+
+func TestNewSyslogWriterErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		network  string
+		address  string
+		facility string
+	}{
+		{"unknown facility", "unixgram", "", "nope"},
+		{"unsupported network", "tcp", "127.0.0.1:514", ""},
+		{"missing socket", "unix", filepath.Join(t.TempDir(), "missing"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newSyslogWriter(tt.network, tt.address, tt.facility, "app"); err == nil {
+				t.Error("newSyslogWriter() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestSyslogHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	l := listenUnixgram(t, path)
+
+	w, err := newSyslogWriter("unix", path, "local3", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	h, err := newSyslogHandler(w, "text", &slog.HandlerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slog.New(h).Warn("disk full", "free", 0)
+
+	want := regexp.MustCompile(`^<156>1 \S+ \S+ app \d+ - - msg="disk full" free=0$`)
+	if got := receive(t, l); !want.MatchString(got) {
+		t.Errorf("received %q, want %s", got, want)
+	}
+}
+
+func TestSyslogWriterReconnect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	l := listenUnixgram(t, path)
+
+	w, err := newSyslogWriter("unixgram", path, "", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = w.Write([]byte("first")); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, l)
+
+	// the syslog server is stopped, reconnecting fails
+	_ = l.Close()
+	_ = os.Remove(path)
+	if _, err = w.Write([]byte("lost")); err == nil {
+		t.Fatal("Write() error = nil, want an error")
+	}
+	if _, err = w.Write([]byte("lost")); !errors.Is(err, errNotConnected) {
+		t.Fatalf("Write() error = %v, want %v", err, errNotConnected)
+	}
+
+	// the syslog server is restarted, the next write reconnects
+	l = listenUnixgram(t, path)
+	if _, err = w.Write([]byte("second")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if got := receive(t, l); !regexp.MustCompile(` - - second$`).MatchString(got) {
+		t.Errorf("received %q, want the second message", got)
+	}
+
+	if err = w.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if err = w.Close(); !errors.Is(err, errNotConnected) {
+		t.Errorf("Close() error = %v, want %v", err, errNotConnected)
+	}
+}
+
+func TestSyslogWriterCloseAfterFailedReconnect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	l := listenUnixgram(t, path)
+
+	w, err := newSyslogWriter("unixgram", path, "", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = l.Close()
+	_ = os.Remove(path)
+	_, _ = w.Write([]byte("lost"))
+
+	if err = w.Close(); !errors.Is(err, errNotConnected) {
+		t.Errorf("Close() error = %v, want %v", err, errNotConnected)
+	}
+}
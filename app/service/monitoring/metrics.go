@@ -0,0 +1,323 @@
+package monitoring
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds the metrics published by the application code.
+// The values of the metrics are merged into the monitoring data alongside the built-in runtime entries.
+//
+//	requests := registry.Counter("http_requests_total", "Number of http requests", "method", "status")
+//	requests.Inc("GET", "200")
+type Registry struct {
+	mu      sync.Mutex
+	labels  map[string]string
+	metrics []metric
+}
+
+// metric is implemented by all instruments.
+type metric interface {
+	name() string
+	models(host string, labels map[string]string) []Model
+}
+
+// NewRegistry returns a new Registry, labels are static instance labels added to every monitoring entry.
+func NewRegistry(labels map[string]string) *Registry {
+	return &Registry{labels: labels}
+}
+
+// Counter returns the counter with the given name, it's created if it doesn't exist.
+// labelNames are the names of the labels, the values are passed in the same order when the counter is updated.
+// It panics if a metric of another type is registered with the same name.
+func (r *Registry) Counter(name, help string, labelNames ...string) *Counter {
+	return register(r, name, func() *Counter {
+		return &Counter{instrument: newInstrument(name, help, labelNames)}
+	})
+}
+
+// Gauge returns the gauge with the given name, it's created if it doesn't exist.
+// labelNames are the names of the labels, the values are passed in the same order when the gauge is updated.
+// It panics if a metric of another type is registered with the same name.
+func (r *Registry) Gauge(name, help string, labelNames ...string) *Gauge {
+	return register(r, name, func() *Gauge {
+		return &Gauge{instrument: newInstrument(name, help, labelNames)}
+	})
+}
+
+// Histogram returns the histogram with the given name, it's created if it doesn't exist.
+// buckets are the upper bounds of the buckets in increasing order.
+// labelNames are the names of the labels, the values are passed in the same order when a value is observed.
+// It panics if a metric of another type is registered with the same name.
+func (r *Registry) Histogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	return register(r, name, func() *Histogram {
+		b := slices.Clone(buckets)
+		slices.Sort(b)
+		return &Histogram{instrument: newInstrument(name, help, labelNames), buckets: b}
+	})
+}
+
+// Collect appends the entries of all registered metrics to services.
+func (r *Registry) Collect(host string, services []Model) []Model {
+	r.mu.Lock()
+	metrics := slices.Clone(r.metrics)
+	r.mu.Unlock()
+
+	for _, m := range metrics {
+		services = append(services, m.models(host, r.labels)...)
+	}
+	return services
+}
+
+// Label adds the static instance labels to every service.
+func (r *Registry) Label(services []Model) []Model {
+	if len(r.labels) == 0 {
+		return services
+	}
+
+	for i := range services {
+		services[i].Labels = mergeLabels(r.labels, services[i].Labels)
+	}
+	return services
+}
+
+// register returns the metric with the given name or registers the one created by create.
+func register[T metric](r *Registry, name string, create func() T) T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, m := range r.metrics {
+		if m.name() == name {
+			t, ok := m.(T)
+			if !ok {
+				panic(fmt.Sprintf("metric %s already registered with another type", name))
+			}
+			return t
+		}
+	}
+
+	m := create()
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+// series is the value of a metric for a combination of label values.
+type series struct {
+	labels []string
+	value  float64
+	counts []uint64 // cumulative bucket counts, histograms only
+	count  uint64   // histograms only
+}
+
+// instrument holds the series of a metric.
+type instrument struct {
+	mu         sync.Mutex
+	metricName string
+	help       string
+	labelNames []string
+	series     map[string]*series
+
+	// mismatchLogged is set once a mismatch of the label values was logged, it's logged once per metric
+	// to not flood the log if the metric is updated on a hot path.
+	mismatchLogged bool
+}
+
+func newInstrument(name, help string, labelNames []string) instrument {
+	return instrument{metricName: name, help: help, labelNames: labelNames, series: map[string]*series{}}
+}
+
+func (i *instrument) name() string {
+	return i.metricName
+}
+
+// get returns the series of the label values, i.mu must be held.
+// It returns nil if the number of label values doesn't match the label names, the first mismatch is logged.
+func (i *instrument) get(values []string) *series {
+	if len(values) != len(i.labelNames) {
+		if !i.mismatchLogged {
+			i.mismatchLogged = true
+			slog.Warn("Metric label values don't match label names, values dropped (logged once)",
+				"metric", i.metricName,
+				"labelNames", i.labelNames,
+				"labelValues", values)
+		}
+		return nil
+	}
+
+	key := strings.Join(values, "\xff")
+	s, ok := i.series[key]
+	if !ok {
+		s = &series{labels: slices.Clone(values)}
+		i.series[key] = s
+	}
+	return s
+}
+
+// each calls f for every series sorted by label values, with the labels of the series merged with the instance labels.
+func (i *instrument) each(instance map[string]string, f func(s *series, labels map[string]string)) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, key := range slices.Sorted(maps.Keys(i.series)) {
+		s := i.series[key]
+		labels := map[string]string{}
+		for n, name := range i.labelNames {
+			labels[name] = s.labels[n]
+		}
+		f(s, mergeLabels(instance, labels))
+	}
+}
+
+// serviceName returns the metric name with the series labels, e.g. http_requests_total{method="GET"}.
+func (i *instrument) serviceName(name string, s *series, extra ...string) string {
+	var pairs []string
+	for n, label := range i.labelNames {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", label, s.labels[n]))
+	}
+	pairs = append(pairs, extra...)
+
+	if len(pairs) == 0 {
+		return name
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Counter is a metric that only increases over time.
+type Counter struct {
+	instrument
+}
+
+// Inc increments the counter of the label values by 1.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases the counter of the label values by delta, negative values are ignored.
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if s := c.get(labelValues); s != nil {
+		s.value += delta
+	}
+}
+
+func (c *Counter) models(host string, labels map[string]string) []Model {
+	var services []Model
+	c.each(labels, func(s *series, l map[string]string) {
+		services = append(services, newModel(host, c.serviceName(c.metricName, s), c.help, s.value, MetricCounter, l))
+	})
+	return services
+}
+
+// Gauge is a metric that can go up and down.
+type Gauge struct {
+	instrument
+}
+
+// Set sets the gauge of the label values to v.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if s := g.get(labelValues); s != nil {
+		s.value = v
+	}
+}
+
+// Add adds delta (which may be negative) to the gauge of the label values.
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if s := g.get(labelValues); s != nil {
+		s.value += delta
+	}
+}
+
+func (g *Gauge) models(host string, labels map[string]string) []Model {
+	var services []Model
+	g.each(labels, func(s *series, l map[string]string) {
+		services = append(services, newModel(host, g.serviceName(g.metricName, s), g.help, s.value, MetricGauge, l))
+	})
+	return services
+}
+
+// Histogram counts observed values in buckets.
+// It's reported as <name>_count, <name>_sum and a cumulative <name>_bucket{le="..."} entry per bucket.
+type Histogram struct {
+	instrument
+	buckets []float64
+}
+
+// Observe adds a value to the histogram of the label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.get(labelValues)
+	if s == nil {
+		return
+	}
+
+	if s.counts == nil {
+		s.counts = make([]uint64, len(h.buckets))
+	}
+	for n, upper := range h.buckets {
+		if v <= upper {
+			s.counts[n]++
+		}
+	}
+	s.count++
+	s.value += v
+}
+
+func (h *Histogram) models(host string, labels map[string]string) []Model {
+	var services []Model
+	h.each(labels, func(s *series, l map[string]string) {
+		services = append(services,
+			newModel(host, h.serviceName(h.metricName+"_count", s), h.help, float64(s.count), MetricCounter, l),
+			newModel(host, h.serviceName(h.metricName+"_sum", s), h.help, s.value, MetricCounter, l))
+
+		for n, upper := range h.buckets {
+			le := strconv.FormatFloat(upper, 'g', -1, 64)
+			bucketLabels := mergeLabels(l, map[string]string{"le": le})
+			services = append(services, newModel(host, h.serviceName(h.metricName+"_bucket", s, fmt.Sprintf("le=%q", le)), h.help, float64(s.counts[n]), MetricCounter, bucketLabels))
+		}
+	})
+	return services
+}
+
+// newModel returns the monitoring entry of a metric value.
+func newModel(host, service, help string, value float64, metric MetricKind, labels map[string]string) Model {
+	return Model{
+		Service:     service,
+		Host:        host,
+		State:       StateOK,
+		Value:       value,
+		Description: fmt.Sprintf("%s: %v", help, value),
+		Metric:      metric,
+		Labels:      labels,
+	}
+}
+
+// mergeLabels returns the union of both label sets, labels of b take precedence.
+func mergeLabels(a, b map[string]string) map[string]string {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+
+	m := make(map[string]string, len(a)+len(b))
+	maps.Copy(m, a)
+	maps.Copy(m, b)
+	return m
+}
@@ -0,0 +1,200 @@
+package monitoring
+
+import (
+	"bytes"
+	"log/slog"
+	"maps"
+	"strings"
+	"testing"
+)
+
+// values returns the values of the collected services by service name.
+func values(r *Registry) map[string]any {
+	m := map[string]any{}
+	for _, s := range r.Collect("pi", nil) {
+		m[s.Service] = s.Value
+	}
+	return m
+}
+
+func TestCounter(t *testing.T) {
+	r := NewRegistry(nil)
+	c := r.Counter("http_requests_total", "Number of http requests", "method", "status")
+	c.Inc("GET", "200")
+	c.Inc("GET", "200")
+	c.Add(2.5, "POST", "201")
+	c.Add(-1, "GET", "200")
+
+	want := map[string]any{
+		`http_requests_total{method="GET",status="200"}`:  2.0,
+		`http_requests_total{method="POST",status="201"}`: 2.5,
+	}
+	if got := values(r); !maps.Equal(got, want) {
+		t.Errorf("Collect() = %v, want %v", got, want)
+	}
+}
+
+func TestGauge(t *testing.T) {
+	r := NewRegistry(nil)
+	g := r.Gauge("queue_length", "Length of the queue")
+	g.Set(5)
+	g.Add(-2)
+	g.Add(0.5)
+
+	want := map[string]any{"queue_length": 3.5}
+	if got := values(r); !maps.Equal(got, want) {
+		t.Errorf("Collect() = %v, want %v", got, want)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	r := NewRegistry(nil)
+	// the buckets are sorted
+	h := r.Histogram("duration_seconds", "Request duration", []float64{1, 0.1, 0.5}, "route")
+	for _, v := range []float64{0.05, 0.1, 0.3, 0.7, 2} {
+		h.Observe(v, "/a")
+	}
+	h.Observe(0.2, "/b")
+
+	want := map[string]any{
+		`duration_seconds_count{route="/a"}`:           5.0,
+		`duration_seconds_sum{route="/a"}`:             3.15,
+		`duration_seconds_bucket{route="/a",le="0.1"}`: 2.0,
+		`duration_seconds_bucket{route="/a",le="0.5"}`: 3.0,
+		`duration_seconds_bucket{route="/a",le="1"}`:   4.0,
+		`duration_seconds_count{route="/b"}`:           1.0,
+		`duration_seconds_sum{route="/b"}`:             0.2,
+		`duration_seconds_bucket{route="/b",le="0.1"}`: 0.0,
+		`duration_seconds_bucket{route="/b",le="0.5"}`: 1.0,
+		`duration_seconds_bucket{route="/b",le="1"}`:   1.0,
+	}
+	got := values(r)
+	if len(got) != len(want) {
+		t.Errorf("Collect() = %v, want %v", got, want)
+	}
+	for service, w := range want {
+		v, ok := ToFloat(got[service])
+		if !ok || v < w.(float64)-1e-9 || v > w.(float64)+1e-9 {
+			t.Errorf("%s = %v, want %v", service, got[service], w)
+		}
+	}
+
+	for _, s := range r.Collect("pi", nil) {
+		if strings.HasPrefix(s.Service, "duration_seconds_bucket") && s.Labels["le"] == "" {
+			t.Errorf("%s has no le label", s.Service)
+		}
+		if s.Metric != MetricCounter {
+			t.Errorf("%s metric = %s, want %s", s.Service, s.Metric, MetricCounter)
+		}
+	}
+}
+
+func TestCollectLabels(t *testing.T) {
+	r := NewRegistry(map[string]string{"site": "vienna", "route": "instance"})
+	r.Counter("jobs_total", "Number of jobs", "route").Inc("/a")
+	r.Gauge("temperature", "Temperature").Set(21)
+
+	existing := []Model{{Service: "Uptime"}}
+	services := r.Collect("pi", existing)
+	if len(services) != 3 || services[0].Service != "Uptime" {
+		t.Fatalf("Collect() = %+v, want the existing service followed by the metrics", services)
+	}
+
+	tests := []struct {
+		service string
+		metric  MetricKind
+		labels  map[string]string
+	}{
+		// the labels of the series take precedence over the instance labels
+		{`jobs_total{route="/a"}`, MetricCounter, map[string]string{"site": "vienna", "route": "/a"}},
+		{"temperature", MetricGauge, map[string]string{"site": "vienna", "route": "instance"}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			s := services[i+1]
+			if s.Service != tt.service || s.Host != "pi" || s.State != StateOK || s.Metric != tt.metric || !maps.Equal(s.Labels, tt.labels) {
+				t.Errorf("service = %+v, want %s with metric %s and labels %v", s, tt.service, tt.metric, tt.labels)
+			}
+		})
+	}
+}
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		instance map[string]string
+		labels   map[string]string
+		want     map[string]string
+	}{
+		{"no instance labels", nil, map[string]string{"a": "1"}, map[string]string{"a": "1"}},
+		{"no labels", nil, nil, nil},
+		{"instance labels added", map[string]string{"site": "vienna"}, nil, map[string]string{"site": "vienna"}},
+		{"service labels take precedence", map[string]string{"site": "vienna", "a": "0"}, map[string]string{"a": "1"}, map[string]string{"site": "vienna", "a": "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			services := NewRegistry(tt.instance).Label([]Model{{Service: "Uptime", Labels: tt.labels}})
+			if got := services[0].Labels; !maps.Equal(got, tt.want) {
+				t.Errorf("Label() labels = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	r := NewRegistry(nil)
+	c := r.Counter("total", "help")
+	if r.Counter("total", "other help") != c {
+		t.Error("Counter() returned a new counter for a registered name")
+	}
+
+	tests := []struct {
+		name     string
+		register func()
+	}{
+		{"gauge", func() { r.Gauge("total", "help") }},
+		{"histogram", func() { r.Histogram("total", "help", []float64{1}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if p := recover(); p == nil {
+					t.Error("registering another type didn't panic")
+				}
+			}()
+			tt.register()
+		})
+	}
+}
+
+func TestLabelMismatch(t *testing.T) {
+	var log bytes.Buffer
+	def := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&log, nil)))
+	defer slog.SetDefault(def)
+
+	r := NewRegistry(nil)
+	c := r.Counter("requests_total", "help", "method")
+	g := r.Gauge("queue", "help", "name")
+
+	for range 3 {
+		c.Inc()
+		c.Inc("GET", "200")
+		g.Set(1)
+	}
+	c.Inc("GET")
+
+	want := map[string]any{`requests_total{method="GET"}`: 1.0}
+	if got := values(r); !maps.Equal(got, want) {
+		t.Errorf("Collect() = %v, want %v", got, want)
+	}
+
+	// the mismatch is logged once per metric
+	if n := strings.Count(log.String(), "metric=requests_total"); n != 1 {
+		t.Errorf("mismatch of requests_total logged %d times, want 1:\n%s", n, log.String())
+	}
+	if n := strings.Count(log.String(), "metric=queue"); n != 1 {
+		t.Errorf("mismatch of queue logged %d times, want 1:\n%s", n, log.String())
+	}
+}
@@ -2,6 +2,7 @@ package monitoring
 
 import (
 	"fmt"
+	"github.com/womat/go-api-template/app/service/runtimestats"
 	"runtime"
 	"strings"
 	"time"
@@ -15,149 +16,242 @@ type Model struct {
 	// Host is the host on which the service is running.
 	Host string `json:"Host"`
 
-	// State represents the current state of the service (e.g., "OK", "Error").
-	// If the state is empty, it will be determined based on WATCHIT thresholds.
-	State string `json:"State"`
+	// State represents the current state of the service (OK, Warning or Critical).
+	// The state of services with configured thresholds is evaluated by the Evaluator.
+	State State `json:"State"`
 
 	// Value holds the actual metric data. The type is `any` to accommodate various types of values.
 	// If empty, no statistic will be written in Odin.
 	Value any `json:"Value,omitempty"`
 
+	// Unit is the unit of Value (e.g., "bytes" or "seconds"), empty for dimensionless values.
+	Unit string `json:"Unit,omitempty"`
+
 	// Description provides a human-readable description of the service's status.
 	Description string `json:"Description"`
 
-	// Metric indicates the type of metric being reported (e.g., "gauge" or "counter").
+	// Metric indicates the type of metric being reported (gauge or counter).
 	// If empty, no statistic will be written in Odin.
-	Metric string `json:"Metric,omitempty"`
-}
+	Metric MetricKind `json:"Metric,omitempty"`
 
-// Constants representing supported metric types.
-const (
-	MetricGauge   = "gauge"   // A metric that represents a value that can go up or down.
-	MetricCounter = "counter" // A metric that only increases over time.
-)
+	// Labels are the labels of the metric and the static instance labels.
+	Labels map[string]string `json:"Labels,omitempty"`
+}
 
 var startTime = time.Now() // Tracks the application's start time.
 
-// Monitoring collects and returns monitoring data for various system metrics.
-func Monitoring(host, version string) ([]Model, error) {
+// UptimeService is the name of the entry reporting the uptime in seconds (v1: whole hours).
+const UptimeService = "Uptime"
 
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
+// Monitoring returns monitoring data for various system metrics.
+// The runtime values are taken from snap, the latest runtime statistics snapshot.
+func Monitoring(host, version string, snap *runtimestats.Snapshot) ([]Model, error) {
 
-	// Remove the port number from the host if present (e.g., "localhost:8080" -> "localhost").
-	h := strings.Split(host, ":")
-	host = h[0]
+	host = HostName(host)
+	uptime := time.Since(startTime)
 
 	// Create a slice of monitoring data for various system metrics.
 	services := []Model{
 		{
-			Service:     "Uptime",
+			Service:     UptimeService,
 			Host:        host,
-			State:       "OK",
-			Value:       time.Since(startTime).Truncate(time.Hour).Hours(),
-			Description: fmt.Sprintf("Uptime: %vh", time.Since(startTime).Truncate(time.Hour).Hours()),
+			State:       StateOK,
+			Value:       uptime.Seconds(),
+			Unit:        UnitSeconds,
+			Description: fmt.Sprintf("Uptime: %v", uptime.Truncate(time.Second)),
 			Metric:      MetricCounter},
 		{
 			Service:     "Version",
 			Host:        host,
-			State:       "OK",
+			State:       StateOK,
 			Description: version},
 		{
 			Service:     "Prog Lang",
 			Host:        host,
-			State:       "OK",
+			State:       StateOK,
 			Description: runtime.Version()},
 		{
 			Service:     "Operating System",
 			Host:        host,
-			State:       "OK",
+			State:       StateOK,
 			Description: runtime.GOOS},
 		{
 			Service:     "Number of Goroutines",
 			Host:        host,
-			State:       "OK",
-			Value:       runtime.NumGoroutine(),
-			Description: fmt.Sprintf("Number of Goroutines: %v", runtime.NumGoroutine()),
-			Metric:      MetricCounter},
+			State:       StateOK,
+			Value:       snap.Goroutines,
+			Unit:        UnitGoroutines,
+			Description: fmt.Sprintf("Number of Goroutines: %v", snap.Goroutines),
+			Metric:      MetricGauge},
 		{
 			Service:     "Number of Cgo Calls",
 			Host:        host,
-			State:       "OK",
-			Value:       runtime.NumCgoCall(),
-			Description: fmt.Sprintf("Number of Cgo Calls: %v", runtime.NumCgoCall()),
+			State:       StateOK,
+			Value:       snap.CgoCalls,
+			Unit:        UnitCalls,
+			Description: fmt.Sprintf("Number of Cgo Calls: %v", snap.CgoCalls),
 			Metric:      MetricCounter},
 		{
 			Service:     "Sys Memory",
 			Host:        host,
-			State:       "OK",
-			Value:       m.Sys,
-			Description: fmt.Sprintf("Sys Memory: %vkB", m.Sys/1024),
-			Metric:      MetricCounter},
+			State:       StateOK,
+			Value:       snap.Sys,
+			Unit:        UnitBytes,
+			Description: fmt.Sprintf("Sys Memory: %vkB", snap.Sys/1024),
+			Metric:      MetricGauge},
 		{
 			Service:     "Total Memory Alloc",
 			Host:        host,
-			State:       "OK",
-			Value:       m.TotalAlloc,
-			Description: fmt.Sprintf("Total Memory Alloc: %vkB", m.TotalAlloc/1024),
+			State:       StateOK,
+			Value:       snap.TotalAlloc,
+			Unit:        UnitBytes,
+			Description: fmt.Sprintf("Total Memory Alloc: %vkB", snap.TotalAlloc/1024),
 			Metric:      MetricCounter},
 		{
 			Service:     "Count of Heap Objects allocated",
 			Host:        host,
-			State:       "OK",
-			Value:       m.Mallocs,
-			Description: fmt.Sprintf("Mallocs: %v", m.Mallocs),
+			State:       StateOK,
+			Value:       snap.Mallocs,
+			Unit:        UnitObjects,
+			Description: fmt.Sprintf("Mallocs: %v", snap.Mallocs),
 			Metric:      MetricCounter},
 		{
 			Service:     "Free Count of Heap Objects",
 			Host:        host,
-			State:       "OK",
-			Value:       m.Frees,
-			Description: fmt.Sprintf("Frees: %v", m.Frees),
+			State:       StateOK,
+			Value:       snap.Frees,
+			Unit:        UnitObjects,
+			Description: fmt.Sprintf("Frees: %v", snap.Frees),
 			Metric:      MetricCounter},
 		{
 			Service:     "Heap Alloc",
 			Host:        host,
-			State:       "OK",
-			Value:       m.HeapAlloc,
-			Description: fmt.Sprintf("Heap Alloc: %vkB", m.HeapAlloc/1024),
-			Metric:      MetricCounter},
+			State:       StateOK,
+			Value:       snap.HeapAlloc,
+			Unit:        UnitBytes,
+			Description: fmt.Sprintf("Heap Alloc: %vkB", snap.HeapAlloc/1024),
+			Metric:      MetricGauge},
 		{
 			Service:     "Heap Sys",
 			Host:        host,
-			State:       "OK",
-			Value:       m.HeapSys,
-			Description: fmt.Sprintf("Heap Sys: %vkB", m.HeapSys/1024),
-			Metric:      MetricCounter},
+			State:       StateOK,
+			Value:       snap.HeapSys,
+			Unit:        UnitBytes,
+			Description: fmt.Sprintf("Heap Sys: %vkB", snap.HeapSys/1024),
+			Metric:      MetricGauge},
 		{
 			Service:     "Heap Idle",
 			Host:        host,
-			State:       "OK",
-			Value:       m.HeapIdle,
-			Description: fmt.Sprintf("Heap Idle: %vkB", m.HeapIdle/1024),
-			Metric:      MetricCounter},
+			State:       StateOK,
+			Value:       snap.HeapIdle,
+			Unit:        UnitBytes,
+			Description: fmt.Sprintf("Heap Idle: %vkB", snap.HeapIdle/1024),
+			Metric:      MetricGauge},
 		{
 			Service:     "Heap Inuse",
 			Host:        host,
-			State:       "OK",
-			Value:       m.HeapInuse,
-			Description: fmt.Sprintf("Heap Inuse: %vkB", m.HeapInuse/1024),
-			Metric:      MetricCounter},
+			State:       StateOK,
+			Value:       snap.HeapInuse,
+			Unit:        UnitBytes,
+			Description: fmt.Sprintf("Heap Inuse: %vkB", snap.HeapInuse/1024),
+			Metric:      MetricGauge},
 		{
 			Service:     "Heap Released",
 			Host:        host,
-			State:       "OK",
-			Value:       m.HeapReleased,
-			Description: fmt.Sprintf("Heap Released: %vkB", m.HeapReleased/1024),
-			Metric:      MetricCounter},
+			State:       StateOK,
+			Value:       snap.HeapReleased,
+			Unit:        UnitBytes,
+			Description: fmt.Sprintf("Heap Released: %vkB", snap.HeapReleased/1024),
+			Metric:      MetricGauge},
 		{
 			Service:     "Heap Objects",
 			Host:        host,
-			State:       "OK",
-			Value:       m.HeapObjects,
-			Description: fmt.Sprintf("Heap Objects: %v", m.HeapObjects),
+			State:       StateOK,
+			Value:       snap.HeapObjects,
+			Unit:        UnitObjects,
+			Description: fmt.Sprintf("Heap Objects: %v", snap.HeapObjects),
+			Metric:      MetricGauge},
+		{
+			Service:     "GC Cycles",
+			Host:        host,
+			State:       StateOK,
+			Value:       snap.GC.Cycles,
+			Unit:        UnitCycles,
+			Description: fmt.Sprintf("GC Cycles: %v", snap.GC.Cycles),
 			Metric:      MetricCounter},
+		{
+			Service:     "GC CPU Fraction",
+			Host:        host,
+			State:       StateOK,
+			Value:       snap.GC.CPUFraction * 100,
+			Unit:        UnitPercent,
+			Description: fmt.Sprintf("GC CPU Fraction: %.2f%%", snap.GC.CPUFraction*100),
+			Metric:      MetricGauge},
+		{
+			Service:     "GC Pause p50",
+			Host:        host,
+			State:       StateOK,
+			Value:       snap.GC.PauseP50Seconds,
+			Unit:        UnitSeconds,
+			Description: fmt.Sprintf("GC Pause p50: %v", seconds(snap.GC.PauseP50Seconds)),
+			Metric:      MetricGauge},
+		{
+			Service:     "GC Pause p90",
+			Host:        host,
+			State:       StateOK,
+			Value:       snap.GC.PauseP90Seconds,
+			Unit:        UnitSeconds,
+			Description: fmt.Sprintf("GC Pause p90: %v", seconds(snap.GC.PauseP90Seconds)),
+			Metric:      MetricGauge},
+		{
+			Service:     "GC Pause p99",
+			Host:        host,
+			State:       StateOK,
+			Value:       snap.GC.PauseP99Seconds,
+			Unit:        UnitSeconds,
+			Description: fmt.Sprintf("GC Pause p99: %v", seconds(snap.GC.PauseP99Seconds)),
+			Metric:      MetricGauge},
+		{
+			Service:     "GC Pause Max",
+			Host:        host,
+			State:       StateOK,
+			Value:       snap.GC.PauseMaxSeconds,
+			Unit:        UnitSeconds,
+			Description: fmt.Sprintf("GC Pause Max: %v", seconds(snap.GC.PauseMaxSeconds)),
+			Metric:      MetricGauge},
+		{
+			Service:     "GOMAXPROCS",
+			Host:        host,
+			State:       StateOK,
+			Value:       snap.GC.MaxProcs,
+			Unit:        UnitThreads,
+			Description: fmt.Sprintf("GOMAXPROCS: %v", snap.GC.MaxProcs),
+			Metric:      MetricGauge},
+		memoryLimit(host, snap.GC),
 	}
 
 	return services, nil
 }
+
+// memoryLimit returns the monitoring entry of the GOMEMLIMIT in effect, without value if unlimited.
+func memoryLimit(host string, gc runtimestats.GCStats) Model {
+	m := Model{Service: "GOMEMLIMIT", Host: host, State: StateOK, Description: "GOMEMLIMIT: unlimited"}
+	if !gc.Unlimited() {
+		m.Value = gc.MemoryLimitBytes
+		m.Unit = UnitBytes
+		m.Description = fmt.Sprintf("GOMEMLIMIT: %vkB", gc.MemoryLimitBytes/1024)
+		m.Metric = MetricGauge
+	}
+	return m
+}
+
+// seconds converts s seconds to a time.Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// HostName removes the port number from the host if present (e.g., "localhost:8080" -> "localhost").
+func HostName(host string) string {
+	h := strings.Split(host, ":")
+	return h[0]
+}
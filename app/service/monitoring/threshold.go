@@ -0,0 +1,122 @@
+package monitoring
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// OverallService is the name of the summary entry reporting the worst state of all services.
+const OverallService = "Overall State"
+
+// Threshold defines the warning and critical limits of a service value.
+// A state is entered if the value exceeds the limit and is left if the value drops below the limit minus the hysteresis.
+// The hysteresis prevents the state from flapping if the value oscillates around a limit.
+type Threshold struct {
+	// Warning is the limit above which the state is Warning, nil means no warning limit.
+	Warning *float64 `yaml:"warning"`
+
+	// Critical is the limit above which the state is Critical, nil means no critical limit.
+	Critical *float64 `yaml:"critical"`
+
+	// Hysteresis is the amount the value must drop below a limit to leave the state.
+	Hysteresis float64 `yaml:"hysteresis"`
+}
+
+// evaluate returns the state of value v, prev is the state of the previous evaluation.
+func (t Threshold) evaluate(v float64, prev State) State {
+	exceeds := func(limit *float64, active bool) bool {
+		if limit == nil {
+			return false
+		}
+		if active {
+			return v > *limit-t.Hysteresis
+		}
+		return v > *limit
+	}
+
+	switch {
+	case exceeds(t.Critical, prev == StateCritical):
+		return StateCritical
+	case exceeds(t.Warning, prev == StateWarning || prev == StateCritical):
+		return StateWarning
+	default:
+		return StateOK
+	}
+}
+
+// Evaluator evaluates the state of services against the configured thresholds.
+// It keeps the state of every service between evaluations to apply the hysteresis.
+type Evaluator struct {
+	mu         sync.Mutex
+	thresholds map[string]Threshold
+	states     map[string]State
+}
+
+// NewEvaluator returns a new Evaluator, the key of thresholds is the service name, e.g. "Number of Goroutines".
+func NewEvaluator(thresholds map[string]Threshold) *Evaluator {
+	return &Evaluator{thresholds: thresholds, states: map[string]State{}}
+}
+
+// Evaluate sets the state of every service with a threshold and a numeric value
+// and returns the response with the worst state of all services.
+// Services without threshold keep their state.
+func (e *Evaluator) Evaluate(host string, services []Model) Response {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var warnings, criticals []string
+	for i := range services {
+		s := &services[i]
+
+		if t, ok := e.thresholds[s.Service]; ok {
+			if v, ok := ToFloat(s.Value); ok {
+				s.State = t.evaluate(v, e.states[s.Service])
+				e.states[s.Service] = s.State
+			}
+		}
+
+		switch s.State {
+		case StateWarning:
+			warnings = append(warnings, s.Service)
+		case StateCritical:
+			criticals = append(criticals, s.Service)
+		}
+	}
+
+	resp := Response{Version: ResponseV2, Host: host, State: StateOK, Summary: "all services OK", Services: services}
+	switch {
+	case len(criticals) > 0:
+		resp.State = StateCritical
+		resp.Summary = fmt.Sprintf("critical: %s", strings.Join(criticals, ", "))
+		if len(warnings) > 0 {
+			resp.Summary += fmt.Sprintf("; warning: %s", strings.Join(warnings, ", "))
+		}
+	case len(warnings) > 0:
+		resp.State = StateWarning
+		resp.Summary = fmt.Sprintf("warning: %s", strings.Join(warnings, ", "))
+	}
+
+	return resp
+}
+
+// ToFloat converts numeric values to float64, it returns false if v is not numeric.
+func ToFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,139 @@
+package monitoring
+
+import (
+	"slices"
+	"testing"
+)
+
+func float(v float64) *float64 { return &v }
+
+func TestEvaluateHysteresis(t *testing.T) {
+	threshold := Threshold{Warning: float(80), Critical: float(90), Hysteresis: 5}
+
+	tests := []struct {
+		name   string
+		values []float64
+		want   []State
+	}{
+		{"below the limits", []float64{10, 79, 80},
+			[]State{StateOK, StateOK, StateOK}},
+		{"enter and leave warning", []float64{81, 78, 76, 75, 74},
+			[]State{StateWarning, StateWarning, StateWarning, StateOK, StateOK}},
+		{"warning entered again only above the limit", []float64{81, 74, 78, 80, 81},
+			[]State{StateWarning, StateOK, StateOK, StateOK, StateWarning}},
+		{"straight to critical and back to OK", []float64{95, 50},
+			[]State{StateCritical, StateOK}},
+		{"critical kept above limit minus hysteresis", []float64{91, 89, 86, 85, 84},
+			[]State{StateCritical, StateCritical, StateCritical, StateWarning, StateWarning}},
+		{"critical left to warning, warning hysteresis applies", []float64{91, 84, 76, 75},
+			[]State{StateCritical, StateWarning, StateWarning, StateOK}},
+		{"warning to critical", []float64{85, 88, 90, 91},
+			[]State{StateWarning, StateWarning, StateWarning, StateCritical}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEvaluator(map[string]Threshold{"CPU": threshold})
+
+			var got []State
+			for _, v := range tt.values {
+				resp := e.Evaluate("pi", []Model{{Service: "CPU", State: StateOK, Value: v}})
+				got = append(got, resp.Services[0].State)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("states of %v = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateWithoutHysteresis(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold Threshold
+		value     any
+		state     State
+		want      State
+	}{
+		{"warning only", Threshold{Warning: float(10)}, 11, StateOK, StateWarning},
+		{"critical only", Threshold{Critical: float(10)}, 11, StateOK, StateCritical},
+		{"equal to the limit", Threshold{Warning: float(10)}, 10.0, StateOK, StateOK},
+		{"no limits", Threshold{}, 100, StateCritical, StateOK},
+		{"integer types", Threshold{Warning: float(10)}, uint64(11), StateOK, StateWarning},
+		{"not numeric keeps the state", Threshold{Warning: float(10)}, "11", StateCritical, StateCritical},
+		{"no value keeps the state", Threshold{Warning: float(10)}, nil, StateWarning, StateWarning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEvaluator(map[string]Threshold{"CPU": tt.threshold})
+			resp := e.Evaluate("pi", []Model{{Service: "CPU", State: tt.state, Value: tt.value}})
+			if got := resp.Services[0].State; got != tt.want {
+				t.Errorf("state = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateSummary(t *testing.T) {
+	thresholds := map[string]Threshold{
+		"CPU":    {Warning: float(80), Critical: float(90)},
+		"Memory": {Warning: float(80), Critical: float(90)},
+	}
+
+	tests := []struct {
+		name        string
+		services    []Model
+		wantState   State
+		wantSummary string
+	}{
+		{
+			name:        "all OK",
+			services:    []Model{{Service: "CPU", Value: 10}, {Service: "Memory", Value: 10}, {Service: "Version", State: StateOK, Value: "1.0.0"}},
+			wantState:   StateOK,
+			wantSummary: "all services OK",
+		},
+		{
+			name:        "warning",
+			services:    []Model{{Service: "CPU", Value: 85}, {Service: "Memory", Value: 81}},
+			wantState:   StateWarning,
+			wantSummary: "warning: CPU, Memory",
+		},
+		{
+			name:        "critical and warning",
+			services:    []Model{{Service: "CPU", Value: 95}, {Service: "Memory", Value: 85}},
+			wantState:   StateCritical,
+			wantSummary: "critical: CPU; warning: Memory",
+		},
+		{
+			name:        "critical only",
+			services:    []Model{{Service: "CPU", Value: 95}, {Service: "Memory", Value: 10}},
+			wantState:   StateCritical,
+			wantSummary: "critical: CPU",
+		},
+		{
+			name:        "state of a service without threshold",
+			services:    []Model{{Service: "CPU", Value: 10}, {Service: "Disk", State: StateWarning}},
+			wantState:   StateWarning,
+			wantSummary: "warning: Disk",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewEvaluator(thresholds).Evaluate("pi", tt.services)
+			if resp.State != tt.wantState || resp.Summary != tt.wantSummary {
+				t.Errorf("Evaluate() = %s %q, want %s %q", resp.State, resp.Summary, tt.wantState, tt.wantSummary)
+			}
+			if resp.Version != ResponseV2 || resp.Host != "pi" {
+				t.Errorf("Evaluate() version and host = %d %s, want %d pi", resp.Version, resp.Host, ResponseV2)
+			}
+
+			// the summary is the overall state entry of the v1 format
+			overall := resp.Legacy()[0]
+			if overall.Service != OverallService || overall.State != tt.wantState || overall.Description != OverallService+": "+tt.wantSummary {
+				t.Errorf("overall state entry = %+v, want state %s and summary %q", overall, tt.wantState, tt.wantSummary)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// State is the state of a service.
+type State string
+
+// Constants representing the states of a service.
+const (
+	StateOK       State = "OK"
+	StateWarning  State = "Warning"
+	StateCritical State = "Critical"
+)
+
+// Valid returns true if s is a known state.
+func (s State) Valid() bool {
+	switch s {
+	case StateOK, StateWarning, StateCritical:
+		return true
+	}
+	return false
+}
+
+// Severity orders the states: OK=0, Warning=1, Critical=2.
+func (s State) Severity() int {
+	switch s {
+	case StateWarning:
+		return 1
+	case StateCritical:
+		return 2
+	}
+	return 0
+}
+
+// UnmarshalJSON decodes the state and returns an error if the state is unknown.
+func (s *State) UnmarshalJSON(b []byte) error {
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if !State(v).Valid() {
+		return fmt.Errorf("invalid state %q", v)
+	}
+	*s = State(v)
+	return nil
+}
+
+// MetricKind is the type of metric being reported.
+// An empty kind means the entry has no statistic value.
+type MetricKind string
+
+// Constants representing supported metric types.
+const (
+	MetricGauge   MetricKind = "gauge"   // A metric that represents a value that can go up or down.
+	MetricCounter MetricKind = "counter" // A metric that only increases over time.
+)
+
+// Valid returns true if k is a known metric kind or empty.
+func (k MetricKind) Valid() bool {
+	switch k {
+	case "", MetricGauge, MetricCounter:
+		return true
+	}
+	return false
+}
+
+// UnmarshalJSON decodes the metric kind and returns an error if the kind is unknown.
+func (k *MetricKind) UnmarshalJSON(b []byte) error {
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if !MetricKind(v).Valid() {
+		return fmt.Errorf("invalid metric kind %q", v)
+	}
+	*k = MetricKind(v)
+	return nil
+}
+
+// Constants representing the units of the values.
+const (
+	UnitBytes       = "bytes"
+	UnitSeconds     = "seconds"
+	UnitGoroutines  = "goroutines"
+	UnitCalls       = "calls"
+	UnitObjects     = "objects"
+	UnitPercent     = "percent"
+	UnitCelsius     = "celsius"
+	UnitPackets     = "packets"
+	UnitDescriptors = "descriptors"
+	UnitThreads     = "threads"
+	UnitSwitches    = "switches"
+	UnitCycles      = "cycles"
+)
+
+// Response versions of the monitoring endpoint.
+const (
+	// ResponseV1 is the WATCHIT compatible format: a list of entries, the first entry is the overall state.
+	ResponseV1 = 1
+
+	// ResponseV2 is a versioned object with the overall state and the list of entries.
+	ResponseV2 = 2
+)
+
+// Response is the versioned (v2) monitoring response.
+type Response struct {
+	// Version is the version of the response format.
+	Version int `json:"version"`
+
+	// Host is the host on which the services are running.
+	Host string `json:"host"`
+
+	// State is the worst state of all services.
+	State State `json:"state"`
+
+	// Summary describes the services which are not OK.
+	Summary string `json:"summary"`
+
+	// Services are the monitoring entries.
+	Services []Model `json:"services"`
+}
+
+// Legacy returns the WATCHIT compatible (v1) format of the response,
+// a list of entries with the overall state as first entry.
+// Like before the v2 format, the entries have no unit and labels and the uptime is reported in whole hours.
+func (r Response) Legacy() []Model {
+	summary := Model{
+		Service:     OverallService,
+		Host:        r.Host,
+		State:       r.State,
+		Description: fmt.Sprintf("%s: %s", OverallService, r.Summary),
+	}
+
+	services := make([]Model, 0, len(r.Services)+1)
+	services = append(services, summary)
+	for _, m := range r.Services {
+		services = append(services, m.legacy())
+	}
+	return services
+}
+
+// legacy returns the entry in the v1 format.
+func (m Model) legacy() Model {
+	m.Unit = ""
+	m.Labels = nil
+
+	if v, ok := ToFloat(m.Value); ok && m.Service == UptimeService {
+		hours := math.Floor(v / 3600)
+		m.Value = hours
+		m.Description = fmt.Sprintf("Uptime: %vh", hours)
+	}
+	return m
+}
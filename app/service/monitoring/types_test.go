@@ -0,0 +1,59 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLegacy(t *testing.T) {
+	resp := Response{
+		Version: ResponseV2,
+		Host:    "pi",
+		State:   StateWarning,
+		Summary: "Heap Alloc: 300MB",
+		Services: []Model{
+			{Service: UptimeService, Host: "pi", State: StateOK, Value: 7*3600 + 59*60.0, Unit: UnitSeconds, Description: "Uptime: 7h59m0s", Metric: MetricCounter},
+			{Service: "Heap Alloc", Host: "pi", State: StateWarning, Value: uint64(300 << 20), Unit: UnitBytes, Description: "Heap Alloc: 307200kB", Metric: MetricGauge, Labels: map[string]string{"site": "vienna"}},
+			{Service: "Version", Host: "pi", State: StateOK, Description: "1.0.0"},
+		},
+	}
+
+	legacy := resp.Legacy()
+	if len(legacy) != 4 {
+		t.Fatalf("Legacy() returned %d entries, want 4", len(legacy))
+	}
+
+	b, _ := json.Marshal(legacy)
+	want := `[` +
+		`{"Service":"Overall State","Host":"pi","State":"Warning","Description":"Overall State: Heap Alloc: 300MB"},` +
+		`{"Service":"Uptime","Host":"pi","State":"OK","Value":7,"Description":"Uptime: 7h","Metric":"counter"},` +
+		`{"Service":"Heap Alloc","Host":"pi","State":"Warning","Value":314572800,"Description":"Heap Alloc: 307200kB","Metric":"gauge"},` +
+		`{"Service":"Version","Host":"pi","State":"OK","Description":"1.0.0"}]`
+	if string(b) != want {
+		t.Fatalf("Legacy() =\n%s\nwant\n%s", b, want)
+	}
+
+	// the v2 response is not modified
+	if resp.Services[0].Value != 7*3600+59*60.0 || resp.Services[1].Labels == nil {
+		t.Fatalf("Legacy() modified the v2 response: %+v", resp.Services)
+	}
+}
+
+func TestUnmarshalValidation(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{`{"State":"OK","Metric":"gauge"}`, false},
+		{`{"State":"Critical"}`, false},
+		{`{"State":"Error"}`, true},
+		{`{"State":"OK","Metric":"histogram"}`, true},
+	}
+
+	for _, tt := range tests {
+		var m Model
+		if err := json.Unmarshal([]byte(tt.in), &m); (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package nagios
+
+import (
+	"fmt"
+	"github.com/womat/go-api-template/app/service/monitoring"
+	"strconv"
+	"strings"
+)
+
+// Status is the status of a Nagios/Icinga plugin, it's the exit code of the plugin.
+type Status int
+
+// Plugin status codes.
+const (
+	OK Status = iota
+	Warning
+	Critical
+	Unknown
+)
+
+// String returns the name of the status, e.g. WARNING.
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case Warning:
+		return "WARNING"
+	case Critical:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// StatusOf returns the plugin status of a monitoring state, unknown states are Unknown.
+func StatusOf(state monitoring.State) Status {
+	switch state {
+	case monitoring.StateOK:
+		return OK
+	case monitoring.StateWarning:
+		return Warning
+	case monitoring.StateCritical:
+		return Critical
+	default:
+		return Unknown
+	}
+}
+
+// Report returns the plugin output and the worst status of the services:
+//
+//	<name> <STATUS> - <summary> | <performance data>
+//	[<STATE>] <description of every service>
+//
+// thresholds are added as warning and critical limits to the performance data, the key is the service name.
+// The status is Unknown if there are no services.
+func Report(name string, services []monitoring.Model, thresholds map[string]monitoring.Threshold) (string, Status) {
+	if len(services) == 0 {
+		return Fail(name, "no matching services")
+	}
+
+	status := OK
+	var problems, perfdata, details []string
+	for _, s := range services {
+		st := StatusOf(s.State)
+		if st > status {
+			status = st
+		}
+		if st != OK {
+			problems = append(problems, s.Description)
+		}
+		if p := Perfdata(s, thresholds[s.Service]); p != "" {
+			perfdata = append(perfdata, p)
+		}
+		details = append(details, fmt.Sprintf("[%s] %s", st, s.Description))
+	}
+
+	var summary string
+	switch {
+	case len(problems) > 0:
+		summary = strings.Join(problems, ", ")
+	case len(services) == 1:
+		summary = services[0].Description
+	default:
+		summary = fmt.Sprintf("all %d services OK", len(services))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s - %s", name, status, oneLine(summary))
+	if len(perfdata) > 0 {
+		b.WriteString(" | " + strings.Join(perfdata, " "))
+	}
+	for _, d := range details {
+		b.WriteString("\n" + oneLine(d))
+	}
+	return b.String(), status
+}
+
+// Fail returns the plugin output and the status Unknown, e.g. if the instance can't be queried.
+func Fail(name string, reason string) (string, Status) {
+	return fmt.Sprintf("%s %s - %s", name, Unknown, oneLine(reason)), Unknown
+}
+
+// Perfdata returns the performance data of a service: 'label'=value[UOM];[warn];[crit]
+// It's empty if the value of the service isn't numeric.
+func Perfdata(s monitoring.Model, t monitoring.Threshold) string {
+	v, ok := monitoring.ToFloat(s.Value)
+	if !ok {
+		return ""
+	}
+
+	limit := func(l *float64) string {
+		if l == nil {
+			return ""
+		}
+		return formatFloat(*l)
+	}
+
+	// single quotes in labels are escaped by doubling them, = isn't allowed
+	label := strings.ReplaceAll(strings.ReplaceAll(s.Service, "'", "''"), "=", "_")
+	return strings.TrimRight(fmt.Sprintf("'%s'=%s%s;%s;%s", label, formatFloat(v), uom(s), limit(t.Warning), limit(t.Critical)), ";")
+}
+
+// uom returns the unit of measurement of the performance data.
+func uom(s monitoring.Model) string {
+	switch s.Unit {
+	case monitoring.UnitSeconds:
+		return "s"
+	case monitoring.UnitPercent:
+		return "%"
+	case monitoring.UnitBytes:
+		return "B"
+	}
+	if s.Metric == monitoring.MetricCounter {
+		return "c"
+	}
+	return ""
+}
+
+// formatFloat formats v without exponent and trailing zeros.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// oneLine replaces line breaks and the perfdata separator, they would break the plugin output.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ", "|", "/").Replace(s)
+}
@@ -0,0 +1,187 @@
+package nagios
+
+import (
+	"github.com/womat/go-api-template/app/service/monitoring"
+	"testing"
+)
+
+func limit(v float64) *float64 { return &v }
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		state monitoring.State
+		want  Status
+	}{
+		{monitoring.StateOK, OK},
+		{monitoring.StateWarning, Warning},
+		{monitoring.StateCritical, Critical},
+		{"", Unknown},
+		{"Maintenance", Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.state), func(t *testing.T) {
+			if got := StatusOf(tt.state); got != tt.want {
+				t.Errorf("StatusOf(%q) = %v, want %v", tt.state, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusExitCode(t *testing.T) {
+	tests := []struct {
+		status Status
+		name   string
+		code   int
+	}{
+		{OK, "OK", 0},
+		{Warning, "WARNING", 1},
+		{Critical, "CRITICAL", 2},
+		{Unknown, "UNKNOWN", 3},
+		{Status(9), "UNKNOWN", 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.status.String(); got != tt.name {
+				t.Errorf("String() = %q, want %q", got, tt.name)
+			}
+			if int(tt.status) != tt.code {
+				t.Errorf("exit code = %d, want %d", tt.status, tt.code)
+			}
+		})
+	}
+}
+
+func TestPerfdata(t *testing.T) {
+	tests := []struct {
+		name      string
+		service   monitoring.Model
+		threshold monitoring.Threshold
+		want      string
+	}{
+		{"integer", monitoring.Model{Service: "Number of Goroutines", Value: 12}, monitoring.Threshold{},
+			"'Number of Goroutines'=12"},
+		{"float without trailing zeros", monitoring.Model{Service: "Load", Value: 0.50}, monitoring.Threshold{},
+			"'Load'=0.5"},
+		{"large value without exponent", monitoring.Model{Service: "Heap", Value: uint64(1e12), Unit: monitoring.UnitBytes}, monitoring.Threshold{},
+			"'Heap'=1000000000000B"},
+		{"seconds", monitoring.Model{Service: "Uptime", Value: 3600.0, Unit: monitoring.UnitSeconds}, monitoring.Threshold{},
+			"'Uptime'=3600s"},
+		{"percent", monitoring.Model{Service: "CPU", Value: 42.5, Unit: monitoring.UnitPercent}, monitoring.Threshold{},
+			"'CPU'=42.5%"},
+		{"counter", monitoring.Model{Service: "GC Runs", Value: int64(7), Metric: monitoring.MetricCounter}, monitoring.Threshold{},
+			"'GC Runs'=7c"},
+		{"unit takes precedence over counter", monitoring.Model{Service: "GC Pause", Value: 0.25, Unit: monitoring.UnitSeconds, Metric: monitoring.MetricCounter}, monitoring.Threshold{},
+			"'GC Pause'=0.25s"},
+		{"unit without uom", monitoring.Model{Service: "Temp", Value: 48.3, Unit: monitoring.UnitCelsius}, monitoring.Threshold{},
+			"'Temp'=48.3"},
+		{"warning and critical", monitoring.Model{Service: "CPU", Value: 42, Unit: monitoring.UnitPercent}, monitoring.Threshold{Warning: limit(80), Critical: limit(95.5)},
+			"'CPU'=42%;80;95.5"},
+		{"warning only", monitoring.Model{Service: "CPU", Value: 42}, monitoring.Threshold{Warning: limit(80)},
+			"'CPU'=42;80"},
+		{"critical only", monitoring.Model{Service: "CPU", Value: 42}, monitoring.Threshold{Critical: limit(95)},
+			"'CPU'=42;;95"},
+		{"label is escaped", monitoring.Model{Service: "it's a=b", Value: 1}, monitoring.Threshold{},
+			"'it''s a_b'=1"},
+		{"not numeric", monitoring.Model{Service: "Version", Value: "1.0.0"}, monitoring.Threshold{}, ""},
+		{"no value", monitoring.Model{Service: "Overall State"}, monitoring.Threshold{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Perfdata(tt.service, tt.threshold); got != tt.want {
+				t.Errorf("Perfdata() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	cpu := monitoring.Model{Service: "CPU", State: monitoring.StateOK, Value: 10.0, Unit: monitoring.UnitPercent, Description: "CPU 10%"}
+	mem := monitoring.Model{Service: "Memory", State: monitoring.StateWarning, Value: 85.0, Unit: monitoring.UnitPercent, Description: "Memory 85%"}
+	disk := monitoring.Model{Service: "Disk", State: monitoring.StateCritical, Value: 99.0, Unit: monitoring.UnitPercent, Description: "Disk 99%"}
+	version := monitoring.Model{Service: "Version", State: monitoring.StateOK, Value: "1.0.0", Description: "Version 1.0.0"}
+	odd := monitoring.Model{Service: "Odd", State: "Maintenance", Description: "in maintenance"}
+
+	tests := []struct {
+		name       string
+		services   []monitoring.Model
+		thresholds map[string]monitoring.Threshold
+		want       string
+		wantStatus Status
+	}{
+		{
+			name:       "no services",
+			want:       "APP UNKNOWN - no matching services",
+			wantStatus: Unknown,
+		},
+		{
+			name:       "single service",
+			services:   []monitoring.Model{cpu},
+			want:       "APP OK - CPU 10% | 'CPU'=10%\n[OK] CPU 10%",
+			wantStatus: OK,
+		},
+		{
+			name:       "all services OK",
+			services:   []monitoring.Model{cpu, version},
+			want:       "APP OK - all 2 services OK | 'CPU'=10%\n[OK] CPU 10%\n[OK] Version 1.0.0",
+			wantStatus: OK,
+		},
+		{
+			name:       "without performance data",
+			services:   []monitoring.Model{version},
+			want:       "APP OK - Version 1.0.0\n[OK] Version 1.0.0",
+			wantStatus: OK,
+		},
+		{
+			name:       "warning",
+			services:   []monitoring.Model{cpu, mem},
+			thresholds: map[string]monitoring.Threshold{"Memory": {Warning: limit(80), Critical: limit(90)}},
+			want:       "APP WARNING - Memory 85% | 'CPU'=10% 'Memory'=85%;80;90\n[OK] CPU 10%\n[WARNING] Memory 85%",
+			wantStatus: Warning,
+		},
+		{
+			name:       "worst status wins",
+			services:   []monitoring.Model{disk, mem, cpu},
+			want:       "APP CRITICAL - Disk 99%, Memory 85% | 'Disk'=99% 'Memory'=85% 'CPU'=10%\n[CRITICAL] Disk 99%\n[WARNING] Memory 85%\n[OK] CPU 10%",
+			wantStatus: Critical,
+		},
+		{
+			name:       "unknown state",
+			services:   []monitoring.Model{disk, odd},
+			want:       "APP UNKNOWN - Disk 99%, in maintenance | 'Disk'=99%\n[CRITICAL] Disk 99%\n[UNKNOWN] in maintenance",
+			wantStatus: Unknown,
+		},
+		{
+			name: "line breaks and pipes are replaced",
+			services: []monitoring.Model{
+				{Service: "Log", State: monitoring.StateWarning, Description: "a|b\r\nc"},
+			},
+			want:       "APP WARNING - a/b  c\n[WARNING] a/b  c",
+			wantStatus: Warning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, status := Report("APP", tt.services, tt.thresholds)
+			if got != tt.want {
+				t.Errorf("Report() = %q, want %q", got, tt.want)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("Report() status = %v, want %v", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestFail(t *testing.T) {
+	got, status := Fail("APP", "connection refused\n| retry")
+	if want := "APP UNKNOWN - connection refused / retry"; got != want {
+		t.Errorf("Fail() = %q, want %q", got, want)
+	}
+	if status != Unknown {
+		t.Errorf("Fail() status = %v, want %v", status, Unknown)
+	}
+}
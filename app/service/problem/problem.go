@@ -0,0 +1,199 @@
+package problem
+
+import (
+	"strings"
+	"time"
+)
+
+// Kind is the category of an application error, it determines the http status and the problem type.
+type Kind int
+
+// Supported error kinds.
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindUnauthorized
+	KindForbidden
+	KindRateLimited
+	KindUnavailable
+)
+
+// kindInfo is the name, title and http status of a kind.
+type kindInfo struct {
+	name   string
+	title  string
+	status int
+}
+
+// kinds maps the error kinds to their name, title and http status.
+var kinds = map[Kind]kindInfo{
+	KindInternal:     {"internal", "Internal Server Error", 500},
+	KindValidation:   {"validation", "Validation Failed", 400},
+	KindNotFound:     {"not-found", "Not Found", 404},
+	KindConflict:     {"conflict", "Conflict", 409},
+	KindUnauthorized: {"unauthorized", "Unauthorized", 401},
+	KindForbidden:    {"forbidden", "Forbidden", 403},
+	KindRateLimited:  {"rate-limited", "Too Many Requests", 429},
+	KindUnavailable:  {"unavailable", "Service Unavailable", 503},
+}
+
+// info returns the name, title and status of the kind, an unknown kind is handled as KindInternal.
+// Otherwise, the zero status would make http.ResponseWriter.WriteHeader panic.
+func (k Kind) info() kindInfo {
+	if i, ok := kinds[k]; ok {
+		return i
+	}
+	return kinds[KindInternal]
+}
+
+// String returns the name of the kind, e.g. not-found.
+func (k Kind) String() string {
+	return k.info().name
+}
+
+// Title returns the short, human-readable summary of the kind.
+func (k Kind) Title() string {
+	return k.info().title
+}
+
+// Status returns the http status code of the kind.
+func (k Kind) Status() int {
+	return k.info().status
+}
+
+// Type returns the problem type URI of the kind, e.g. /problems/not-found.
+func (k Kind) Type() string {
+	return "/problems/" + k.String()
+}
+
+// Sentinel errors to check the kind of an error with errors.Is.
+var (
+	ErrInternal     = &Error{Kind: KindInternal}
+	ErrValidation   = &Error{Kind: KindValidation}
+	ErrNotFound     = &Error{Kind: KindNotFound}
+	ErrConflict     = &Error{Kind: KindConflict}
+	ErrUnauthorized = &Error{Kind: KindUnauthorized}
+	ErrForbidden    = &Error{Kind: KindForbidden}
+	ErrRateLimited  = &Error{Kind: KindRateLimited}
+	ErrUnavailable  = &Error{Kind: KindUnavailable}
+)
+
+// FieldError is the validation error of a request field (e.g. a query parameter or a json property).
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an application error, it's written as problem details (RFC 7807) or as web.ApiError by Write.
+//
+//	if !found {
+//		problem.Write(w, r, problem.NotFound(fmt.Errorf("job %q not found", name)))
+//	}
+type Error struct {
+	// Kind is the category of the error.
+	Kind Kind
+
+	// Detail is the human-readable explanation of the error, default is the message of Err.
+	Detail string
+
+	// Fields are the field-level validation errors.
+	Fields []FieldError
+
+	// RetryAfter is the time after which the client may retry, it's sent in the Retry-After header.
+	RetryAfter time.Duration
+
+	// Err is the underlying error.
+	Err error
+}
+
+// New returns an error of the given kind wrapping err.
+func New(kind Kind, err error) *Error {
+	return &Error{Kind: kind, Err: err}
+}
+
+// Internal returns an internal error wrapping err.
+func Internal(err error) *Error {
+	return New(KindInternal, err)
+}
+
+// Validation returns a validation error with the field-level details.
+func Validation(detail string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Detail: detail, Fields: fields}
+}
+
+// Field returns the validation error of a field.
+func Field(field, message string) FieldError {
+	return FieldError{Field: field, Message: message}
+}
+
+// NotFound returns a not-found error wrapping err.
+func NotFound(err error) *Error {
+	return New(KindNotFound, err)
+}
+
+// Conflict returns a conflict error wrapping err.
+func Conflict(err error) *Error {
+	return New(KindConflict, err)
+}
+
+// Unauthorized returns an unauthorized error (missing or invalid credentials) wrapping err.
+func Unauthorized(err error) *Error {
+	return New(KindUnauthorized, err)
+}
+
+// Forbidden returns a forbidden error (insufficient permissions) wrapping err.
+func Forbidden(err error) *Error {
+	return New(KindForbidden, err)
+}
+
+// RateLimited returns a rate-limited error wrapping err, the client may retry after retryAfter (0 if unknown).
+func RateLimited(err error, retryAfter time.Duration) *Error {
+	return &Error{Kind: KindRateLimited, Err: err, RetryAfter: retryAfter}
+}
+
+// Unavailable returns a service unavailable error wrapping err.
+func Unavailable(err error) *Error {
+	return New(KindUnavailable, err)
+}
+
+// Error returns the detail followed by the field errors, e.g. "invalid parameter: from: expected RFC3339 time".
+func (e *Error) Error() string {
+	msg := e.detail()
+	if len(e.Fields) == 0 {
+		return msg
+	}
+
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Field + ": " + f.Message
+	}
+	return msg + ": " + strings.Join(messages, "; ")
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel error of the kind of e, e.g. errors.Is(err, problem.ErrNotFound).
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Detail == "" && t.Err == nil && len(t.Fields) == 0 && t.Kind == e.Kind
+}
+
+// detail returns the detail, the message of the underlying error or the title of the kind.
+func (e *Error) detail() string {
+	switch {
+	case e.Detail != "":
+		return e.Detail
+	case e.Err != nil:
+		return e.Err.Error()
+	default:
+		return e.Kind.Title()
+	}
+}
@@ -0,0 +1,69 @@
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        *Error
+		wantMsg    string
+		wantStatus int
+		wantType   string
+	}{
+		{"internal", Internal(errors.New("db down")), "db down", 500, "/problems/internal"},
+		{"not found", NotFound(errors.New(`job "x" not found`)), `job "x" not found`, 404, "/problems/not-found"},
+		{"validation with fields", Validation("invalid parameter", Field("from", "expected RFC3339 time"), Field("step", "too small")),
+			"invalid parameter: from: expected RFC3339 time; step: too small", 400, "/problems/validation"},
+		{"detail overrides the error", &Error{Kind: KindConflict, Detail: "busy", Err: errors.New("locked")}, "busy", 409, "/problems/conflict"},
+		{"title without detail", &Error{Kind: KindUnavailable}, "Service Unavailable", 503, "/problems/unavailable"},
+		{"unauthorized", Unauthorized(errors.New("missing api key")), "missing api key", 401, "/problems/unauthorized"},
+		{"forbidden", Forbidden(errors.New("admin required")), "admin required", 403, "/problems/forbidden"},
+		{"rate limited", RateLimited(errors.New("slow down"), 0), "slow down", 429, "/problems/rate-limited"},
+		{"unknown kind is internal", &Error{Kind: Kind(99), Err: errors.New("boom")}, "boom", 500, "/problems/internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.wantMsg {
+				t.Errorf("Error() = %q, want %q", got, tt.wantMsg)
+			}
+			if got := tt.err.Kind.Status(); got != tt.wantStatus {
+				t.Errorf("Status() = %d, want %d", got, tt.wantStatus)
+			}
+			if got := tt.err.Kind.Type(); got != tt.wantType {
+				t.Errorf("Type() = %q, want %q", got, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	cause := errors.New("db down")
+	wrapped := fmt.Errorf("query: %w", Unavailable(cause))
+
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{"same kind", NotFound(errors.New("x")), ErrNotFound, true},
+		{"other kind", NotFound(errors.New("x")), ErrConflict, false},
+		{"wrapped", wrapped, ErrUnavailable, true},
+		{"underlying error", wrapped, cause, true},
+		{"not a sentinel", NotFound(errors.New("x")), NotFound(errors.New("x")), false},
+		{"plain error", errors.New("x"), ErrInternal, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Errorf("errors.Is() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/womat/go-api-template/app/service/requestid"
+	"github.com/womat/golib/web"
+	"log/slog"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// Format is the format of error responses.
+type Format string
+
+// Supported error response formats.
+const (
+	// FormatLegacy is the web.ApiError format {"error": "..."} of the existing clients.
+	FormatLegacy Format = "legacy"
+
+	// FormatProblem is the problem details format (RFC 7807) with the content type application/problem+json.
+	FormatProblem Format = "problem"
+)
+
+// Problem is the problem details (RFC 7807) response body.
+type Problem struct {
+	// Type is a URI reference identifying the problem type, e.g. /problems/not-found.
+	Type string `json:"type"`
+
+	// Title is a short, human-readable summary of the problem type.
+	Title string `json:"title"`
+
+	// Status is the http status code.
+	Status int `json:"status"`
+
+	// Detail is the human-readable explanation of this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// Instance is the path of the request.
+	Instance string `json:"instance,omitempty"`
+
+	// RequestID is the id of the request, it correlates the response with the log records.
+	RequestID string `json:"requestId,omitempty"`
+
+	// Errors are the field-level validation errors.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// formatKey is the context key of the default error response format.
+type formatKey struct{}
+
+// WithFormat is a middleware that sets the error response format used if the client doesn't ask for problem details.
+func WithFormat(h http.Handler, def Format) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), formatKey{}, def)))
+		},
+	)
+}
+
+// Negotiate returns the error response format of the request.
+// Problem details are returned if the Accept header lists application/problem+json,
+// otherwise the default format set by WithFormat (legacy if not set).
+func Negotiate(r *http.Request) Format {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || mediaType != ContentType {
+				continue
+			}
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+				continue
+			}
+			return FormatProblem
+		}
+	}
+
+	if f, ok := r.Context().Value(formatKey{}).(Format); ok && f != "" {
+		return f
+	}
+	return FormatLegacy
+}
+
+// Write writes the error response in the negotiated format.
+// Errors which aren't of type *Error are written as internal errors.
+// Server errors are logged as error, client errors as debug.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = Internal(err)
+	}
+	status := e.Kind.Status()
+
+	level := slog.LevelDebug
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	slog.Log(r.Context(), level, "API error",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"status", status,
+		"error", err)
+
+	if e.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+
+	if Negotiate(r) != FormatProblem {
+		web.Encode(w, status, web.NewApiError(e))
+		return
+	}
+
+	b, err := json.Marshal(Problem{
+		Type:      e.Kind.Type(),
+		Title:     e.Kind.Title(),
+		Status:    status,
+		Detail:    e.detail(),
+		Instance:  r.URL.Path,
+		RequestID: requestid.FromContext(r.Context()),
+		Errors:    e.Fields,
+	})
+	if err != nil {
+		web.Encode(w, http.StatusInternalServerError, web.NewApiError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
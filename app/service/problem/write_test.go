@@ -0,0 +1,165 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/womat/go-api-template/app/service/requestid"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept []string
+		def    Format
+		want   Format
+	}{
+		{"no accept header", nil, "", FormatLegacy},
+		{"json", []string{"application/json"}, "", FormatLegacy},
+		{"problem", []string{"application/problem+json"}, "", FormatProblem},
+		{"problem in a list", []string{"application/json, application/problem+json;q=0.9"}, "", FormatProblem},
+		{"problem in a second header", []string{"text/html", "application/problem+json"}, "", FormatProblem},
+		{"problem with q=0", []string{"application/problem+json;q=0"}, "", FormatLegacy},
+		{"invalid media type", []string{"application/problem+json;;="}, "", FormatLegacy},
+		{"wildcard", []string{"*/*"}, "", FormatLegacy},
+		{"default format", []string{"application/json"}, FormatProblem, FormatProblem},
+		{"explicit problem overrides the default", []string{"application/problem+json"}, FormatLegacy, FormatProblem},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Format
+			var h http.Handler = http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) { got = Negotiate(r) })
+			if tt.def != "" {
+				h = WithFormat(h, tt.def)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/api/jobs", nil)
+			for _, a := range tt.accept {
+				r.Header.Add("Accept", a)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != tt.want {
+				t.Errorf("Negotiate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		accept          string
+		wantStatus      int
+		wantContentType string
+		wantRetryAfter  string
+		wantBody        any
+	}{
+		{
+			name:            "legacy",
+			err:             NotFound(errors.New(`job "x" not found`)),
+			wantStatus:      http.StatusNotFound,
+			wantContentType: "application/json",
+			wantBody:        map[string]any{"error": `job "x" not found`},
+		},
+		{
+			name:            "legacy validation",
+			err:             Validation("invalid parameter", Field("seconds", "expected a positive integer")),
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: "application/json",
+			wantBody:        map[string]any{"error": "invalid parameter: seconds: expected a positive integer"},
+		},
+		{
+			name:            "plain error is internal",
+			err:             errors.New("boom"),
+			accept:          ContentType,
+			wantStatus:      http.StatusInternalServerError,
+			wantContentType: ContentType,
+			wantBody: map[string]any{
+				"type": "/problems/internal", "title": "Internal Server Error", "status": 500.0,
+				"detail": "boom", "instance": "/api/jobs/x/run", "requestId": "req-1",
+			},
+		},
+		{
+			name:            "unknown kind is internal",
+			err:             &Error{Kind: Kind(99), Err: errors.New("boom")},
+			accept:          ContentType,
+			wantStatus:      http.StatusInternalServerError,
+			wantContentType: ContentType,
+			wantBody: map[string]any{
+				"type": "/problems/internal", "title": "Internal Server Error", "status": 500.0,
+				"detail": "boom", "instance": "/api/jobs/x/run", "requestId": "req-1",
+			},
+		},
+		{
+			name:            "problem validation",
+			err:             fmt.Errorf("parse: %w", Validation("invalid parameter", Field("from", "expected RFC3339 time"))),
+			accept:          ContentType,
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: ContentType,
+			wantBody: map[string]any{
+				"type": "/problems/validation", "title": "Validation Failed", "status": 400.0,
+				"detail": "invalid parameter", "instance": "/api/jobs/x/run", "requestId": "req-1",
+				"errors": []any{map[string]any{"field": "from", "message": "expected RFC3339 time"}},
+			},
+		},
+		{
+			name:            "retry after is rounded up",
+			err:             RateLimited(errors.New("too many requests"), 1500*time.Millisecond),
+			accept:          ContentType,
+			wantStatus:      http.StatusTooManyRequests,
+			wantContentType: ContentType,
+			wantRetryAfter:  "2",
+			wantBody: map[string]any{
+				"type": "/problems/rate-limited", "title": "Too Many Requests", "status": 429.0,
+				"detail": "too many requests", "instance": "/api/jobs/x/run", "requestId": "req-1",
+			},
+		},
+		{
+			name:            "legacy retry after",
+			err:             RateLimited(errors.New("too many requests"), 3*time.Second),
+			wantStatus:      http.StatusTooManyRequests,
+			wantContentType: "application/json",
+			wantRetryAfter:  "3",
+			wantBody:        map[string]any{"error": "too many requests"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/jobs/x/run", nil)
+			r = r.WithContext(requestid.WithRequestID(r.Context(), "req-1"))
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			Write(w, r, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+
+			var body any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid body %q: %v", w.Body, err)
+			}
+			if !reflect.DeepEqual(body, tt.wantBody) {
+				t.Errorf("body = %v, want %v", body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package process
+
+import (
+	"fmt"
+	"github.com/womat/go-api-template/app/service/monitoring"
+	"time"
+)
+
+// Monitoring returns the monitoring entries of the process stats.
+func Monitoring(host string, s *Stats) []monitoring.Model {
+	gauge := func(service string, value any, unit, desc string) monitoring.Model {
+		return monitoring.Model{Service: service, Host: host, State: monitoring.StateOK, Value: value, Unit: unit, Description: desc, Metric: monitoring.MetricGauge}
+	}
+	counter := func(service string, value any, unit, desc string) monitoring.Model {
+		return monitoring.Model{Service: service, Host: host, State: monitoring.StateOK, Value: value, Unit: unit, Description: desc, Metric: monitoring.MetricCounter}
+	}
+
+	maxFDs := "unlimited"
+	if s.MaxFDs > 0 {
+		maxFDs = fmt.Sprint(s.MaxFDs)
+	}
+
+	return []monitoring.Model{
+		{Service: "Process Start Time", Host: host, State: monitoring.StateOK, Description: s.StartTime.Format(time.RFC3339)},
+		gauge("Process RSS", s.RSSBytes, monitoring.UnitBytes, fmt.Sprintf("Process RSS: %vkB", s.RSSBytes/1024)),
+		gauge("Open File Descriptors", s.OpenFDs, monitoring.UnitDescriptors, fmt.Sprintf("Open File Descriptors: %v of %v", s.OpenFDs, maxFDs)),
+		gauge("File Descriptor Usage", s.FDUsagePercent(), monitoring.UnitPercent, fmt.Sprintf("File Descriptor Usage: %.1f%%", s.FDUsagePercent())),
+		gauge("Number of Threads", s.Threads, monitoring.UnitThreads, fmt.Sprintf("Number of Threads: %v", s.Threads)),
+		counter("Voluntary Context Switches", s.VoluntaryCtxSwitches, monitoring.UnitSwitches,
+			fmt.Sprintf("Voluntary Context Switches: %v", s.VoluntaryCtxSwitches)),
+		counter("Involuntary Context Switches", s.InvoluntaryCtxSwitches, monitoring.UnitSwitches,
+			fmt.Sprintf("Involuntary Context Switches: %v", s.InvoluntaryCtxSwitches)),
+	}
+}
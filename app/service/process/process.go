@@ -0,0 +1,219 @@
+package process
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stats holds the process-level health data.
+type Stats struct {
+	// Time is the time the stats were read.
+	Time time.Time `json:"Time"`
+
+	// PID is the process id.
+	PID int `json:"PID"`
+
+	// StartTime is the time the process was started.
+	StartTime time.Time `json:"StartTime"`
+
+	// RSSBytes is the resident set size, the physical memory used by the process.
+	RSSBytes uint64 `json:"RSSBytes"`
+
+	// OpenFDs is the number of open file descriptors.
+	OpenFDs uint64 `json:"OpenFDs"`
+
+	// MaxFDs is the soft limit of open file descriptors, 0 if unlimited.
+	MaxFDs uint64 `json:"MaxFDs"`
+
+	// Threads is the number of OS threads.
+	Threads uint64 `json:"Threads"`
+
+	// VoluntaryCtxSwitches is the number of voluntary context switches (e.g. waiting for I/O).
+	VoluntaryCtxSwitches uint64 `json:"VoluntaryCtxSwitches"`
+
+	// InvoluntaryCtxSwitches is the number of involuntary context switches (preempted by the kernel).
+	InvoluntaryCtxSwitches uint64 `json:"InvoluntaryCtxSwitches"`
+
+	// Errors lists the sources which couldn't be read.
+	Errors []string `json:"Errors,omitempty"`
+}
+
+// FDUsagePercent returns the open file descriptors in percent of the limit, 0 if unlimited.
+func (s *Stats) FDUsagePercent() float64 {
+	if s.MaxFDs == 0 {
+		return 0
+	}
+	return float64(s.OpenFDs) / float64(s.MaxFDs) * 100
+}
+
+// clockTicks is the number of clock ticks per second (USER_HZ) used in proc/<pid>/stat,
+// it's 100 on all supported linux architectures.
+const clockTicks = 100
+
+// Reader reads the process stats from the proc file system.
+type Reader struct {
+	fsys fs.FS
+	pid  string
+}
+
+// NewReader returns a Reader for the current process.
+func NewReader() *Reader {
+	return NewFSReader(os.DirFS("/proc"), "self")
+}
+
+// NewFSReader returns a Reader for the given proc file system, e.g. a directory with fixture files.
+// fsys must contain the stat file and the <pid>/stat, <pid>/status, <pid>/limits and <pid>/fd entries.
+func NewFSReader(fsys fs.FS, pid string) *Reader {
+	return &Reader{fsys: fsys, pid: pid}
+}
+
+// Read returns the current process stats. Sources which can't be read are listed in Stats.Errors.
+func (r *Reader) Read() *Stats {
+	s := &Stats{Time: time.Now()}
+
+	collect := func(name string, err error) {
+		if err != nil {
+			s.Errors = append(s.Errors, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	collect("stat", r.readStat(s))
+	collect("status", r.readStatus(s))
+	collect("limits", r.readLimits(s))
+	collect("fd", r.readFDs(s))
+
+	return s
+}
+
+// readStat reads the pid and the start time from <pid>/stat and the boot time from stat.
+func (r *Reader) readStat(s *Stats) error {
+	b, err := fs.ReadFile(r.fsys, r.pid+"/stat")
+	if err != nil {
+		return err
+	}
+
+	// the command name in parentheses may contain spaces
+	pid, _, _ := strings.Cut(string(b), " ")
+	i := strings.LastIndexByte(string(b), ')')
+	if i < 0 {
+		return errors.New("unexpected format")
+	}
+	// fields starts with field 3 (state), starttime is field 22
+	fields := strings.Fields(string(b[i+1:]))
+	if len(fields) < 20 {
+		return errors.New("unexpected format")
+	}
+
+	if s.PID, err = strconv.Atoi(pid); err != nil {
+		return err
+	}
+	ticks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	btime, err := r.bootTime()
+	if err != nil {
+		return err
+	}
+
+	s.StartTime = btime.Add(time.Duration(ticks) * time.Second / clockTicks)
+	return nil
+}
+
+// bootTime reads the system boot time from stat.
+func (r *Reader) bootTime() (time.Time, error) {
+	b, err := fs.ReadFile(r.fsys, "stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, line := range strings.Split(string(b), "\n") {
+		if v, ok := strings.CutPrefix(line, "btime "); ok {
+			sec, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(sec, 0), nil
+		}
+	}
+	return time.Time{}, errors.New("btime not found")
+}
+
+// readStatus reads the rss, threads and context switches from <pid>/status.
+func (r *Reader) readStatus(s *Stats) error {
+	f, err := r.fsys.Open(r.pid + "/status")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		switch fields[0] {
+		case "VmRSS:":
+			s.RSSBytes = v * 1024
+		case "Threads:":
+			s.Threads = v
+		case "voluntary_ctxt_switches:":
+			s.VoluntaryCtxSwitches = v
+		case "nonvoluntary_ctxt_switches:":
+			s.InvoluntaryCtxSwitches = v
+		}
+	}
+	return scanner.Err()
+}
+
+// readLimits reads the soft limit of open files from <pid>/limits.
+func (r *Reader) readLimits(s *Stats) error {
+	b, err := fs.ReadFile(r.fsys, r.pid+"/limits")
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(string(b), "\n") {
+		v, ok := strings.CutPrefix(line, "Max open files")
+		if !ok {
+			continue
+		}
+
+		fields := strings.Fields(v)
+		if len(fields) == 0 {
+			break
+		}
+		if fields[0] == "unlimited" {
+			s.MaxFDs = 0
+			return nil
+		}
+		s.MaxFDs, err = strconv.ParseUint(fields[0], 10, 64)
+		return err
+	}
+	return errors.New("max open files not found")
+}
+
+// readFDs counts the open file descriptors in <pid>/fd.
+func (r *Reader) readFDs(s *Stats) error {
+	entries, err := fs.ReadDir(r.fsys, r.pid+"/fd")
+	if err != nil {
+		return err
+	}
+
+	s.OpenFDs = uint64(len(entries))
+	return nil
+}
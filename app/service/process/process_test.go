@@ -0,0 +1,210 @@
+package process
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// statLine returns a proc/<pid>/stat line with the given command name and start time in clock ticks.
+func statLine(comm, startTicks string) string {
+	// fields 3 (state) to 21, starttime is field 22, followed by vsize and rss
+	return "1234 (" + comm + ") S" + strings.Repeat(" 0", 18) + " " + startTicks + " 12345678 100\n"
+}
+
+const (
+	procStat = "cpu  100 0 100 700 100 0 0 0 0 0\nbtime 1700000000\nprocesses 1000\n"
+
+	procStatus = `Name:	app
+State:	S (sleeping)
+VmRSS:	   20480 kB
+Threads:	12
+voluntary_ctxt_switches:	150
+nonvoluntary_ctxt_switches:	7
+`
+
+	procLimits = `Limit                     Soft Limit           Hard Limit           Units
+Max cpu time              unlimited            unlimited            seconds
+Max open files            1024                 524288               files
+Max processes             63429                63429                processes
+`
+)
+
+func TestRead(t *testing.T) {
+	fsys := fstest.MapFS{
+		"stat":        {Data: []byte(procStat)},
+		"self/stat":   {Data: []byte(statLine("app", "250"))},
+		"self/status": {Data: []byte(procStatus)},
+		"self/limits": {Data: []byte(procLimits)},
+		"self/fd/0":   {},
+		"self/fd/1":   {},
+		"self/fd/2":   {},
+		"self/fd/3":   {},
+	}
+
+	s := NewFSReader(fsys, "self").Read()
+
+	want := &Stats{
+		Time:                   s.Time,
+		PID:                    1234,
+		StartTime:              time.Unix(1700000002, int64(500*time.Millisecond)),
+		RSSBytes:               20480 * 1024,
+		OpenFDs:                4,
+		MaxFDs:                 1024,
+		Threads:                12,
+		VoluntaryCtxSwitches:   150,
+		InvoluntaryCtxSwitches: 7,
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("Read() = %+v, want %+v", s, want)
+	}
+	if got := s.FDUsagePercent(); got != 0.390625 {
+		t.Errorf("FDUsagePercent() = %v, want 0.390625", got)
+	}
+}
+
+func TestReadStat(t *testing.T) {
+	tests := []struct {
+		name      string
+		stat      string
+		procStat  string
+		wantPID   int
+		wantStart time.Time
+		wantErr   bool
+	}{
+		{"plain name", statLine("app", "100"), procStat, 1234, time.Unix(1700000001, 0), false},
+		{"name with spaces", statLine("my app", "100"), procStat, 1234, time.Unix(1700000001, 0), false},
+		{"name with parentheses", statLine("a) b (c", "100"), procStat, 1234, time.Unix(1700000001, 0), false},
+		{"no parentheses", "1234 app S 1\n", procStat, 0, time.Time{}, true},
+		{"too few fields", "1234 (app) S 1 2 3\n", procStat, 0, time.Time{}, true},
+		{"invalid pid", "x" + statLine("app", "100")[4:], procStat, 0, time.Time{}, true},
+		{"invalid start time", statLine("app", "x"), procStat, 1234, time.Time{}, true},
+		{"no boot time", statLine("app", "100"), "cpu 1 2 3 4\n", 1234, time.Time{}, true},
+		{"invalid boot time", statLine("app", "100"), "btime x\n", 1234, time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"stat":      {Data: []byte(tt.procStat)},
+				"self/stat": {Data: []byte(tt.stat)},
+			}
+
+			var s Stats
+			err := NewFSReader(fsys, "self").readStat(&s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readStat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if s.PID != tt.wantPID || !s.StartTime.Equal(tt.wantStart) {
+				t.Errorf("readStat() = %d/%v, want %d/%v", s.PID, s.StartTime, tt.wantPID, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestReadStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		want   Stats
+	}{
+		{
+			name:   "all fields",
+			status: procStatus,
+			want:   Stats{RSSBytes: 20480 * 1024, Threads: 12, VoluntaryCtxSwitches: 150, InvoluntaryCtxSwitches: 7},
+		},
+		{
+			name:   "kernel thread without VmRSS",
+			status: "Name:\tkthreadd\nThreads:\t1\n",
+			want:   Stats{Threads: 1},
+		},
+		{
+			name:   "malformed lines are skipped",
+			status: "VmRSS:\nThreads:\tmany\nvoluntary_ctxt_switches:\t3\n",
+			want:   Stats{VoluntaryCtxSwitches: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"self/status": {Data: []byte(tt.status)}}
+
+			var s Stats
+			if err := NewFSReader(fsys, "self").readStatus(&s); err != nil {
+				t.Fatalf("readStatus() error = %v", err)
+			}
+			if !reflect.DeepEqual(s, tt.want) {
+				t.Errorf("readStatus() = %+v, want %+v", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  string
+		want    uint64
+		wantErr bool
+	}{
+		{"limited", procLimits, 1024, false},
+		{"unlimited", "Max open files            unlimited            unlimited            files\n", 0, false},
+		{"missing", "Max processes             63429                63429                processes\n", 0, true},
+		{"no value", "Max open files\n", 0, true},
+		{"invalid value", "Max open files            many                 many                 files\n", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"self/limits": {Data: []byte(tt.limits)}}
+
+			s := Stats{MaxFDs: 42}
+			err := NewFSReader(fsys, "self").readLimits(&s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && s.MaxFDs != tt.want {
+				t.Errorf("readLimits() = %v, want %v", s.MaxFDs, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadMissingFiles(t *testing.T) {
+	s := NewFSReader(fstest.MapFS{}, "self").Read()
+
+	want := []string{
+		"stat: open self/stat: file does not exist",
+		"status: open self/status: file does not exist",
+		"limits: open self/limits: file does not exist",
+		"fd: open self/fd: file does not exist",
+	}
+	if !reflect.DeepEqual(s.Errors, want) {
+		t.Errorf("Read() errors = %q, want %q", s.Errors, want)
+	}
+}
+
+func TestFDUsagePercent(t *testing.T) {
+	tests := []struct {
+		name string
+		s    Stats
+		want float64
+	}{
+		{"unlimited", Stats{OpenFDs: 10}, 0},
+		{"half", Stats{OpenFDs: 512, MaxFDs: 1024}, 50},
+		{"exhausted", Stats{OpenFDs: 1024, MaxFDs: 1024}, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.FDUsagePercent(); got != tt.want {
+				t.Errorf("FDUsagePercent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+)
+
+// Header is the http header carrying the request id.
+const Header = "X-Request-ID"
+
+// LogKey is the key of the request id attribute in log records.
+const LogKey = "request_id"
+
+// maxLength is the maximum length of a request id accepted from the caller.
+const maxLength = 128
+
+// contextKey is the context key of the request id.
+type contextKey struct{}
+
+// New returns a new random request id.
+func New() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID returns a copy of ctx carrying the request id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request id of ctx, an empty string if ctx carries none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Middleware assigns a request id to every request and returns it in the X-Request-ID response header.
+// A valid X-Request-ID header of the caller (e.g. a load balancer) is used, otherwise a new id is generated.
+func Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(Header)
+			if !valid(id) {
+				id = New()
+			}
+
+			w.Header().Set(Header, id)
+			h.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), id)))
+		},
+	)
+}
+
+// valid reports whether id is a non-empty printable ascii string of at most maxLength characters.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, c := range []byte(id) {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// LogHandler is a slog.Handler that adds the request id of the context to every log record.
+// Use the context aware log functions (e.g. slog.InfoContext) to correlate log records with requests.
+type LogHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler wraps the given handler.
+func NewLogHandler(h slog.Handler) *LogHandler {
+	return &LogHandler{Handler: h}
+}
+
+// Handle adds the request_id attribute if ctx carries a request id.
+func (h *LogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String(LogKey, id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs returns a new LogHandler whose attributes consist of h's attributes followed by attrs.
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a new LogHandler with the given group appended to h's groups.
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,278 @@
+package runtimestats
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot is an immutable sample of the Go runtime statistics.
+// The values correspond to the runtime.MemStats fields of the same name,
+// but are read with the runtime/metrics package which doesn't stop the world.
+type Snapshot struct {
+	// Time is the time the sample was taken.
+	Time time.Time
+
+	// Goroutines is the number of live goroutines.
+	Goroutines uint64
+
+	// CgoCalls is the number of cgo calls made by the process.
+	CgoCalls int64
+
+	// Sys is the total bytes of memory obtained from the OS.
+	Sys uint64
+
+	// TotalAlloc is the cumulative bytes allocated for heap objects.
+	TotalAlloc uint64
+
+	// Mallocs is the cumulative count of heap objects allocated.
+	Mallocs uint64
+
+	// Frees is the cumulative count of heap objects freed.
+	Frees uint64
+
+	// HeapAlloc is the bytes of allocated heap objects.
+	HeapAlloc uint64
+
+	// HeapSys is the bytes of heap memory obtained from the OS.
+	HeapSys uint64
+
+	// HeapIdle is the bytes in idle (unused) spans.
+	HeapIdle uint64
+
+	// HeapInuse is the bytes in in-use spans.
+	HeapInuse uint64
+
+	// HeapReleased is the bytes of physical memory returned to the OS.
+	HeapReleased uint64
+
+	// HeapObjects is the number of allocated heap objects.
+	HeapObjects uint64
+
+	// GC holds the garbage collector statistics.
+	GC GCStats
+}
+
+// GCStats holds the garbage collector statistics and the runtime limits in effect.
+type GCStats struct {
+	// Cycles is the number of completed GC cycles.
+	Cycles uint64 `json:"Cycles"`
+
+	// CPUFraction is the estimated fraction of the available CPU time used by the GC since the program started.
+	CPUFraction float64 `json:"CPUFraction"`
+
+	// PauseP50Seconds, PauseP90Seconds and PauseP99Seconds are the quantiles of the
+	// stop-the-world GC pause latencies since the program started.
+	PauseP50Seconds float64 `json:"PauseP50Seconds"`
+	PauseP90Seconds float64 `json:"PauseP90Seconds"`
+	PauseP99Seconds float64 `json:"PauseP99Seconds"`
+
+	// PauseMaxSeconds is the upper bound of the longest GC pause since the program started.
+	PauseMaxSeconds float64 `json:"PauseMaxSeconds"`
+
+	// MemoryLimitBytes is the GOMEMLIMIT in effect, math.MaxInt64 if unlimited.
+	MemoryLimitBytes uint64 `json:"MemoryLimitBytes"`
+
+	// MaxProcs is the GOMAXPROCS in effect.
+	MaxProcs uint64 `json:"MaxProcs"`
+}
+
+// Unlimited reports whether no GOMEMLIMIT is set.
+func (g GCStats) Unlimited() bool {
+	return g.MemoryLimitBytes == math.MaxInt64
+}
+
+// Names of the sampled runtime metrics.
+const (
+	metricGoroutines   = "/sched/goroutines:goroutines"
+	metricTotal        = "/memory/classes/total:bytes"
+	metricAllocBytes   = "/gc/heap/allocs:bytes"
+	metricAllocObjects = "/gc/heap/allocs:objects"
+	metricFreeObjects  = "/gc/heap/frees:objects"
+	metricHeapObjects  = "/memory/classes/heap/objects:bytes"
+	metricHeapUnused   = "/memory/classes/heap/unused:bytes"
+	metricHeapFree     = "/memory/classes/heap/free:bytes"
+	metricHeapReleased = "/memory/classes/heap/released:bytes"
+	metricObjects      = "/gc/heap/objects:objects"
+	metricGCCycles     = "/gc/cycles/total:gc-cycles"
+	metricGCPauses     = "/sched/pauses/total/gc:seconds"
+	metricGCCPU        = "/cpu/classes/gc/total:cpu-seconds"
+	metricTotalCPU     = "/cpu/classes/total:cpu-seconds"
+	metricMemoryLimit  = "/gc/gomemlimit:bytes"
+	metricMaxProcs     = "/sched/gomaxprocs:threads"
+)
+
+// Collector samples the runtime statistics in the background at a fixed interval.
+// Handlers read the latest snapshot, so frequent requests don't affect the application latency.
+type Collector struct {
+	interval time.Duration
+	snapshot atomic.Pointer[Snapshot]
+	samples  []metrics.Sample
+	mu       sync.Mutex // protects samples
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// defaultInterval is used if no valid interval is configured.
+const defaultInterval = 10 * time.Second
+
+// New returns a new Collector sampling at the given interval, an initial snapshot is taken immediately.
+func New(interval time.Duration) *Collector {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	names := []string{
+		metricGoroutines, metricTotal, metricAllocBytes, metricAllocObjects, metricFreeObjects,
+		metricHeapObjects, metricHeapUnused, metricHeapFree, metricHeapReleased, metricObjects,
+		metricGCCycles, metricGCPauses, metricGCCPU, metricTotalCPU, metricMemoryLimit, metricMaxProcs,
+	}
+
+	c := &Collector{interval: interval, samples: make([]metrics.Sample, len(names))}
+	for i, name := range names {
+		c.samples[i].Name = name
+	}
+
+	c.Collect()
+	return c
+}
+
+// Name returns the component name.
+func (c *Collector) Name() string {
+	return "runtimestats"
+}
+
+// Start starts sampling in the background until ctx is cancelled or Stop is called.
+func (c *Collector) Start(ctx context.Context) error {
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.Collect()
+			}
+		}
+	}()
+
+	slog.Info("Runtime statistics collector started", "interval", c.interval)
+	return nil
+}
+
+// Stop stops sampling.
+func (c *Collector) Stop(ctx context.Context) error {
+	if c.cancel == nil {
+		return nil
+	}
+	c.cancel()
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Snapshot returns the latest snapshot, it must not be modified.
+func (c *Collector) Snapshot() *Snapshot {
+	return c.snapshot.Load()
+}
+
+// Collect takes a new snapshot and returns it.
+func (c *Collector) Collect() *Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	metrics.Read(c.samples)
+
+	v := make(map[string]uint64, len(c.samples))
+	f := make(map[string]float64)
+	var pauses *metrics.Float64Histogram
+	for _, s := range c.samples {
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			v[s.Name] = s.Value.Uint64()
+		case metrics.KindFloat64:
+			f[s.Name] = s.Value.Float64()
+		case metrics.KindFloat64Histogram:
+			pauses = s.Value.Float64Histogram()
+		}
+	}
+
+	gc := GCStats{
+		Cycles:           v[metricGCCycles],
+		MemoryLimitBytes: v[metricMemoryLimit],
+		MaxProcs:         v[metricMaxProcs],
+	}
+	if total := f[metricTotalCPU]; total > 0 {
+		gc.CPUFraction = f[metricGCCPU] / total
+	}
+	if pauses != nil {
+		gc.PauseP50Seconds = quantile(pauses, 0.5)
+		gc.PauseP90Seconds = quantile(pauses, 0.9)
+		gc.PauseP99Seconds = quantile(pauses, 0.99)
+		gc.PauseMaxSeconds = quantile(pauses, 1)
+	}
+
+	s := &Snapshot{
+		Time:         time.Now(),
+		Goroutines:   v[metricGoroutines],
+		CgoCalls:     runtime.NumCgoCall(),
+		Sys:          v[metricTotal],
+		TotalAlloc:   v[metricAllocBytes],
+		Mallocs:      v[metricAllocObjects],
+		Frees:        v[metricFreeObjects],
+		HeapAlloc:    v[metricHeapObjects],
+		HeapSys:      v[metricHeapObjects] + v[metricHeapUnused] + v[metricHeapFree] + v[metricHeapReleased],
+		HeapIdle:     v[metricHeapFree] + v[metricHeapReleased],
+		HeapInuse:    v[metricHeapObjects] + v[metricHeapUnused],
+		HeapReleased: v[metricHeapReleased],
+		HeapObjects:  v[metricObjects],
+		GC:           gc,
+	}
+
+	c.snapshot.Store(s)
+	return s
+}
+
+// quantile returns the upper bound of the bucket containing the q-quantile of h, 0 if h is empty.
+// Infinite bucket bounds are replaced by the finite bound of the bucket.
+func quantile(h *metrics.Float64Histogram, q float64) float64 {
+	var total uint64
+	for _, n := range h.Counts {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(total)))
+	var seen uint64
+	for i, n := range h.Counts {
+		seen += n
+		if n == 0 || seen < rank {
+			continue
+		}
+
+		// bucket i covers [Buckets[i], Buckets[i+1])
+		if upper := h.Buckets[i+1]; !math.IsInf(upper, 1) {
+			return upper
+		}
+		return h.Buckets[i]
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package runtimestats
+
+import (
+	"math"
+	"runtime/metrics"
+	"testing"
+)
+
+func TestQuantile(t *testing.T) {
+	inf := math.Inf(1)
+	h := &metrics.Float64Histogram{
+		Counts:  []uint64{0, 5, 3, 0, 2},
+		Buckets: []float64{0, 1, 2, 3, 4, inf},
+	}
+
+	tests := []struct {
+		name string
+		h    *metrics.Float64Histogram
+		q    float64
+		want float64
+	}{
+		{"empty", &metrics.Float64Histogram{Counts: []uint64{0, 0}, Buckets: []float64{0, 1, 2}}, 0.5, 0},
+		{"median", h, 0.5, 2},
+		{"upper bound of the first bucket", h, 0.1, 2},
+		{"p75", h, 0.75, 3},
+		{"p80", h, 0.8, 3},
+		{"infinite bucket uses the lower bound", h, 0.99, 4},
+		{"max", h, 1, 4},
+		{"negative infinite lower bound", &metrics.Float64Histogram{Counts: []uint64{1}, Buckets: []float64{math.Inf(-1), 1}}, 0.5, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quantile(tt.h, tt.q); got != tt.want {
+				t.Errorf("quantile(%v) = %v, want %v", tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	s := New(0).Collect()
+
+	if s.Goroutines == 0 || s.HeapAlloc == 0 || s.HeapSys < s.HeapInuse {
+		t.Errorf("Collect() = %+v, want goroutines and a consistent heap", s)
+	}
+	if s.GC.MaxProcs == 0 || s.GC.MemoryLimitBytes == 0 {
+		t.Errorf("Collect() GC = %+v, want the GOMAXPROCS and GOMEMLIMIT in effect", s.GC)
+	}
+}
@@ -0,0 +1,284 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes the next activation time of a job.
+type Schedule interface {
+	// Next returns the next activation time after t.
+	Next(t time.Time) time.Time
+
+	// String returns the schedule expression.
+	String() string
+}
+
+// Parse parses a schedule expression:
+//   - a fixed interval: "@every 5m"
+//   - a predefined cron schedule: @yearly, @monthly, @weekly, @daily, @hourly
+//   - a cron expression with 5 fields (minute hour day-of-month month day-of-week), e.g. "*/15 6-22 * * 1-5"
+//
+// Cron fields support *, lists (1,2,3), ranges (1-5) and steps (*/5, 0-30/10).
+// Cron expressions are evaluated in the local time zone, daylight saving time transitions are handled like Vixie cron:
+//   - activations in the hour skipped when the clock is set forward run at the time of the transition.
+//   - activations in the hour repeated when the clock is set back run once, unless the hour field is "*".
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+
+	if d, ok := strings.CutPrefix(expr, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval %q: %w", expr, err)
+		}
+		return Every(interval)
+	}
+
+	switch expr {
+	case "@yearly", "@annually":
+		expr = "0 0 1 1 *"
+	case "@monthly":
+		expr = "0 0 1 * *"
+	case "@weekly":
+		expr = "0 0 * * 0"
+	case "@daily", "@midnight":
+		expr = "0 0 * * *"
+	case "@hourly":
+		expr = "0 * * * *"
+	}
+
+	return parseCron(expr)
+}
+
+// interval is a schedule with a fixed interval between activations.
+type interval time.Duration
+
+// Every returns a schedule with a fixed interval between activations.
+func Every(d time.Duration) (Schedule, error) {
+	if d <= 0 {
+		return nil, fmt.Errorf("invalid interval %v: must be positive", d)
+	}
+	return interval(d), nil
+}
+
+// Next returns t plus the interval.
+func (i interval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+// String returns the schedule expression.
+func (i interval) String() string {
+	return "@every " + time.Duration(i).String()
+}
+
+// cron is a schedule defined by a cron expression, every field is a bit set of the allowed values.
+type cron struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64
+	domRestricted, dowRestricted  bool
+	hourRestricted                bool
+}
+
+// bounds are the allowed values of a cron field.
+type bounds struct {
+	min, max int
+}
+
+var (
+	minuteBounds = bounds{0, 59}
+	hourBounds   = bounds{0, 23}
+	domBounds    = bounds{1, 31}
+	monthBounds  = bounds{1, 12}
+	dowBounds    = bounds{0, 7} // 0 and 7 are sunday
+)
+
+// parseCron parses a cron expression with 5 fields.
+func parseCron(expr string) (Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	c := &cron{expr: expr}
+	var err error
+
+	if c.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, fmt.Errorf("invalid minute field in %q: %w", expr, err)
+	}
+	if c.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, fmt.Errorf("invalid hour field in %q: %w", expr, err)
+	}
+	if c.dom, err = parseField(fields[2], domBounds); err != nil {
+		return nil, fmt.Errorf("invalid day-of-month field in %q: %w", expr, err)
+	}
+	if c.month, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, fmt.Errorf("invalid month field in %q: %w", expr, err)
+	}
+	if c.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return nil, fmt.Errorf("invalid day-of-week field in %q: %w", expr, err)
+	}
+
+	// sunday can be written as 0 or 7
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+
+	// a field starting with * (e.g. */2) isn't restricted (like Vixie cron)
+	c.domRestricted = !strings.HasPrefix(fields[2], "*")
+	c.dowRestricted = !strings.HasPrefix(fields[4], "*")
+	c.hourRestricted = fields[1] != "*"
+	return c, nil
+}
+
+// parseField parses a comma separated list of values, ranges and steps into a bit set.
+func parseField(field string, b bounds) (uint64, error) {
+	var set uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+		}
+
+		lo, hi := b.min, b.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			loStr, hiStr, _ := strings.Cut(rng, "-")
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(loStr)
+			hi, err2 = strconv.Atoi(hiStr)
+			if err1 != nil || err2 != nil || lo > hi {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			v, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rng)
+			}
+			lo, hi = v, v
+			if hasStep {
+				hi = b.max
+			}
+		}
+
+		if lo < b.min || hi > b.max {
+			return 0, fmt.Errorf("value out of range %d-%d: %q", b.min, b.max, part)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+// Next returns the first activation time after t, or the zero time if there is none within 5 years.
+func (c *cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		// activations skipped by a daylight saving time transition run at the time of the transition
+		if c.skipped(t) {
+			return t
+		}
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = date(t.Year(), t.Month()+1, 1, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = date(t.Year(), t.Month(), t.Day()+1, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = date(t.Year(), t.Month(), t.Day(), t.Hour()+1, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 || c.hourRestricted && repeated(t) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// matches reports whether the wall clock time w matches the expression.
+func (c *cron) matches(w time.Time) bool {
+	return c.month&(1<<uint(w.Month())) != 0 &&
+		c.dayMatches(w) &&
+		c.hour&(1<<uint(w.Hour())) != 0 &&
+		c.minute&(1<<uint(w.Minute())) != 0
+}
+
+// skipped reports whether t is the first minute after the clock was set forward
+// and a wall clock time skipped by the transition matches the expression.
+func (c *cron) skipped(t time.Time) bool {
+	from, to := wall(t.Add(-time.Minute)).Add(time.Minute), wall(t)
+	for w := from; w.Before(to); w = w.Add(time.Minute) {
+		if c.matches(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// repeated reports whether the wall clock time of t already occurred before, since the clock was set back.
+func repeated(t time.Time) bool {
+	_, ok := earlier(t)
+	return ok
+}
+
+// earlier returns the first occurrence of the wall clock time of t, if it's repeated since the clock was set back.
+func earlier(t time.Time) (time.Time, bool) {
+	_, offset := t.Zone()
+	_, before := t.Add(-3 * time.Hour).Zone()
+	if before <= offset {
+		return t, false
+	}
+	e := t.Add(-time.Duration(before-offset) * time.Second)
+	return e, wall(e).Equal(wall(t))
+}
+
+// date returns the first occurrence of the given wall clock hour,
+// time.Date doesn't define which occurrence of a repeated wall clock time is returned.
+func date(year int, month time.Month, day, hour int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, 0, 0, 0, loc)
+	if e, ok := earlier(t); ok {
+		return e
+	}
+	return t
+}
+
+// wall returns the wall clock time of t in UTC, so that wall clock times can be compared and iterated
+// without daylight saving time transitions.
+func wall(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
+
+// dayMatches reports whether the day of t matches the day-of-month and day-of-week fields.
+// If both fields are restricted, a day matching either field matches (like Vixie cron).
+func (c *cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+
+	if c.domRestricted && c.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// String returns the cron expression.
+func (c *cron) String() string {
+	return c.expr
+}
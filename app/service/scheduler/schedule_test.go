@@ -0,0 +1,198 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{expr: "@every 5m", want: "@every 5m0s"},
+		{expr: " @every 90s ", want: "@every 1m30s"},
+		{expr: "@every 0s", wantErr: true},
+		{expr: "@every -1m", wantErr: true},
+		{expr: "@every five", wantErr: true},
+		{expr: "@daily", want: "0 0 * * *"},
+		{expr: "@hourly", want: "0 * * * *"},
+		{expr: "@weekly", want: "0 0 * * 0"},
+		{expr: "@monthly", want: "0 0 1 * *"},
+		{expr: "@yearly", want: "0 0 1 1 *"},
+		{expr: "*/15 6-22 * * 1-5", want: "*/15 6-22 * * 1-5"},
+		{expr: "0,30 8 1,15 * 7", want: "0,30 8 1,15 * 7"},
+		{expr: "* * * *", wantErr: true},
+		{expr: "* * * * * *", wantErr: true},
+		{expr: "60 * * * *", wantErr: true},
+		{expr: "* 24 * * *", wantErr: true},
+		{expr: "* * 0 * *", wantErr: true},
+		{expr: "* * * 13 *", wantErr: true},
+		{expr: "* * * * 8", wantErr: true},
+		{expr: "5-1 * * * *", wantErr: true},
+		{expr: "*/0 * * * *", wantErr: true},
+		{expr: "a * * * *", wantErr: true},
+		{expr: "@reboot", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && s.String() != tt.want {
+				t.Fatalf("String() = %q, want %q", s.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseField(t *testing.T) {
+	tests := []struct {
+		field string
+		b     bounds
+		want  []int
+	}{
+		{"*", bounds{0, 5}, []int{0, 1, 2, 3, 4, 5}},
+		{"*/2", bounds{0, 5}, []int{0, 2, 4}},
+		{"3", minuteBounds, []int{3}},
+		{"3/20", minuteBounds, []int{3, 23, 43}},
+		{"1-4", hourBounds, []int{1, 2, 3, 4}},
+		{"0-30/10", minuteBounds, []int{0, 10, 20, 30}},
+		{"1,5,9-10", domBounds, []int{1, 5, 9, 10}},
+	}
+
+	for _, tt := range tests {
+		set, err := parseField(tt.field, tt.b)
+		if err != nil {
+			t.Fatalf("parseField(%q) error: %v", tt.field, err)
+		}
+		var want uint64
+		for _, v := range tt.want {
+			want |= 1 << uint(v)
+		}
+		if set != want {
+			t.Errorf("parseField(%q) = %b, want %b", tt.field, set, want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// 2026-01-14 is a wednesday
+	from := time.Date(2026, 1, 14, 10, 17, 42, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"@every 5m", from.Add(5 * time.Minute)},
+		{"* * * * *", time.Date(2026, 1, 14, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 1, 14, 10, 30, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2026, 1, 14, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"30 8 * * 1-5", time.Date(2026, 1, 15, 8, 30, 0, 0, time.UTC)},
+		{"0 9 * * 0", time.Date(2026, 1, 18, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2026, 1, 18, 9, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// day-of-month or day-of-week if both are restricted: the 20th or the next friday
+		{"0 0 20 * 5", time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)},
+		// day-of-month and wildcard day-of-week
+		{"0 0 20 * *", time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC)},
+		// a stepped wildcard isn't restricted: an odd day that is a friday, the 20th if it's an even weekday
+		{"0 0 */2 * 5", time.Date(2026, 1, 23, 0, 0, 0, 0, time.UTC)},
+		{"0 0 20 * */2", time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Next(from); !got.Equal(tt.want) {
+				t.Fatalf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextDaylightSavingTime(t *testing.T) {
+	vienna, err := time.LoadLocation("Europe/Vienna")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2026-03-29 02:00 CET the clock is set forward to 03:00 CEST,
+	// 2026-10-25 03:00 CEST the clock is set back to 02:00 CET.
+	springForward := time.Date(2026, 3, 29, 0, 0, 0, 0, vienna)
+	cet := time.FixedZone("CET", 3600)
+	cest := time.FixedZone("CEST", 2*3600)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []time.Time
+	}{
+		{
+			name: "skipped hour runs at the transition",
+			expr: "30 2 * * *",
+			from: springForward,
+			want: []time.Time{
+				time.Date(2026, 3, 29, 3, 0, 0, 0, cest),
+				time.Date(2026, 3, 30, 2, 30, 0, 0, cest),
+			},
+		},
+		{
+			name: "hourly schedule over the skipped hour",
+			expr: "0 * * * *",
+			from: time.Date(2026, 3, 29, 1, 30, 0, 0, vienna),
+			want: []time.Time{
+				time.Date(2026, 3, 29, 3, 0, 0, 0, cest),
+				time.Date(2026, 3, 29, 4, 0, 0, 0, cest),
+			},
+		},
+		{
+			name: "repeated hour runs once",
+			expr: "30 2 * * *",
+			from: time.Date(2026, 10, 25, 0, 0, 0, 0, vienna),
+			want: []time.Time{
+				time.Date(2026, 10, 25, 2, 30, 0, 0, cest),
+				time.Date(2026, 10, 26, 2, 30, 0, 0, cet),
+			},
+		},
+		{
+			name: "hourly schedule runs in both repeated hours",
+			expr: "30 * * * *",
+			from: time.Date(2026, 10, 25, 2, 0, 0, 0, cest),
+			want: []time.Time{
+				time.Date(2026, 10, 25, 2, 30, 0, 0, cest),
+				time.Date(2026, 10, 25, 2, 30, 0, 0, cet),
+				time.Date(2026, 10, 25, 3, 30, 0, 0, cet),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next := tt.from.In(vienna)
+			for i, want := range tt.want {
+				next = s.Next(next)
+				if !next.Equal(want) {
+					t.Fatalf("activation %d = %v, want %v", i+1, next, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/womat/go-api-template/app/service/monitoring"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobRunning  = errors.New("job is already running")
+	ErrNotRunning  = errors.New("scheduler is not running")
+)
+
+// Func is the work executed by a job. The context is cancelled on timeout and on shutdown.
+type Func func(ctx context.Context) error
+
+// Job defines a periodic task.
+type Job struct {
+	// Name is the unique name of the job.
+	Name string
+
+	// Schedule defines when the job runs.
+	Schedule Schedule
+
+	// Func is the work executed by the job.
+	Func Func
+
+	// Timeout is the maximum duration of a run, 0 means no timeout.
+	Timeout time.Duration
+
+	// Jitter is the maximum random delay added to every scheduled run,
+	// it spreads the load of jobs scheduled at the same time.
+	Jitter time.Duration
+}
+
+// JobStatus is the state and the result of the last run of a job.
+type JobStatus struct {
+	// Name is the name of the job.
+	Name string `json:"name"`
+
+	// Schedule is the schedule expression of the job.
+	Schedule string `json:"schedule"`
+
+	// Running reports whether the job is currently running.
+	Running bool `json:"running"`
+
+	// LastRun is the start time of the last run, zero if the job has not run yet.
+	LastRun time.Time `json:"lastRun"`
+
+	// LastDuration is the duration of the last run in seconds.
+	LastDuration float64 `json:"lastDurationSeconds"`
+
+	// LastError is the error of the last run, empty if it succeeded.
+	LastError string `json:"lastError,omitempty"`
+
+	// NextRun is the time of the next scheduled run.
+	NextRun time.Time `json:"nextRun"`
+
+	// Runs is the number of runs since the scheduler was started.
+	Runs int `json:"runs"`
+
+	// Failures is the number of failed runs since the scheduler was started.
+	Failures int `json:"failures"`
+}
+
+// job is a registered job and its status.
+type job struct {
+	Job
+	mu     sync.Mutex
+	status JobStatus
+}
+
+// Scheduler runs jobs on their schedule.
+//   - runs of the same job never overlap, a run is skipped if the previous one is still running.
+//   - the scheduler implements lifecycle.Component and is stopped with the shutdown procedure.
+type Scheduler struct {
+	mu      sync.Mutex
+	jobs    []*job
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// New returns a new Scheduler.
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add registers a job, jobs must be added before the scheduler is started.
+func (s *Scheduler) Add(j Job) error {
+	if j.Name == "" || j.Schedule == nil || j.Func == nil {
+		return errors.New("job name, schedule and func are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.find(j.Name) != nil {
+		return fmt.Errorf("job %s already registered", j.Name)
+	}
+
+	s.jobs = append(s.jobs, &job{Job: j, status: JobStatus{Name: j.Name, Schedule: j.Schedule.String()}})
+	return nil
+}
+
+// Name returns the component name.
+func (s *Scheduler) Name() string {
+	return "scheduler"
+}
+
+// Start starts a goroutine per job, the jobs run until ctx is cancelled or Stop is called.
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.stopped = false
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(j)
+	}
+
+	slog.Info("Scheduler started", "jobs", len(s.jobs))
+	return nil
+}
+
+// Stop cancels running jobs and waits until they have returned or ctx is done.
+// Jobs can't be triggered once Stop is called.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	// no run is added to the wait group after stopped is set
+	s.mu.Lock()
+	cancel := s.cancel
+	s.stopped = true
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		slog.Info("Scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for running jobs: %w", ctx.Err())
+	}
+}
+
+// Trigger starts a run of the named job immediately, independent of its schedule.
+// It returns ErrJobNotFound if the job doesn't exist, ErrJobRunning if the job is running
+// and ErrNotRunning if the scheduler isn't started or is stopped.
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j := s.find(name)
+	ctx := s.ctx
+
+	switch {
+	case j == nil:
+		return ErrJobNotFound
+	case ctx == nil || s.stopped || ctx.Err() != nil:
+		return ErrNotRunning
+	case !j.begin():
+		return ErrJobRunning
+	}
+
+	// s.mu is held, so Stop can't start waiting before the run is added
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(ctx, j, "manual")
+	}()
+	return nil
+}
+
+// Status returns the status of all jobs sorted by name.
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	jobs := slices.Clone(s.jobs)
+	s.mu.Unlock()
+
+	status := make([]JobStatus, 0, len(jobs))
+	for _, j := range jobs {
+		j.mu.Lock()
+		status = append(status, j.status)
+		j.mu.Unlock()
+	}
+
+	slices.SortFunc(status, func(a, b JobStatus) int { return strings.Compare(a.Name, b.Name) })
+	return status
+}
+
+// Monitoring returns a monitoring entry per job with the last run, duration, next run and error.
+// The state of a job is Warning if the last run failed.
+func (s *Scheduler) Monitoring(host string) []monitoring.Model {
+	status := s.Status()
+
+	services := make([]monitoring.Model, 0, len(status))
+	for _, js := range status {
+		state := monitoring.StateOK
+		desc := fmt.Sprintf("Job %s: last run: %s, duration: %.3fs, next run: %s",
+			js.Name, formatTime(js.LastRun), js.LastDuration, formatTime(js.NextRun))
+		if js.LastError != "" {
+			state = monitoring.StateWarning
+			desc += ", error: " + js.LastError
+		}
+
+		services = append(services, monitoring.Model{
+			Service:     "Job " + js.Name,
+			Host:        host,
+			State:       state,
+			Value:       js.LastDuration,
+			Description: desc,
+			Metric:      monitoring.MetricGauge,
+			Unit:        monitoring.UnitSeconds,
+		})
+	}
+
+	return services
+}
+
+// formatTime formats t as RFC3339 or returns "never" for the zero time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// loop runs the job on its schedule until the scheduler is stopped.
+func (s *Scheduler) loop(j *job) {
+	defer s.wg.Done()
+
+	for {
+		next := j.Schedule.Next(time.Now())
+		if next.IsZero() {
+			slog.Warn("Job has no next run, stopping schedule", "job", j.Name, "schedule", j.Schedule.String())
+			return
+		}
+		if j.Jitter > 0 {
+			next = next.Add(rand.N(j.Jitter))
+		}
+
+		j.mu.Lock()
+		j.status.NextRun = next
+		j.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !j.begin() {
+			slog.Warn("Skipping job run, previous run is still running", "job", j.Name)
+			continue
+		}
+		s.run(s.ctx, j, "schedule")
+	}
+}
+
+// run executes the job and records the result, the job must be marked as running by begin.
+func (s *Scheduler) run(ctx context.Context, j *job, trigger string) {
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	slog.Debug("Job started", "job", j.Name, "trigger", trigger)
+
+	err := call(ctx, j.Func)
+	duration := time.Since(start)
+
+	j.mu.Lock()
+	j.status.Running = false
+	j.status.LastRun = start
+	j.status.LastDuration = duration.Seconds()
+	j.status.LastError = ""
+	j.status.Runs++
+	if err != nil {
+		j.status.LastError = err.Error()
+		j.status.Failures++
+	}
+	j.mu.Unlock()
+
+	if err != nil {
+		slog.Error("Job failed", "job", j.Name, "trigger", trigger, "duration", duration, "error", err)
+		return
+	}
+	slog.Debug("Job finished", "job", j.Name, "trigger", trigger, "duration", duration)
+}
+
+// begin marks the job as running, it returns false if the job is already running.
+func (j *job) begin() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.status.Running {
+		return false
+	}
+	j.status.Running = true
+	return true
+}
+
+// call executes f and converts a panic into an error.
+func call(ctx context.Context, f Func) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return f(ctx)
+}
+
+// find returns the named job or nil, s.mu must be held.
+func (s *Scheduler) find(name string) *job {
+	for _, j := range s.jobs {
+		if j.Name == name {
+			return j
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerNoOverlap(t *testing.T) {
+	every, _ := Every(5 * time.Millisecond)
+
+	var running, overlaps, runs atomic.Int32
+	s := New()
+	err := s.Add(Job{Name: "slow", Schedule: every, Func: func(ctx context.Context) error {
+		if running.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		defer running.Add(-1)
+		runs.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err = s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if runs.Load() == 0 {
+		t.Fatal("job didn't run")
+	}
+	if overlaps.Load() > 0 {
+		t.Fatalf("%d overlapping runs", overlaps.Load())
+	}
+}
+
+func TestSchedulerTrigger(t *testing.T) {
+	daily, _ := Parse("@daily")
+
+	release := make(chan struct{})
+	s := New()
+	_ = s.Add(Job{Name: "report", Schedule: daily, Func: func(ctx context.Context) error {
+		<-release
+		return errors.New("no data")
+	}})
+
+	if err := s.Trigger("report"); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("Trigger() before Start = %v, want %v", err, ErrNotRunning)
+	}
+
+	_ = s.Start(context.Background())
+
+	if err := s.Trigger("unknown"); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("Trigger(unknown) = %v, want %v", err, ErrJobNotFound)
+	}
+	if err := s.Trigger("report"); err != nil {
+		t.Fatalf("Trigger() = %v", err)
+	}
+	if err := s.Trigger("report"); !errors.Is(err, ErrJobRunning) {
+		t.Fatalf("Trigger() while running = %v, want %v", err, ErrJobRunning)
+	}
+	close(release)
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Trigger("report"); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("Trigger() after Stop = %v, want %v", err, ErrNotRunning)
+	}
+
+	status := s.Status()[0]
+	if status.Runs != 1 || status.Failures != 1 || status.LastError != "no data" || status.Running {
+		t.Fatalf("Status() = %+v, want one failed run", status)
+	}
+}
+
+func TestSchedulerTriggerDuringStop(t *testing.T) {
+	daily, _ := Parse("@daily")
+
+	for range 50 {
+		s := New()
+		_ = s.Add(Job{Name: "noop", Schedule: daily, Func: func(context.Context) error { return nil }})
+		_ = s.Start(context.Background())
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				_ = s.Trigger("noop")
+			}
+		}()
+		if err := s.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+
+		if status := s.Status()[0]; status.Running {
+			t.Fatal("job run started after Stop")
+		}
+	}
+}
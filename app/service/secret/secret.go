@@ -0,0 +1,58 @@
+package secret
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// Redacted replaces the value of a secret in logs, config dumps and api responses.
+const Redacted = "[REDACTED]"
+
+// String is a string holding a secret, e.g. an api key or password.
+// It's read from config files like a plain string, but is redacted when it's formatted with fmt,
+// logged with slog or marshaled to JSON or YAML. Use Value to get the clear text.
+// An empty secret is formatted as empty string, to show that it's not set.
+type String string
+
+// Value returns the clear text of the secret.
+func (s String) Value() string {
+	return string(s)
+}
+
+// IsSet reports whether the secret is not empty.
+func (s String) IsSet() bool {
+	return s != ""
+}
+
+// redact returns Redacted, or an empty string if the secret is not set.
+func (s String) redact() string {
+	if s == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// String returns the redacted secret, it's used by fmt (%v, %s, %+v).
+func (s String) String() string {
+	return s.redact()
+}
+
+// GoString returns the redacted secret, it's used by fmt (%#v).
+func (s String) GoString() string {
+	return `"` + s.redact() + `"`
+}
+
+// LogValue returns the redacted secret for slog.
+func (s String) LogValue() slog.Value {
+	return slog.StringValue(s.redact())
+}
+
+// MarshalJSON returns the redacted secret as JSON string.
+func (s String) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.redact())
+}
+
+// MarshalYAML returns the redacted secret for YAML.
+func (s String) MarshalYAML() (any, error) {
+	return s.redact(), nil
+}
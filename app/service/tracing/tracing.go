@@ -0,0 +1,144 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Supported span exporters.
+const (
+	ExporterOTLP = "otlp" // OTLP/HTTP exporter sending spans to a collector
+	ExporterFile = "file" // JSON file exporter for offline debugging
+)
+
+// Config holds the tracing configuration.
+type Config struct {
+	// ServiceName is reported as service.name resource attribute.
+	ServiceName string
+
+	// ServiceVersion is reported as service.version resource attribute.
+	ServiceVersion string
+
+	// Exporter is the span exporter: otlp | file
+	Exporter string
+
+	// Endpoint is the OTLP/HTTP endpoint url, e.g. http://localhost:4318/v1/traces
+	Endpoint string
+
+	// Headers are additional http headers sent to the OTLP endpoint, e.g. for authentication.
+	Headers map[string]string
+
+	// File is the path of the file the JSON file exporter writes to.
+	File string
+
+	// SampleRatio is the fraction of traces sampled (0..1).
+	// Sampling decisions of the caller (traceparent) are respected.
+	SampleRatio float64
+}
+
+// Provider wraps the OpenTelemetry tracer provider and its exporter resources.
+type Provider struct {
+	tp   *sdktrace.TracerProvider
+	file *os.File
+}
+
+// Init creates the tracer provider for the configured exporter and registers it,
+// together with the W3C trace context (traceparent/tracestate) and baggage propagators, as global provider.
+func Init(ctx context.Context, config Config) (*Provider, error) {
+	var exporter sdktrace.SpanExporter
+	var file *os.File
+	var err error
+
+	switch strings.ToLower(config.Exporter) {
+	case ExporterOTLP:
+		if config.Endpoint == "" {
+			return nil, errors.New("tracing endpoint is required for the otlp exporter")
+		}
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(config.Endpoint)}
+		if len(config.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(config.Headers))
+		}
+		if exporter, err = otlptracehttp.New(ctx, opts...); err != nil {
+			return nil, fmt.Errorf("create otlp exporter: %w", err)
+		}
+
+	case ExporterFile:
+		if config.File == "" {
+			return nil, errors.New("tracing file is required for the file exporter")
+		}
+		if file, err = os.OpenFile(config.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err != nil {
+			return nil, fmt.Errorf("open tracing file: %w", err)
+		}
+		if exporter, err = stdouttrace.New(stdouttrace.WithWriter(file)); err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("create file exporter: %w", err)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter %q", config.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(config.ServiceName),
+		semconv.ServiceVersion(config.ServiceVersion),
+	))
+	if err != nil {
+		res = resource.Default()
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return &Provider{tp: tp, file: file}, nil
+}
+
+// Shutdown flushes pending spans and releases the exporter resources.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	err := p.tp.Shutdown(ctx)
+	if p.file != nil {
+		err = errors.Join(err, p.file.Close())
+	}
+	return err
+}
+
+// WithTracing is a middleware that starts a server span for every request.
+// The trace context of the caller is extracted from the traceparent/tracestate headers.
+// The span is named after the route pattern registered on mux (e.g. "GET /api/jobs/{name}/run"), not the request path,
+// to keep the number of span names low. Requests without matching route are named after the method.
+func WithTracing(h http.Handler, mux *http.ServeMux) http.Handler {
+	return otelhttp.NewHandler(h, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if _, pattern := mux.Handler(r); pattern != "" {
+				return pattern
+			}
+			return r.Method
+		}))
+}
+
+// NewTransport wraps base (http.DefaultTransport if nil) to create a client span for every outbound request
+// and to inject the traceparent/tracestate headers.
+// Use it as transport of the http clients of the application, with the request context, to propagate the trace.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base)
+}
@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithTracingSpanName(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /api/jobs/{name}/run", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	h := WithTracing(mux, mux)
+
+	for _, tt := range []struct {
+		method, path, want string
+	}{
+		{http.MethodPost, "/api/jobs/cleanup/run", "POST /api/jobs/{name}/run"},
+		{http.MethodPost, "/api/jobs/report/run", "POST /api/jobs/{name}/run"},
+		{http.MethodGet, "/unknown/12345", "GET"},
+	} {
+		recorder.Reset()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+		spans := recorder.Ended()
+		if len(spans) != 1 {
+			t.Fatalf("%s %s: %d spans, want 1", tt.method, tt.path, len(spans))
+		}
+		if got := spans[0].Name(); got != tt.want {
+			t.Errorf("%s %s: span name %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	var traceparent string
+	s := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer s.Close()
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	resp, err := (&http.Client{Transport: NewTransport(nil)}).Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	_ = resp.Body.Close()
+	parent.End()
+
+	traceID := parent.SpanContext().TraceID().String()
+	if !strings.Contains(traceparent, traceID) {
+		t.Errorf("traceparent = %q, want trace id %s", traceparent, traceID)
+	}
+	if spans := recorder.Ended(); len(spans) != 2 || spans[0].Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("%d spans, want a client span and its parent", len(spans))
+	}
+}
@@ -1,10 +1,15 @@
 package app
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"github.com/womat/go-api-template/app/service/logging"
 	"log/slog"
 	"net"
 	"net/http"
+	"os"
 )
 
 // StartWebServer initializes and starts the web server in a separate Goroutine.
@@ -15,9 +20,30 @@ import (
 // The function does not block execution. Errors occurring during setup are returned immediately.
 // Runtime errors (e.g., failure in Serve()) are logged but do not propagate.
 //
+// If a client CA file is configured, client certificates are requested and verified,
+// so clients can authenticate with a certificate.
+//
 // Returns an error if the server cannot be initialized.
 func (app *App) StartWebServer() error {
 
+	if caFile := app.config.HttpsServer.ClientCAFile; caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			slog.Error("Failed to read client CA file", "file", caFile, "error", err)
+			return err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no valid certificates found in client CA file %s", caFile)
+		}
+
+		app.web.TLSConfig = &tls.Config{
+			ClientCAs:  pool,
+			ClientAuth: tls.VerifyClientCertIfGiven,
+		}
+	}
+
 	listener, err := net.Listen("tcp4", app.config.HttpsServer.ListenHost+":"+app.config.HttpsServer.ListenPort)
 	if err != nil {
 		slog.Error("Failed to create listener", "error", err)
@@ -30,10 +56,42 @@ func (app *App) StartWebServer() error {
 			slog.Error("Failed serving", "error", err)
 		}
 
-		if err := listener.Close(); err != nil {
+		// the listener is already closed if the server was shut down
+		if err := listener.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
 			slog.Error("Failed to close listener", "error", err)
 		}
 	}()
 
 	return nil
 }
+
+// withInFlight is a middleware that counts the requests currently being served.
+// The count is used to report aborted requests if draining exceeds the timeout.
+func (app *App) withInFlight(h http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			app.inFlight.Add(1)
+			defer app.inFlight.Add(-1)
+
+			h.ServeHTTP(w, r)
+		},
+	)
+}
+
+// withRequestLog is a middleware that logs every request with its headers at debug level.
+// Sensitive headers (Authorization, Cookie, X-Api-Key, ...) are redacted.
+func withRequestLog(h http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if slog.Default().Enabled(r.Context(), slog.LevelDebug) {
+				slog.DebugContext(r.Context(), "Web request",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"client_ip", r.RemoteAddr,
+					"headers", logging.RedactHeader(r.Header))
+			}
+
+			h.ServeHTTP(w, r)
+		},
+	)
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithRequestLog(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    slog.Level
+		want     []string
+		wantNot  []string
+		wantNone bool
+	}{
+		{
+			name:    "debug",
+			level:   slog.LevelDebug,
+			want:    []string{`msg="Web request"`, "method=GET", "path=/api/health", "Accept:[application/json]", "X-Api-Key:[[REDACTED]]", "Authorization:[[REDACTED]]"},
+			wantNot: []string{"12345678", "Bearer abc"},
+		},
+		{
+			name:     "info",
+			level:    slog.LevelInfo,
+			wantNone: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			defer slog.SetDefault(slog.Default())
+			slog.SetDefault(slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{Level: tt.level})))
+
+			var served bool
+			h := withRequestLog(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { served = true }))
+
+			r := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+			r.Header.Set("Accept", "application/json")
+			r.Header.Set("X-Api-Key", "12345678")
+			r.Header.Set("Authorization", "Bearer abc")
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			if !served {
+				t.Fatal("request wasn't passed to the handler")
+			}
+			got := b.String()
+			if tt.wantNone && got != "" {
+				t.Errorf("logged %q, want nothing", got)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("logged %q, want %q", got, s)
+				}
+			}
+			for _, s := range tt.wantNot {
+				if strings.Contains(got, s) {
+					t.Errorf("logged %q, must not contain %q", got, s)
+				}
+			}
+			if r.Header.Get("X-Api-Key") != "12345678" {
+				t.Errorf("request header was modified: %q", r.Header)
+			}
+		})
+	}
+}
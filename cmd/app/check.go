@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/womat/go-api-template/app"
+	"github.com/womat/go-api-template/app/service/monitoring"
+	"github.com/womat/go-api-template/app/service/nagios"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listFlag is a flag which may be repeated.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// limit is a warning or critical limit of the check command: [service=]limit
+type limit struct {
+	service string
+	value   float64
+}
+
+// runCheck runs the check command, a Nagios/Icinga plugin for the monitoring data.
+// It prints the plugin output and returns the exit code: 0 OK, 1 WARNING, 2 CRITICAL, 3 UNKNOWN.
+func runCheck(args []string) int {
+	name := strings.ToUpper(app.MODULE)
+
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(os.Stdout)
+
+	configFile := flags.String("config", filepath.Join("/opt", app.MODULE, "etc", "config.yaml"), "Specify the path to the config file")
+	url := flags.String("url", "", "Base url of the instance (default https://<listenHost>:<listenPort> of the config file)")
+	apiKey := flags.String("apikey", "", "Api key (default apiKey of the config file)")
+	insecure := flags.Bool("insecure", false, "Skip the verification of the server certificate, e.g. a self-signed certificate")
+	timeout := flags.Duration("timeout", 10*time.Second, "Timeout of the check")
+	local := flags.Bool("local", false, "Evaluate the host metrics locally instead of querying the instance")
+
+	var services, warnings, criticals listFlag
+	flags.Var(&services, "service", "Service to check, * matches any characters (repeatable, default all services)")
+	flags.Var(&warnings, "warning", "Warning limit [service=]limit, without service it applies to all checked services (repeatable)")
+	flags.Var(&criticals, "critical", "Critical limit [service=]limit, without service it applies to all checked services (repeatable)")
+
+	if err := flags.Parse(args); err != nil {
+		return int(nagios.Unknown)
+	}
+
+	warningLimits, err := parseLimits(warnings)
+	if err != nil {
+		return fail(name, fmt.Errorf("invalid warning limit: %w", err))
+	}
+	criticalLimits, err := parseLimits(criticals)
+	if err != nil {
+		return fail(name, fmt.Errorf("invalid critical limit: %w", err))
+	}
+
+	// the config file is optional if url and api key are given
+	config, configErr := loadConfig(*configFile, false)
+	if configErr != nil && (*local || *url == "") {
+		return fail(name, configErr)
+	}
+
+	var resp monitoring.Response
+	if *local {
+		hostName, _ := os.Hostname()
+		resp = app.LocalMonitoring(config, hostName, time.Second)
+	} else {
+		if *url == "" {
+			*url = instanceURL(config)
+		}
+		if *apiKey == "" && config != nil {
+			*apiKey = config.HttpsServer.ApiKey.Value()
+		}
+		if resp, err = fetchMonitoring(*url, *apiKey, *insecure, *timeout); err != nil {
+			return fail(name, err)
+		}
+	}
+
+	// the configured thresholds are reported in the performance data, the command line limits take precedence
+	thresholds := map[string]monitoring.Threshold{}
+	if config != nil {
+		for k, v := range config.Monitoring.Thresholds {
+			thresholds[k] = v
+		}
+	}
+
+	var selected []monitoring.Model
+	for _, s := range resp.Services {
+		if len(services) > 0 && !matchAny(services, s.Service) {
+			continue
+		}
+		selected = append(selected, s)
+	}
+
+	commandLine := map[string]monitoring.Threshold{}
+	for _, s := range selected {
+		t, found := thresholds[s.Service], false
+		for _, l := range warningLimits {
+			if l.service == "" || match(l.service, s.Service) {
+				t.Warning, found = &l.value, true
+			}
+		}
+		for _, l := range criticalLimits {
+			if l.service == "" || match(l.service, s.Service) {
+				t.Critical, found = &l.value, true
+			}
+		}
+		if found {
+			thresholds[s.Service] = t
+			commandLine[s.Service] = t
+		}
+	}
+
+	// the services with command line limits are evaluated locally, the others keep the state of the instance
+	if len(commandLine) > 0 {
+		selected = monitoring.NewEvaluator(commandLine).Evaluate(resp.Host, selected).Services
+	}
+
+	out, status := nagios.Report(name, selected, thresholds)
+	fmt.Println(out)
+	return int(status)
+}
+
+// fetchMonitoring returns the v2 monitoring data of the instance.
+func fetchMonitoring(url, apiKey string, insecure bool, timeout time.Duration) (monitoring.Response, error) {
+	client := newClient(insecure, timeout)
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(url, "/")+"/api/monitoring?version=2", nil)
+	if err != nil {
+		return monitoring.Response{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if apiKey != "" {
+		req.Header.Set("X-Api-Key", apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return monitoring.Response{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return monitoring.Response{}, responseError(resp)
+	}
+
+	var data monitoring.Response
+	if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return monitoring.Response{}, fmt.Errorf("invalid monitoring data: %w", err)
+	}
+	return data, nil
+}
+
+// parseLimits parses the limits [service=]limit.
+func parseLimits(values []string) ([]limit, error) {
+	limits := make([]limit, 0, len(values))
+	for _, v := range values {
+		var l limit
+		s := v
+		if i := strings.LastIndex(v, "="); i >= 0 {
+			l.service, s = v[:i], v[i+1:]
+		}
+
+		var err error
+		if l.value, err = strconv.ParseFloat(strings.TrimSpace(s), 64); err != nil {
+			return nil, fmt.Errorf("%q: expected [service=]number", v)
+		}
+		limits = append(limits, l)
+	}
+	return limits, nil
+}
+
+// match reports whether the service name matches the pattern, * matches any characters, case is ignored.
+func match(pattern, service string) bool {
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.MustCompile("(?i)^" + strings.Join(parts, ".*") + "$").MatchString(service)
+}
+
+// matchAny reports whether the service name matches any of the patterns.
+func matchAny(patterns []string, service string) bool {
+	for _, p := range patterns {
+		if match(p, service) {
+			return true
+		}
+	}
+	return false
+}
+
+// fail prints the plugin output of a failed check and returns the exit code UNKNOWN.
+func fail(name string, err error) int {
+	out, status := nagios.Fail(name, err.Error())
+	fmt.Println(out)
+	return int(status)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/womat/go-api-template/app/service/monitoring"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what f prints to stdout.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	f()
+	_ = w.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// monitoringServer returns a TLS server answering /api/monitoring with services, the api key must be 12345678.
+func monitoringServer(t *testing.T, services []monitoring.Model) *httptest.Server {
+	t.Helper()
+
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path != "/api/monitoring" || r.URL.Query().Get("version") != "2":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Header.Get("X-Api-Key") != "12345678":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid api key"}`))
+		default:
+			_ = json.NewEncoder(w).Encode(monitoring.Response{Version: monitoring.ResponseV2, Host: "pi", State: monitoring.StateOK, Services: services})
+		}
+	}))
+	// the handshake errors of the test verifying the certificate aren't logged
+	s.Config.ErrorLog = log.New(io.Discard, "", 0)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestRunCheck(t *testing.T) {
+	services := []monitoring.Model{
+		{Service: "Number of Goroutines", State: monitoring.StateOK, Value: 12, Description: "12 goroutines"},
+		{Service: "Heap Alloc", State: monitoring.StateWarning, Value: 2048, Unit: monitoring.UnitBytes, Description: "heap 2048 bytes"},
+		{Service: "Version", State: monitoring.StateOK, Value: "1.0.0", Description: "version 1.0.0"},
+	}
+	s := monitoringServer(t, services)
+	missingConfig := filepath.Join(t.TempDir(), "config.yaml")
+
+	tests := []struct {
+		name     string
+		args     []string
+		want     int
+		wantText string
+	}{
+		{"all services", nil, 1, "WARNING - heap 2048 bytes"},
+		{"selected service", []string{"-service", "number*"}, 0, "OK - 12 goroutines | 'Number of Goroutines'=12"},
+		{"no matching services", []string{"-service", "nope"}, 3, "UNKNOWN - no matching services"},
+		{"warning limit", []string{"-service", "Number of Goroutines", "-warning", "10"}, 1, "'Number of Goroutines'=12;10"},
+		{"critical limit", []string{"-service", "Number of Goroutines", "-warning", "5", "-critical", "10"}, 2, "'Number of Goroutines'=12;5;10"},
+		{"limit of another service", []string{"-service", "Number*", "-critical", "Heap*=10"}, 0, "OK - 12 goroutines"},
+		{"limit overrides the instance state", []string{"-service", "Heap Alloc", "-warning", "4096"}, 0, "OK - heap 2048 bytes | 'Heap Alloc'=2048B;4096"},
+		{"invalid limit", []string{"-warning", "high"}, 3, "UNKNOWN - invalid warning limit"},
+		{"wrong api key", []string{"-apikey", "wrong"}, 3, "UNKNOWN - 401 Unauthorized: invalid api key"},
+		{"certificate not verified", []string{"-insecure=false"}, 3, "UNKNOWN - "},
+		{"unknown flag", []string{"-nope"}, 3, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-config", missingConfig, "-url", s.URL, "-apikey", "12345678", "-insecure"}, tt.args...)
+
+			var got int
+			out := captureStdout(t, func() { got = runCheck(args) })
+			if got != tt.want {
+				t.Errorf("runCheck() = %d, want %d, output %q", got, tt.want, out)
+			}
+			if !strings.Contains(out, tt.wantText) {
+				t.Errorf("runCheck() output = %q, want %q", out, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestRunCheckWithoutConfig(t *testing.T) {
+	missingConfig := filepath.Join(t.TempDir(), "config.yaml")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"without url", []string{"-config", missingConfig}},
+		{"local", []string{"-config", missingConfig, "-url", "https://127.0.0.1:1", "-local"}},
+		{"unreachable", []string{"-config", missingConfig, "-url", "https://127.0.0.1:1", "-timeout", "1s"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got int
+			out := captureStdout(t, func() { got = runCheck(tt.args) })
+			if got != 3 || !strings.Contains(out, "UNKNOWN - ") {
+				t.Errorf("runCheck() = %d, output %q, want 3 and the UNKNOWN output", got, out)
+			}
+		})
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    []limit
+		wantErr bool
+	}{
+		{"empty", nil, []limit{}, false},
+		{"all services", []string{"80"}, []limit{{"", 80}}, false},
+		{"service", []string{"CPU=90.5"}, []limit{{"CPU", 90.5}}, false},
+		{"equal sign in service", []string{"a=b= 1"}, []limit{{"a=b", 1}}, false},
+		{"several", []string{"1", "Heap*=2"}, []limit{{"", 1}, {"Heap*", 2}}, false},
+		{"not a number", []string{"CPU=high"}, nil, true},
+		{"missing limit", []string{"CPU="}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLimits(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseLimits() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("parseLimits() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		service string
+		want    bool
+	}{
+		{"CPU", "CPU", true},
+		{"cpu", "CPU", true},
+		{"CPU", "CPU Load", false},
+		{"CPU*", "CPU Load", true},
+		{"*load", "CPU Load", true},
+		{"*", "anything", true},
+		{"Heap (MB)", "Heap (MB)", true},
+		{"Heap.", "HeapX", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.service, func(t *testing.T) {
+			if got := match(tt.pattern, tt.service); got != tt.want {
+				t.Errorf("match(%q, %q) = %v, want %v", tt.pattern, tt.service, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/womat/go-api-template/app"
+	"github.com/womat/go-api-template/app/service/tracing"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// instanceURL returns the base url of the instance configured in config.
+// An unspecified listen host (e.g. 0.0.0.0) is replaced by the loopback address.
+func instanceURL(config *app.Config) string {
+	host := config.HttpsServer.ListenHost
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	return "https://" + net.JoinHostPort(host, config.HttpsServer.ListenPort)
+}
+
+// isLoopback reports whether the host of the url is a loopback address or localhost.
+func isLoopback(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// responseError returns the error of a response with an error status.
+// The reason is read from the legacy {"error": "..."} body or the detail (or title) of problem details.
+func responseError(resp *http.Response) error {
+	var body struct {
+		Error  string `json:"error"`
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+
+	reason := body.Error
+	if reason == "" {
+		reason = body.Detail
+	}
+	if reason == "" {
+		reason = body.Title
+	}
+	if reason == "" {
+		return errors.New(resp.Status)
+	}
+	return fmt.Errorf("%s: %s", resp.Status, reason)
+}
+
+// newClient returns an http client for the commands querying the instance.
+// If insecure, the server certificate isn't verified, e.g. the self-signed development certificate.
+// The transport is traced, without a registered tracer provider (tracing.Init) its spans are no-ops.
+func newClient(insecure bool, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: tracing.NewTransport(&http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+		}),
+	}
+}
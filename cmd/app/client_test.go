@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseError(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"legacy error", `{"error":"not ready"}`, "503 Service Unavailable: not ready"},
+		{"problem detail", `{"type":"about:blank","title":"Service Unavailable","status":503,"detail":"not ready"}`, "503 Service Unavailable: not ready"},
+		{"problem title", `{"type":"about:blank","title":"Service Unavailable","status":503}`, "503 Service Unavailable: Service Unavailable"},
+		{"empty body", ``, "503 Service Unavailable"},
+		{"no json", `service unavailable`, "503 Service Unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.WriteString(tt.body)
+
+			if got := responseError(w.Result()); got.Error() != tt.want {
+				t.Errorf("responseError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/womat/go-api-template/app"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// runHealthcheck runs the healthcheck command, e.g. for the HEALTHCHECK of a container image without curl.
+// It reads the config file, calls /api/ready of the instance at listenHost:listenPort
+// and returns the exit code: 0 ready, 1 not ready or not reachable within the timeout.
+// The server certificate isn't verified, the instance usually has a self-signed certificate.
+func runHealthcheck(args []string) int {
+	flags := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	flags.SetOutput(os.Stdout)
+
+	configFile := flags.String("config", filepath.Join("/opt", app.MODULE, "etc", "config.yaml"), "Specify the path to the config file")
+	timeout := flags.Duration("timeout", 5*time.Second, "Timeout of the healthcheck")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	config, err := loadConfig(*configFile, false)
+	if err != nil {
+		fmt.Printf("unhealthy: %s\n", err.Error())
+		return 1
+	}
+
+	if err = checkReady(instanceURL(config), config.HttpsServer.ApiKey.Value(), *timeout); err != nil {
+		fmt.Printf("unhealthy: %s\n", err.Error())
+		return 1
+	}
+
+	fmt.Println("healthy")
+	return 0
+}
+
+// checkReady calls the readiness endpoint of the instance, it returns an error if the instance isn't ready.
+// The readiness endpoint is public by default, so the request is sent without the api key. Its policy may be
+// overridden in the config file: after 401 Unauthorized the request is repeated with the api key, but only if
+// the instance is on the loopback interface, the server certificate isn't verified.
+func checkReady(baseURL, apiKey string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client := newClient(true, timeout)
+	resp, err := getReady(ctx, client, baseURL, "")
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && apiKey != "" && isLoopback(baseURL) {
+		_ = resp.Body.Close()
+		if resp, err = getReady(ctx, client, baseURL, apiKey); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// getReady sends the request to the readiness endpoint, the api key is only sent if it isn't empty.
+func getReady(ctx context.Context, client *http.Client, baseURL, apiKey string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/ready", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if apiKey != "" {
+		req.Header.Set("X-Api-Key", apiKey)
+	}
+	return client.Do(req)
+}
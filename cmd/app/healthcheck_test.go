@@ -0,0 +1,153 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// readyServer returns a TLS server answering /api/ready with status, if requireKey the api key 12345678 is required.
+// The server listens on the loopback interface if addr is empty. The api keys of the requests are returned by the keys function.
+func readyServer(t *testing.T, addr string, status int, requireKey bool) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var keys []string
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("X-Api-Key"))
+		mu.Unlock()
+
+		if requireKey && r.Header.Get("X-Api-Key") != "12345678" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid api key"}`))
+			return
+		}
+		// problem details as written with errorFormat problem
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"type":"about:blank","title":"Service Unavailable","status":503,"detail":"not ready"}`))
+	}))
+	if addr != "" {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Skip(err)
+		}
+		_ = s.Listener.Close()
+		s.Listener = l
+	}
+	s.Config.ErrorLog = log.New(io.Discard, "", 0)
+	s.StartTLS()
+	t.Cleanup(s.Close)
+
+	return s, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(keys)
+	}
+}
+
+func TestCheckReady(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		requireKey bool
+		apiKey     string
+		localhost  bool
+		wantErr    string
+		wantKeys   []string
+	}{
+		{"public endpoint", http.StatusOK, false, "12345678", false, "", []string{""}},
+		{"not ready", http.StatusServiceUnavailable, false, "12345678", false, "503 Service Unavailable: not ready", []string{""}},
+		{"retry with the api key", http.StatusOK, true, "12345678", false, "", []string{"", "12345678"}},
+		{"retry with the api key on localhost", http.StatusOK, true, "12345678", true, "", []string{"", "12345678"}},
+		{"not ready after the retry", http.StatusServiceUnavailable, true, "12345678", false, "503 Service Unavailable: not ready", []string{"", "12345678"}},
+		{"wrong api key", http.StatusOK, true, "wrong", false, "401 Unauthorized: invalid api key", []string{"", "wrong"}},
+		{"no api key", http.StatusOK, true, "", false, "401 Unauthorized: invalid api key", []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, keys := readyServer(t, "", tt.status, tt.requireKey)
+			url := s.URL
+			if tt.localhost {
+				url = strings.Replace(url, "127.0.0.1", "localhost", 1)
+			}
+
+			err := checkReady(url, tt.apiKey, 5*time.Second)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("checkReady() error = %v, want %q", err, tt.wantErr)
+			}
+			if got := keys(); !slices.Equal(got, tt.wantKeys) {
+				t.Errorf("api keys sent = %q, want %q", got, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestCheckReadyNotLoopback(t *testing.T) {
+	var ip net.IP
+	addrs, _ := net.InterfaceAddrs()
+	for _, a := range addrs {
+		if n, ok := a.(*net.IPNet); ok && n.IP.To4() != nil && !n.IP.IsLoopback() {
+			ip = n.IP
+			break
+		}
+	}
+	if ip == nil {
+		t.Skip("no non-loopback interface address")
+	}
+
+	s, keys := readyServer(t, net.JoinHostPort(ip.String(), "0"), http.StatusOK, true)
+
+	// the api key isn't sent to another host
+	if err := checkReady(s.URL, "12345678", 5*time.Second); err == nil || err.Error() != "401 Unauthorized: invalid api key" {
+		t.Errorf("checkReady() error = %v, want 401 Unauthorized", err)
+	}
+	if got := keys(); !slices.Equal(got, []string{""}) {
+		t.Errorf("api keys sent = %q, want no api key", got)
+	}
+}
+
+func TestCheckReadyUnreachable(t *testing.T) {
+	s, _ := readyServer(t, "", http.StatusOK, false)
+	url := s.URL
+	s.Close()
+
+	if err := checkReady(url, "", time.Second); err == nil {
+		t.Error("checkReady() error = nil, want an error")
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://127.0.0.1:4443", true},
+		{"https://127.0.0.2:4443", true},
+		{"https://[::1]:4443", true},
+		{"https://localhost:4443", true},
+		{"https://LocalHost", true},
+		{"https://192.168.1.10:4443", false},
+		{"https://[::]:4443", false},
+		{"https://example.com:4443", false},
+		{"https://localhost.example.com", false},
+		{"://invalid", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := isLoopback(tt.url); got != tt.want {
+				t.Errorf("isLoopback(%q) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,21 +2,33 @@ package main
 
 import (
 	_ "embed"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/womat/go-api-template/app"
-	"github.com/womat/golib/xlog"
+	"github.com/womat/go-api-template/app/service/logging"
+	"github.com/womat/go-api-template/app/service/requestid"
+	"github.com/womat/go-api-template/app/service/tracing"
 	"gopkg.in/yaml.v3"
 	"log/slog"
 	"os"
 	"path/filepath"
-	"runtime"
 )
 
 //go:embed README.md
 var Readme string
 
 func main() {
+	// run a subcommand, e.g. check
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "check":
+			os.Exit(runCheck(os.Args[2:]))
+		case "healthcheck":
+			os.Exit(runHealthcheck(os.Args[2:]))
+		}
+	}
+
 	// Parse command line flags.
 	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
 	flags.SetOutput(os.Stdout)
@@ -24,6 +36,7 @@ func main() {
 	about := flags.Bool("about", false, "Print app details and exit")
 	help := flags.Bool("help", false, "Print a help message and exit")
 	version := flags.Bool("version", false, "Print the app version and exit")
+	jsonOutput := flags.Bool("json", false, "Print the version and build information as json (with -version)")
 	debug := flags.Bool("debug", false, "Enable debug logging to stdout (overrides log settings from the config file)")
 	configFile := flags.String("config", filepath.Join("/opt", app.MODULE, "etc", "config.yaml"), "Specify the path to the config file")
 
@@ -36,6 +49,12 @@ func main() {
 	case *about:
 		fmt.Println(About())
 		os.Exit(0)
+	case *version && *jsonOutput:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(app.BuildInfo())
+		os.Exit(0)
 	case *version:
 		fmt.Println(app.VERSION)
 		os.Exit(0)
@@ -44,7 +63,10 @@ func main() {
 		os.Exit(0)
 	}
 
-	var logger *xlog.LoggerWrapper
+	var logger *logging.Logger
+
+	// the log buffer keeps the last log records for /api/logs, it survives restarts
+	logBuffer := logging.NewBuffer(0, slog.LevelInfo)
 
 	config, err := loadConfig(*configFile, *debug)
 	if err != nil {
@@ -56,18 +78,27 @@ func main() {
 		// run the app in a function to be able to restart it and reload the config
 		// possible open log files are always closed before the function exits
 		func() {
-			if logger, err = xlog.Init(config.LogDestination, config.LogLevel); err != nil {
+			logBuffer.Configure(config.LogBuffer.Size, config.LogBufferLevel())
+			logCfg := config.Logging()
+			logCfg.Buffer = logBuffer
+
+			if logger, err = logging.Init(logCfg); err != nil {
 				fmt.Printf("Failed to initialize logger: %s\n", err.Error())
 				os.Exit(1)
 			}
 			defer logger.Close()
 
+			// reopen the log files on SIGUSR1
+			stopReopen := logger.HandleReopenSignal()
+			defer stopReopen()
+
 			// set slog logger as default logger
-			slog.SetDefault(logger.Logger)
+			// the tracing and request id log handlers add trace, span and request ids to records logged with a request context
+			slog.SetDefault(slog.New(tracing.NewLogHandler(requestid.NewLogHandler(logger.Handler()))))
 			slog.Info("Logging initialized", "logLevel", config.LogLevel)
 			slog.Debug("Starting with configuration", "config", config)
 
-			a, err := app.New(config).Run()
+			a, err := app.New(config).WithLogBuffer(logBuffer).Run()
 			if err != nil {
 				slog.Error("Critical error occurred, shutting down", "error", err)
 				os.Exit(1)
@@ -91,16 +122,26 @@ func main() {
 }
 
 func About() string {
-	p := map[string]string{
+	info := app.BuildInfo()
+
+	// the build date is the build time set at build time or the time of the vcs revision
+	date := info.BuildTime
+	if date == "" {
+		date = info.RevisionTime
+	}
+
+	p := map[string]any{
 		"Author":   "Wolfgang Mathe",
 		"Binary":   filepath.Join("/opt", app.MODULE, "bin", app.MODULE),
-		"Date":     "2025-02-24",
+		"Date":     date,
 		"Desc":     "Blueprint for Go applications",
 		"Help":     filepath.Join("/opt", app.MODULE, "bin", app.MODULE) + " --help",
 		"Libinfo":  "plain go with go modules from ITdesign golib",
 		"Main":     filepath.Join("/opt/src", app.MODULE, "cmd", app.MODULE, "main.go"),
-		"ProgLang": runtime.Version(),
+		"Platform": info.Os + "/" + info.Arch,
+		"ProgLang": info.GoVersion,
 		"Repo":     "https://github.com/womat/" + app.MODULE + ".git",
+		"Revision": info.ShortRevision(),
 		"Version":  app.VERSION,
 	}
 	b, _ := yaml.Marshal(p)
@@ -118,6 +159,7 @@ func loadConfig(configFile string, debug bool) (*app.Config, error) {
 	if debug {
 		config.LogLevel = "debug"
 		config.LogDestination = "stdout"
+		config.LogSinks = nil
 	}
 
 	return config, nil